@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
@@ -15,16 +17,56 @@ import (
 func NewMigrateCommand(app core.App) *cobra.Command {
 	desc := `
 Supported arguments are:
-- up                 - runs all available migrations.
-- down [number]      - reverts the last [number] applied migrations.
-- create folder name - creates new migration template file.
+- up                 - runs all available migrations (--timings path writes the migrations
+                       and statements durations as tab separated values, --progress collapses
+                       the output of large runs into periodic "Applied N/TOTAL..." lines,
+                       --verbose prints the skipped already applied migrations, --pause-file path
+                       pauses the run between its transactions while the file exists,
+                       --webhook url posts the run events as JSON to the url).
+- down [number]      - reverts the last [number] applied migrations (defaults to 1, -1 reverts all).
+- create folder name - creates new migration template file (or prints it with --stdout,
+                       --no-confirm skips the confirmation).
+                       Use --collection name to scaffold a collection schema change migration.
+                       Use --index path to register the new migration in an index file.
+                       Use --lang sql to create an annotated "-- +up"/"-- +down" SQL migration.
+                       Use --author to add a header with the git author and the creation time.
+- mark-release tag   - records a release marker with the specified tag.
+- rename old new     - renames an applied migration file and its record.
+- run-one file [up|down] - executes only the specified migration (for debugging).
+- export-plan        - prints the pending SQL migrations as a single SQL script.
+- explain file       - prints the SQL statements that the migration would execute (without applying it).
+- list               - prints all migrations grouped by their applied status
+                       (--since duration prints only the ones applied recently, eg. 12h or 7d).
+- pending            - prints the pending migrations names, one per line (--json for JSON array,
+                       --output path to write them to a file, --check to exit with error if any).
+- confirm [files...] - confirms the tentatively applied migrations (all if no files are specified).
+- resume --cleanup|--skip - recovers a failed run by dropping the declared partial objects of the
+                       first pending migration (or by marking it as applied) and applies the pending migrations.
+- adopt              - marks all pending migrations as applied without executing them
+                       (eg. after importing an already migrated db dump, asks for a double confirmation).
+- diff dsn           - prints the applied migrations that differ from the other database.
+- unfloor            - removes the rollback floor recorded by down (or use "up --past-floor").
+- preflight          - checks whether all pending migrations are reversible
+                       (and that the SQL migrations down sections revert their up sections).
+- version            - prints the applied migrations schema version digest.
+- since-last         - prints the elapsed time since the last migration was applied
+                       (--seconds prints it as a number of seconds, eg. for monitoring).
+- backup path        - creates a snapshot of the SQLite db file (also available as "up --backup path").
+- prune duration|date - deletes the reverted history records older than the cutoff (eg. 720h or 2022-06-30).
+
+Command specific flags must be passed after "--", eg. "migrate -- up --allow-out-of-order".
+Use the "--quiet" flag to suppress the success messages, eg. "migrate -- up --quiet".
+Use the "--yes" flag to skip the confirmation prompts, eg. "migrate -- down 2 --yes".
+Use the "--dry-run" flag to print what "up" or "down" would execute, eg. "migrate -- down --count 2 --dry-run".
+Use the "--db" flag to migrate another database file, eg. "migrate --db /path/to/other/data.db up".
 `
 	var databaseFlag string
+	var dbPathFlag string
 
 	command := &cobra.Command{
 		Use:       "migrate",
 		Short:     "Executes DB migration scripts",
-		ValidArgs: []string{"up", "down", "create"},
+		ValidArgs: []string{"up", "down", "create", "mark-release", "rename", "run-one", "export-plan", "explain", "list", "pending", "confirm", "resume", "adopt", "diff", "unfloor", "preflight", "version", "since-last", "backup", "prune"},
 		Long:      desc,
 		Run: func(command *cobra.Command, args []string) {
 			// normalize
@@ -32,17 +74,9 @@ Supported arguments are:
 				databaseFlag = "db"
 			}
 
-			connections := migrationsConnectionsMap(app)
-
-			runner, err := migrate.NewRunner(
-				connections[databaseFlag].DB,
-				connections[databaseFlag].MigrationsList,
-			)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			if err := runner.Run(args...); err != nil {
+			// log.Fatal skips the deferred calls, so it is called
+			// only after the --db database is closed
+			if err := runMigrateCommand(app, databaseFlag, dbPathFlag, args); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -55,6 +89,13 @@ Supported arguments are:
 		"specify the database connection to use (db or logs)",
 	)
 
+	command.PersistentFlags().StringVar(
+		&dbPathFlag,
+		"db",
+		"",
+		"specify an existing SQLite database file to migrate instead of the app one\n(the --database flag selects its migrations)",
+	)
+
 	return command
 }
 
@@ -75,3 +116,39 @@ func migrationsConnectionsMap(app core.App) map[string]migrationsConnection {
 		},
 	}
 }
+
+// runMigrateCommand executes the migrations runner command with the
+// specified database connection (or the --db database file at dbPath).
+func runMigrateCommand(app core.App, database string, dbPath string, args []string) error {
+	connection := migrationsConnectionsMap(app)[database]
+
+	if dbPath != "" {
+		db, err := openMigrationsDB(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		connection.DB = db
+	}
+
+	runner, err := migrate.NewRunner(
+		connection.DB,
+		connection.MigrationsList,
+	)
+	if err != nil {
+		return err
+	}
+
+	return runner.Run(args...)
+}
+
+// openMigrationsDB opens the existing SQLite database file at dbPath
+// with the same driver and connection pragmas as the app databases.
+func openMigrationsDB(dbPath string) (*dbx.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("Failed to open the --db database file: %w", err)
+	}
+
+	return core.ConnectDB(dbPath)
+}
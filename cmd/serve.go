@@ -140,13 +140,15 @@ func NewServeCommand(app core.App, showStartBanner bool) *cobra.Command {
 func runMigrations(app core.App) error {
 	connections := migrationsConnectionsMap(app)
 
-	for _, c := range connections {
-		runner, err := migrate.NewRunner(c.DB, c.MigrationsList)
-		if err != nil {
-			return err
+	for name, c := range connections {
+		configure := func(r *migrate.Runner) {
+			// the target version refers to the app db migrations
+			if name != "db" {
+				r.TargetVersion = ""
+			}
 		}
 
-		if _, err := runner.Up(); err != nil {
+		if _, err := migrate.Bootstrap(c.DB, c.MigrationsList, configure); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,12 @@
+package core
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// ConnectDB opens the SQLite database file at dbPath with the same
+// driver and connection pragmas (eg. WAL journal mode, busy timeout
+// and foreign keys) as the app databases.
+func ConnectDB(dbPath string) (*dbx.DB, error) {
+	return connectDB(dbPath)
+}
@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/dbx"
+)
+
+// Adopt marks all pending migrations as applied without executing
+// their Up funcs (in a single transaction).
+//
+// It is intended for adopting an already migrated database, eg. after
+// importing a production db dump with an empty migrations table.
+// To mark only a single migration as applied use MarkApplied instead.
+//
+// Returns the names of the adopted migrations.
+func (r *Runner) Adopt() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if err := r.checkSets(); err != nil {
+		return nil, err
+	}
+
+	pending, err := r.pendingItems()
+	if err != nil {
+		return nil, err
+	}
+
+	r.startRun()
+
+	adopted := make([]string, 0, len(pending))
+
+	err = r.db.Transactional(func(tx *dbx.Tx) error {
+		for _, m := range pending {
+			if err := r.saveAppliedMigration(tx, m.set, m.File); err != nil {
+				return fmt.Errorf("Failed to save applied migration info for %s: %w", migrationName(m), err)
+			}
+
+			adopted = append(adopted, migrationName(m))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return adopted, nil
+}
+
+// runAdoptCommand handles the "adopt" command, asking for
+// a double confirmation before marking the pending migrations.
+func (r *Runner) runAdoptCommand() error {
+	pending, err := r.PendingMigrations()
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	if len(pending) == 0 {
+		r.printSuccess("No pending migrations to adopt.")
+		return nil
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	if len(applied) > 0 {
+		color.Yellow("The database has already %d applied migration(s) - adopt is intended for databases with an imported schema and empty migrations history.", len(applied))
+	}
+
+	messages := []string{
+		fmt.Sprintf("Do you really want to mark all %d pending migration(s) as applied WITHOUT executing them?", len(pending)),
+		"The schema must already contain all their changes, otherwise the database will be left in an inconsistent state. Are you absolutely sure?",
+	}
+
+	for _, message := range messages {
+		confirm, err := r.Confirm(message)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			fmt.Println("The command has been cancelled")
+			return nil
+		}
+	}
+
+	adopted, err := r.Adopt()
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	for _, name := range adopted {
+		r.printSuccess("Adopted %s (run %s)", name, r.LastRunId())
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerRunAdopt(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	upCalled := false
+	up := func(db dbx.Builder) error {
+		upCalled = true
+		return nil
+	}
+
+	l := MigrationsList{}
+	l.Register(up, nil, "1_test")
+	l.Register(up, nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staging := MigrationsList{}
+	staging.Register(up, nil, "3_seed")
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second confirmation is declined
+	confirmations := []string{}
+	r.Confirm = func(message string) (bool, error) {
+		confirmations = append(confirmations, message)
+		return len(confirmations) < 2, nil
+	}
+
+	if err := r.Run("adopt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(confirmations) != 2 || !strings.Contains(confirmations[0], "all 3 pending migration(s)") {
+		t.Fatalf("Expected 2 confirmations, got %v", confirmations)
+	}
+
+	if total := r.appliedCount(); total != 0 {
+		t.Fatalf("Expected no adopted migrations after a cancelled adopt, got %d", total)
+	}
+
+	r.Confirm = func(message string) (bool, error) {
+		return true, nil
+	}
+
+	if err := r.Run("adopt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if upCalled {
+		t.Fatal("Expected the Up funcs to not be called")
+	}
+
+	for _, m := range []struct{ set, file string }{{"", "1_test"}, {"", "2_test"}, {"staging", "3_seed"}} {
+		if !r.isMigrationApplied(testDB, m.set, m.file) {
+			t.Fatalf("Expected %s/%s to be adopted", m.set, m.file)
+		}
+	}
+
+	adopted, err := r.Adopt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adopted) != 0 {
+		t.Fatalf("Expected no more migrations to adopt, got %v", adopted)
+	}
+
+	r.ReadOnly = true
+	if _, err := r.Adopt(); err != ErrReadOnly {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// valueFlags lists the Runner.Run flags that expect a value
+// (either as the next argument or in the "--name=value" format).
+var valueFlags = []string{
+	"--backup",
+	"--collection",
+	"--count",
+	"--dir",
+	"--index",
+	"--lang",
+	"--output",
+	"--pause-file",
+	"--set",
+	"--since",
+	"--timings",
+	"--webhook",
+}
+
+// RunArgs defines the parsed Runner.Run arguments.
+type RunArgs struct {
+	// Command is the subcommand name (default to "up").
+	Command string
+
+	// Positional is the list with the non-flag arguments
+	// after the subcommand (eg. the "create" migration name).
+	Positional []string
+
+	// Sets is the list with the "--set NAMES" flags set names
+	// (the flag could be repeated and could have comma separated names).
+	Sets []string
+
+	// Yes is the "--yes" flag that skips the confirmation prompts.
+	Yes bool
+
+	// DryRun is the "--dry-run" flag that prints what
+	// the "up" and "down" commands would do without executing them.
+	DryRun bool
+
+	// Count is the "--count N" flag value (0 if not set).
+	Count int
+
+	// Output is the "--output PATH" flag value.
+	Output string
+
+	// Dir is the "--dir PATH" flag value.
+	Dir string
+
+	// flags contains all parsed flags with their values
+	// (empty for the boolean flags).
+	flags map[string]string
+}
+
+// ParseRunArgs parses the Runner.Run arguments into a RunArgs struct.
+//
+// The subcommand is the first non-flag argument. The flags
+// from valueFlags consume the next argument as their value (unless it
+// is another flag) and all other "--" prefixed arguments are treated
+// as boolean flags.
+func ParseRunArgs(args ...string) (RunArgs, error) {
+	result := RunArgs{
+		Command:    "up",
+		Positional: []string{},
+		flags:      map[string]string{},
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "--") {
+			result.Positional = append(result.Positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		isValueFlag := false
+		for _, f := range valueFlags {
+			if f == name {
+				isValueFlag = true
+				break
+			}
+		}
+
+		if isValueFlag && !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			value = args[i+1]
+			i++
+		}
+
+		result.flags[name] = value
+
+		switch name {
+		case "--set":
+			for _, set := range strings.Split(value, ",") {
+				if set = strings.TrimSpace(set); set != "" {
+					result.Sets = append(result.Sets, set)
+				}
+			}
+		case "--yes":
+			result.Yes = true
+		case "--dry-run":
+			result.DryRun = true
+		case "--count":
+			count, err := cast.ToIntE(value)
+			if err != nil || count == 0 {
+				return result, fmt.Errorf("Invalid --count value %q (expected a non-zero number)", value)
+			}
+			result.Count = count
+		case "--output":
+			result.Output = value
+		case "--dir":
+			result.Dir = value
+		}
+	}
+
+	if len(result.Positional) > 0 {
+		result.Command = result.Positional[0]
+		result.Positional = result.Positional[1:]
+	}
+
+	return result, nil
+}
+
+// Has reports whether the specified flag (eg. "--json") was set.
+func (a RunArgs) Has(flag string) bool {
+	_, ok := a.flags[flag]
+
+	return ok
+}
+
+// Value returns the value of the specified flag (eg. "--since")
+// and whether the flag was set.
+func (a RunArgs) Value(flag string) (string, bool) {
+	value, ok := a.flags[flag]
+
+	return value, ok
+}
+
+// Arg returns the i-th positional argument (or empty string if missing).
+func (a RunArgs) Arg(i int) string {
+	if i < 0 || i >= len(a.Positional) {
+		return ""
+	}
+
+	return a.Positional[i]
+}
+
+// revertCount returns the "down" command revert count from the
+// "--count" flag or the first positional argument (default to 1).
+func (a RunArgs) revertCount() (int, error) {
+	if a.Count != 0 {
+		return a.Count, nil
+	}
+
+	return parseRevertCount(a.Positional)
+}
@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestParseRunArgs(t *testing.T) {
+	scenarios := []struct {
+		args               []string
+		expectError        bool
+		expectedCommand    string
+		expectedPositional []string
+		expectedSets       []string
+		expectedYes        bool
+		expectedDryRun     bool
+		expectedCount      int
+		expectedOutput     string
+		expectedDir        string
+	}{
+		{nil, false, "up", nil, nil, false, false, 0, "", ""},
+		{[]string{"--verbose"}, false, "up", nil, nil, false, false, 0, "", ""},
+		{[]string{"down", "-1"}, false, "down", []string{"-1"}, nil, false, false, 0, "", ""},
+		{[]string{"down", "--count", "2", "--yes", "--dry-run"}, false, "down", nil, nil, true, true, 2, "", ""},
+		{[]string{"down", "--count=-1"}, false, "down", nil, nil, false, false, -1, "", ""},
+		{[]string{"down", "--count", "abc"}, true, "down", nil, nil, false, false, 0, "", ""},
+		{[]string{"down", "--count", "0"}, true, "down", nil, nil, false, false, 0, "", ""},
+		{[]string{"create", "--collection", "posts", "add_title", "--dir=migrations"}, false, "create", []string{"add_title"}, nil, false, false, 0, "", "migrations"},
+		{[]string{"--set", "a,b", "pending", "--set=c", "--output", "pending.txt", "--json"}, false, "pending", nil, []string{"a", "b", "c"}, false, false, 0, "pending.txt", ""},
+		{[]string{"rename", "old.go", "--quiet", "new.go"}, false, "rename", []string{"old.go", "new.go"}, nil, false, false, 0, "", ""},
+		// a value flag followed by another flag has an empty value
+		{[]string{"up", "--timings", "--progress"}, false, "up", nil, nil, false, false, 0, "", ""},
+	}
+
+	for i, s := range scenarios {
+		a, err := ParseRunArgs(s.args...)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+		if hasErr {
+			continue
+		}
+
+		if a.Command != s.expectedCommand {
+			t.Errorf("(%d) Expected command %q, got %q", i, s.expectedCommand, a.Command)
+		}
+
+		if strings.Join(a.Positional, ",") != strings.Join(s.expectedPositional, ",") {
+			t.Errorf("(%d) Expected positional %v, got %v", i, s.expectedPositional, a.Positional)
+		}
+
+		if strings.Join(a.Sets, ",") != strings.Join(s.expectedSets, ",") {
+			t.Errorf("(%d) Expected sets %v, got %v", i, s.expectedSets, a.Sets)
+		}
+
+		if a.Yes != s.expectedYes || a.DryRun != s.expectedDryRun || a.Count != s.expectedCount {
+			t.Errorf("(%d) Expected yes %v, dry-run %v and count %d, got %v, %v and %d", i, s.expectedYes, s.expectedDryRun, s.expectedCount, a.Yes, a.DryRun, a.Count)
+		}
+
+		if a.Output != s.expectedOutput || a.Dir != s.expectedDir {
+			t.Errorf("(%d) Expected output %q and dir %q, got %q and %q", i, s.expectedOutput, s.expectedDir, a.Output, a.Dir)
+		}
+	}
+
+	a, _ := ParseRunArgs("up", "--timings", "--progress")
+	if value, ok := a.Value("--timings"); !ok || value != "" {
+		t.Fatalf("Expected empty --timings value, got %q (%v)", value, ok)
+	}
+	if !a.Has("--progress") || a.Has("--verbose") {
+		t.Fatal("Expected only the --progress boolean flag to be set")
+	}
+	if a.Arg(0) != "" || a.Arg(-1) != "" {
+		t.Fatal("Expected empty missing positional args")
+	}
+}
+
+func TestRunnerRunYesAndDryRun(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Confirm = func(message string) (bool, error) {
+		t.Fatalf("Unexpected confirmation prompt %q", message)
+		return false, nil
+	}
+
+	output := captureStdout(t, func() {
+		if err := r.Run("up", "--dry-run"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if expected := "Would apply 1_test\nWould apply 2_test\nWould apply 3_test\n"; output != expected {
+		t.Fatalf("Expected dry-run output %q, got %q", expected, output)
+	}
+
+	if total := r.appliedCount(); total != 0 {
+		t.Fatalf("Expected no applied migrations after the dry-run, got %d", total)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	output = captureStdout(t, func() {
+		if err := r.Run("down", "--count", "2", "--dry-run"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if expected := "Would revert 3_test\nWould revert 2_test\n"; output != expected {
+		t.Fatalf("Expected dry-run output %q, got %q", expected, output)
+	}
+
+	if err := r.Run("down", "--count=2", "--yes", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+
+	if total := r.appliedCount(); total != 1 {
+		t.Fatalf("Expected 1 applied migration, got %d", total)
+	}
+}
+
+// captureStdout returns the os.Stdout output of fn.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	original := os.Stdout
+	defer func() { os.Stdout = original }()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+
+	fn()
+
+	writer.Close()
+
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(out)
+}
@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitConfigValue returns the trimmed value of the specified git config
+// key (eg. "user.name") resolved from dir (or empty string on error).
+//
+// It is a variable to allow mocking the git lookup in the tests.
+var gitConfigValue = func(dir string, key string) string {
+	cmd := exec.Command("git", "config", "--get", key)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		cmd.Dir = dir // for the repository specific config
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// migrationAuthor returns the "create" migration author identity in the
+// format "Name <email>" from the standard GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL
+// env variables, falling back to the git "user.name" and "user.email" config.
+//
+// Returns empty string if neither the name nor the email are available.
+func migrationAuthor(dir string) string {
+	name := os.Getenv("GIT_AUTHOR_NAME")
+	if name == "" {
+		name = gitConfigValue(dir, "user.name")
+	}
+
+	email := os.Getenv("GIT_AUTHOR_EMAIL")
+	if email == "" {
+		email = gitConfigValue(dir, "user.email")
+	}
+
+	switch {
+	case name != "" && email != "":
+		return name + " <" + email + ">"
+	case email != "":
+		return "<" + email + ">"
+	default:
+		return name
+	}
+}
+
+// authorHeader returns the "create" migration comment header with
+// the author identity (if available) and the RFC3339 creation time
+// (see Runner.AnnotateAuthor).
+func (r *Runner) authorHeader(dir string) string {
+	var b strings.Builder
+
+	prefix := r.commentPrefix()
+
+	if author := migrationAuthor(dir); author != "" {
+		b.WriteString(prefix + "Author: " + author + "\n")
+	}
+	b.WriteString(prefix + "Created: " + r.now().UTC().Format(time.RFC3339) + "\n\n")
+
+	return b.String()
+}
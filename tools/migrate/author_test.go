@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationAuthor(t *testing.T) {
+	defer func(original func(dir string, key string) string) { gitConfigValue = original }(gitConfigValue)
+
+	gitConfig := map[string]string{}
+	gitConfigValue = func(dir string, key string) string {
+		return gitConfig[key]
+	}
+
+	scenarios := []struct {
+		envName  string
+		envEmail string
+		gitName  string
+		gitEmail string
+		expected string
+	}{
+		{"", "", "", "", ""},
+		{"", "", "git", "git@example.com", "git <git@example.com>"},
+		{"env", "", "git", "git@example.com", "env <git@example.com>"},
+		{"env", "env@example.com", "git", "git@example.com", "env <env@example.com>"},
+		{"", "", "git", "", "git"},
+		{"", "", "", "git@example.com", "<git@example.com>"},
+	}
+
+	for i, s := range scenarios {
+		t.Setenv("GIT_AUTHOR_NAME", s.envName)
+		t.Setenv("GIT_AUTHOR_EMAIL", s.envEmail)
+		gitConfig["user.name"] = s.gitName
+		gitConfig["user.email"] = s.gitEmail
+
+		if v := migrationAuthor(t.TempDir()); v != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, v)
+		}
+	}
+}
+
+func TestRunnerRunCreateAuthor(t *testing.T) {
+	defer func(original func(dir string, key string) string) { gitConfigValue = original }(gitConfigValue)
+	gitConfigValue = func(dir string, key string) string { return "" }
+
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+	r.Quiet = true
+	r.Now = func() time.Time {
+		return time.Date(2022, 6, 30, 12, 30, 0, 0, time.UTC)
+	}
+
+	scenarios := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"create", "init"}, createTemplateContent},
+		{[]string{"create", "init", "--author"}, "// Author: Test <test@example.com>\n// Created: 2022-06-30T12:30:00Z\n\n" + createTemplateContent},
+		{[]string{"create", "init", "--author", "--lang", "sql"}, "-- Author: Test <test@example.com>\n-- Created: 2022-06-30T12:30:00Z\n\n" + createSQLTemplateContent},
+	}
+
+	for i, s := range scenarios {
+		dir := t.TempDir()
+
+		if err := r.Run(append(s.args, dir)...); err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*_init.*"))
+		if len(files) != 1 {
+			t.Fatalf("(%d) Expected 1 created file, got %v", i, files)
+		}
+
+		content, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != s.expected {
+			t.Errorf("(%d) Expected content\n%s\ngot\n%s", i, s.expected, content)
+		}
+	}
+
+	// AnnotateAuthor
+	r.AnnotateAuthor = true
+
+	output := captureStdout(t, func() {
+		if err := r.Run("create", "init", t.TempDir(), "--stdout"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(output, "// Author: Test <test@example.com>\n") {
+		t.Fatalf("Expected the author header, got\n%s", output)
+	}
+}
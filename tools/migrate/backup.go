@@ -0,0 +1,99 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pocketbase/dbx"
+)
+
+// Backup creates a snapshot of the runner db at the specified
+// destination path using the SQLite "VACUUM INTO" statement, so that
+// the db could be restored by replacing its file with the snapshot
+// in case of a failed or lossy migration.
+//
+// If the destination file already exists, it is replaced only if overwrite is set.
+//
+// NB! This works only with file based SQLite databases.
+func (r *Runner) Backup(path string, overwrite bool) error {
+	if driver := r.db.DriverName(); driver != "sqlite" && driver != "sqlite3" {
+		return fmt.Errorf("Backups are supported only for SQLite databases (got %q)", driver)
+	}
+
+	var dbFile string
+	err := r.db.NewQuery("SELECT [[file]] FROM pragma_database_list WHERE [[name]] = 'main'").Row(&dbFile)
+	if err != nil {
+		return err
+	}
+	if dbFile == "" {
+		return errors.New("Backups are supported only for file based SQLite databases")
+	}
+
+	if path == "" {
+		return errors.New("Missing backup destination path")
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("Backup destination %q is a directory", path)
+		}
+		if !overwrite {
+			return fmt.Errorf("Backup destination %q already exists", path)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// check whether the destination dir is writable
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".backup_*")
+	if err != nil {
+		return fmt.Errorf("Backup destination %q is not writable: %w", path, err)
+	}
+	tmp.Close()
+
+	// VACUUM INTO requires a non existing or empty destination file
+	// so the snapshot is created next to it and then moved in its place
+	if err := os.Remove(tmp.Name()); err != nil {
+		return err
+	}
+
+	_, err = r.db.NewQuery("VACUUM INTO {:path}").Bind(dbx.Params{"path": tmp.Name()}).Execute()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Failed to backup the database: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// backupWithConfirm creates a Backup of the runner db at the specified
+// destination path, asking for confirmation if it already exists.
+//
+// Returns false if the overwrite was not confirmed.
+func (r *Runner) backupWithConfirm(path string) (bool, error) {
+	overwrite := false
+
+	if _, err := os.Stat(path); err == nil {
+		confirm, err := r.Confirm(fmt.Sprintf("Do you really want to overwrite the existing backup %q?", path))
+		if err != nil {
+			return false, err
+		}
+		if !confirm {
+			return false, nil
+		}
+		overwrite = true
+	}
+
+	if err := r.Backup(path, overwrite); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,139 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerBackup(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := dbx.Open("sqlite", filepath.Join(dir, "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	r, err := NewRunner(db, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.saveAppliedMigration(db, "", "1_test")
+
+	existing := filepath.Join(dir, "existing.db")
+	if err := os.WriteFile(existing, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		path        string
+		overwrite   bool
+		expectError bool
+	}{
+		{"", false, true},
+		{dir, true, true},
+		{filepath.Join(dir, "missing", "backup.db"), false, true},
+		{existing, false, true},
+		{existing, true, false},
+		{filepath.Join(dir, "backup.db"), false, false},
+	}
+
+	for i, s := range scenarios {
+		err := r.Backup(s.path, s.overwrite)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if hasErr {
+			continue
+		}
+
+		backup, err := dbx.Open("sqlite", s.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var total int
+		err = backup.Select("count(*)").From(r.TableName).Row(&total)
+		backup.Close()
+		if err != nil || total != 1 {
+			t.Errorf("(%d) Expected the backup to have 1 migration record, got %d (%v)", i, total, err)
+		}
+	}
+
+	// in-memory db
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r2, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.Backup(filepath.Join(dir, "memory.db"), false); err == nil {
+		t.Fatal("Expected in-memory db backup error")
+	}
+}
+
+func TestRunnerRunUpBackupConfirm(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := dbx.Open("sqlite", filepath.Join(dir, "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	r, err := NewRunner(db, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath := filepath.Join(dir, "backup.db")
+	if err := os.WriteFile(backupPath, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Confirm = func(message string) (bool, error) { return false, nil }
+
+	if err := r.Run("up", "--backup", backupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, _ := r.IsApplied("1_test"); applied {
+		t.Fatal("Expected the up command to be cancelled")
+	}
+
+	r.Confirm = func(message string) (bool, error) { return true, nil }
+
+	if err := r.Run("up", "--backup", backupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, _ := r.IsApplied("1_test"); !applied {
+		t.Fatal("Expected 1_test to be applied")
+	}
+
+	// the backup should be created before the migrations run
+	backup, err := dbx.Open("sqlite", backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+
+	var total int
+	if err := backup.Select("count(*)").From(r.TableName).Row(&total); err != nil || total != 0 {
+		t.Fatalf("Expected the backup to have no migration records, got %d (%v)", total, err)
+	}
+}
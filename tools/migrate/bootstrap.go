@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/dbx"
+)
+
+// ErrNonInteractive is returned by the Bootstrap runner Confirm func.
+var ErrNonInteractive = errors.New("The migrations runner is non-interactive and cannot ask for confirmation")
+
+// Bootstrap runs all unapplied migrations of the provided list with
+// options appropriate for executing them on every app start, eg.:
+//
+//	if _, err := migrate.Bootstrap(app.DB(), migrations.AppMigrations); err != nil {
+//		log.Fatal(err) // refuse to start with an outdated schema
+//	}
+//
+// It creates a Runner that:
+//   - never prompts (any confirmation fails with ErrNonInteractive)
+//   - holds the migrations lock while applying the migrations, so that
+//     the concurrent Bootstrap calls of different processes (eg. multiple
+//     app instances sharing the same db) apply them only once
+//     (waits up to Runner.LockTimeout for the lock of another process
+//     and takes over a lock not refreshed for longer than Runner.LockTTL,
+//     eg. of a crashed process)
+//   - retries the tracking queries of a busy db (see Runner.TrackingRetries)
+//   - prints the applied migrations (unless configured as Quiet)
+//   - applies only the migrations up to the TargetVersionEnv env
+//     variable version (if set, see Runner.TargetVersion)
+//
+// The runner could be further customized with the optConfigure funcs
+// (same as NewRunner).
+//
+// Returns the applied migrations file names or the first error
+// that the caller should use to abort the app start.
+func Bootstrap(db *dbx.DB, migrationsList Migrations, optConfigure ...func(r *Runner)) ([]string, error) {
+	configure := append([]func(r *Runner){
+		func(r *Runner) {
+			r.Confirm = func(message string) (bool, error) {
+				return false, ErrNonInteractive
+			}
+			r.TargetVersion = os.Getenv(TargetVersionEnv)
+		},
+	}, optConfigure...)
+
+	runner, err := NewRunner(db, migrationsList, configure...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to initialize the migrations runner: %w", err)
+	}
+	defer runner.Close()
+
+	if runner.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	release, err := runner.acquireLock(runner.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := release(); err != nil {
+			color.Yellow("Failed to release the migrations lock: %v", err)
+		}
+	}()
+
+	applied, err := runner.Up()
+	if err != nil {
+		return applied, err
+	}
+
+	for _, file := range applied {
+		runner.printSuccess("Applied %s (run %s)", file, runner.LastRunId())
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,256 @@
+package migrate
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestBootstrap(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	var runner *Runner
+	quiet := func(r *Runner) {
+		r.Quiet = true
+		runner = r
+	}
+
+	applied, err := Bootstrap(testDB.DB, l, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %v", applied)
+	}
+
+	if _, err := runner.Confirm("test"); !errors.Is(err, ErrNonInteractive) {
+		t.Fatalf("Expected ErrNonInteractive confirmation, got %v", err)
+	}
+
+	// idempotent restart
+	applied, err = Bootstrap(testDB.DB, l, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("Expected no applied migrations, got %v", applied)
+	}
+
+	// failure
+	l.Register(func(db dbx.Builder) error { return errors.New("test error") }, nil, "3_test")
+	if _, err := Bootstrap(testDB.DB, l, quiet); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// invalid configuration
+	if _, err := Bootstrap(testDB.DB, l, func(r *Runner) { r.TableName = "invalid name" }); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+func TestBootstrapConcurrent(t *testing.T) {
+	// same as the app db pragmas
+	dsn := filepath.Join(t.TempDir(), "data.db") + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	var calls int32
+
+	l := MigrationsList{}
+	for _, file := range []string{"1_test", "2_test"} {
+		l.Register(func(db dbx.Builder) error {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}, nil, file)
+	}
+
+	quiet := func(r *Runner) { r.Quiet = true }
+
+	// simulate multiple processes with separate connection pools
+	total := 3
+	results := make([][]string, total)
+	errs := make([]error, total)
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		db, err := dbx.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		wg.Add(1)
+		go func(i int, db *dbx.DB) {
+			defer wg.Done()
+			results[i], errs[i] = Bootstrap(db, l, quiet)
+		}(i, db)
+	}
+	wg.Wait()
+
+	applied := 0
+	for i := 0; i < total; i++ {
+		if errs[i] != nil {
+			t.Fatalf("(%d) Expected nil error, got %v", i, errs[i])
+		}
+		applied += len(results[i])
+	}
+
+	if applied != 2 || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("Expected each migration to be applied once, got %d applied and %d calls", applied, calls)
+	}
+}
+
+func TestBootstrapLockTimeout(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	var runner *Runner
+	configure := func(r *Runner) {
+		r.Quiet = true
+		r.LockTimeout = 50 * time.Millisecond
+		runner = r
+	}
+
+	// releases the lock after the run
+	if _, err := Bootstrap(testDB.DB, l, configure); err != nil {
+		t.Fatal(err)
+	}
+
+	var locks int
+	testDB.Select("count(*)").From(runner.lockTable()).Row(&locks)
+	if locks != 0 {
+		t.Fatalf("Expected the lock to be released, got %d lock records", locks)
+	}
+
+	// lock held (and recently refreshed) by another process
+	_, err = testDB.Insert(runner.lockTable(), dbx.Params{"id": lockId, "owner": "other", "acquired": time.Now().UnixNano()}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "2_test")
+
+	applied, err := Bootstrap(testDB.DB, l, configure)
+	if err == nil || !strings.Contains(err.Error(), `held by "other"`) {
+		t.Fatalf("Expected lock timeout error, got %v", err)
+	}
+	if len(applied) != 0 || runner.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatalf("Expected no applied migrations, got %v", applied)
+	}
+}
+
+func TestBootstrapStaleLockTakeover(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	var runner *Runner
+	configure := func(r *Runner) {
+		r.Quiet = true
+		r.LockTimeout = 50 * time.Millisecond
+		r.LockTTL = time.Minute
+		runner = r
+	}
+
+	if _, err := NewRunner(testDB.DB, l, configure); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.createLockTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	// lock of a crashed process that is no longer refreshed
+	_, err = testDB.Insert(runner.lockTable(), dbx.Params{
+		"id":       lockId,
+		"owner":    "crashed",
+		"acquired": time.Now().Add(-2 * time.Minute).UnixNano(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Bootstrap(testDB.DB, l, configure)
+	if err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+	}
+	if len(applied) != 1 || !runner.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatalf("Expected 1_test to be applied, got %v", applied)
+	}
+
+	var locks int
+	testDB.Select("count(*)").From(runner.lockTable()).Row(&locks)
+	if locks != 0 {
+		t.Fatalf("Expected the lock to be released, got %d lock records", locks)
+	}
+}
+
+func TestAcquireLockRefresh(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+		r.LockTTL = 80 * time.Millisecond
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := r.acquireLock(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// outlive the TTL a few times while the lock is refreshed
+	time.Sleep(4 * r.LockTTL)
+
+	holder, err := r.lockHolder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder == nil || r.isStaleLock(holder) {
+		t.Fatalf("Expected a refreshed lock, got %v", holder)
+	}
+
+	// the refreshed lock is not taken over
+	if _, err := r.acquireLock(50 * time.Millisecond); err == nil {
+		t.Fatal("Expected lock timeout error, got nil")
+	}
+
+	if err := release(); err != nil {
+		t.Fatal(err)
+	}
+
+	holder, err = r.lockHolder()
+	if err != nil || holder != nil {
+		t.Fatalf("Expected the lock to be released, got %v (%v)", holder, err)
+	}
+}
@@ -0,0 +1,122 @@
+package migrate
+
+import "github.com/pocketbase/dbx"
+
+// checkpointItems returns the provided ordered migrations starting
+// from the latest checkpoint of each set (see Migration.Checkpoint):
+//   - if the checkpoint is already applied, the migrations before it are
+//     excluded without checking their state
+//   - if none of the set migrations are applied (aka. a fresh database),
+//     the migrations before the checkpoint are excluded and the
+//     checkpoint Up func produces their state
+//   - otherwise the migrations before the checkpoint are kept and the
+//     checkpoint is only recorded as applied after them (without executing
+//     its Up func because the earlier migrations already produced its state)
+//
+// The migrations are returned unchanged if Runner.UseCheckpoints is not set.
+func (r *Runner) checkpointItems(migrations []*Migration) ([]*Migration, error) {
+	if !r.UseCheckpoints {
+		return migrations, nil
+	}
+
+	// the index of the latest checkpoint of each set
+	checkpoints := map[string]int{}
+	for i, m := range migrations {
+		if m.Checkpoint {
+			checkpoints[m.set] = i
+		}
+	}
+
+	if len(checkpoints) == 0 {
+		return migrations, nil
+	}
+
+	applied, err := r.appliedNames()
+	if err != nil {
+		return nil, err
+	}
+
+	// the sets with at least one applied migration
+	started := map[string]bool{}
+	for _, m := range migrations {
+		if _, ok := applied[migrationName(m)]; ok {
+			started[m.set] = true
+		}
+	}
+
+	result := make([]*Migration, 0, len(migrations))
+
+	for i, m := range migrations {
+		checkpoint, hasCheckpoint := checkpoints[m.set]
+		if !hasCheckpoint {
+			result = append(result, m)
+			continue
+		}
+
+		_, checkpointApplied := applied[migrationName(migrations[checkpoint])]
+
+		switch {
+		case i < checkpoint && (checkpointApplied || !started[m.set]):
+			// before the checkpoint
+		case i == checkpoint && !checkpointApplied && started[m.set]:
+			result = append(result, recordOnly(m))
+		default:
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+// checkpointDownItems returns the provided ordered migrations with the
+// checkpoints that follow applied migrations of their set replaced with
+// record only copies, so that reverting them doesn't execute their Down
+// func (the state is reverted by the Down funcs of the earlier migrations).
+//
+// The migrations are returned unchanged if Runner.UseCheckpoints is not set.
+func (r *Runner) checkpointDownItems(migrations []*Migration) ([]*Migration, error) {
+	if !r.UseCheckpoints {
+		return migrations, nil
+	}
+
+	applied, err := r.appliedNames()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Migration, len(migrations))
+
+	// the sets with at least one applied migration before the current one
+	started := map[string]bool{}
+
+	for i, m := range migrations {
+		result[i] = m
+
+		if m.Checkpoint && started[m.set] {
+			result[i] = recordOnly(m)
+		}
+
+		if _, ok := applied[migrationName(m)]; ok {
+			started[m.set] = true
+		}
+	}
+
+	return result, nil
+}
+
+// recordOnly returns a copy of the provided migration that is only
+// recorded as applied or reverted without executing its funcs.
+func recordOnly(m *Migration) *Migration {
+	noop := func(db dbx.Builder) error {
+		return nil
+	}
+
+	recorded := *m
+	recorded.isSQL = false
+	recorded.Validate = nil
+	recorded.IrreversibleReason = ""
+	recorded.Up = noop
+	recorded.Down = noop
+
+	return &recorded
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerUseCheckpoints(t *testing.T) {
+	calls := []string{}
+	callsFunc := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	newList := func() MigrationsList {
+		l := MigrationsList{}
+		l.Register(callsFunc("1_up"), callsFunc("1_down"), "1_test")
+		l.Register(callsFunc("2_up"), callsFunc("2_down"), "2_test")
+		l.Register(callsFunc("3_up"), callsFunc("3_down"), "3_checkpoint")
+		l.Register(callsFunc("4_up"), callsFunc("4_down"), "4_test")
+		l.Item(2).Checkpoint = true
+		return l
+	}
+
+	scenarios := []struct {
+		name            string
+		useCheckpoints  bool
+		applied         []string
+		expectedCalls   string
+		expectedDown    string
+		expectedApplied []string
+		expectedMissing []string
+	}{
+		{
+			"disabled",
+			false,
+			nil,
+			"1_up,2_up,3_up,4_up",
+			"4_down,3_down,2_down,1_down",
+			[]string{"1_test", "2_test", "3_checkpoint", "4_test"},
+			nil,
+		},
+		{
+			"fresh db",
+			true,
+			nil,
+			"3_up,4_up",
+			"4_down,3_down",
+			[]string{"3_checkpoint", "4_test"},
+			[]string{"1_test", "2_test"},
+		},
+		{
+			"partially migrated db",
+			true,
+			[]string{"1_test"},
+			"2_up,4_up",
+			"4_down,2_down,1_down",
+			[]string{"1_test", "2_test", "3_checkpoint", "4_test"},
+			nil,
+		},
+		{
+			"applied checkpoint",
+			true,
+			[]string{"3_checkpoint"},
+			"4_up",
+			"4_down,3_down",
+			[]string{"3_checkpoint", "4_test"},
+			[]string{"1_test", "2_test"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			testDB, err := createTestDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer testDB.Close()
+
+			r, err := NewRunner(testDB.DB, newList())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.UseCheckpoints = s.useCheckpoints
+
+			for _, file := range s.applied {
+				r.saveAppliedMigration(testDB, "", file)
+			}
+
+			// the skipped migrations are not reported as pending
+			pending, err := r.PendingMigrations()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			calls = []string{}
+
+			applied, err := r.Up()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(calls, ",") != s.expectedCalls {
+				t.Fatalf("Expected calls %s, got %v", s.expectedCalls, calls)
+			}
+
+			if len(applied) != len(pending) {
+				t.Fatalf("Expected the pending migrations %v to be applied, got %v", pending, applied)
+			}
+
+			for _, file := range s.expectedApplied {
+				if !r.isMigrationApplied(testDB, "", file) {
+					t.Fatalf("Expected %s to be applied", file)
+				}
+			}
+
+			for _, file := range s.expectedMissing {
+				if r.isMigrationApplied(testDB, "", file) {
+					t.Fatalf("Expected %s to not be applied", file)
+				}
+			}
+
+			// down past the checkpoint
+			calls = []string{}
+			if _, err := r.Down(r.appliedCount()); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(calls, ",") != s.expectedDown {
+				t.Fatalf("Expected down calls %s, got %v", s.expectedDown, calls)
+			}
+
+			if total := r.appliedCount(); total != 0 {
+				t.Fatalf("Expected all migrations to be reverted, got %d", total)
+			}
+		})
+	}
+}
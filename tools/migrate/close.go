@@ -0,0 +1,25 @@
+package migrate
+
+import "errors"
+
+// ErrClosed is returned when trying to run migrations with a closed Runner.
+var ErrClosed = errors.New("The migrations runner is closed")
+
+// Close releases the resources held by the runner and marks it
+// as closed, making the next migrations runs fail with ErrClosed.
+//
+// It waits for the in-progress run of the same Runner instance (if any)
+// and it is safe to call it multiple times (eg. after a failed Up).
+//
+// Note that the db passed to NewRunner is owned by the caller
+// and it is not closed.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	r.tx = nil
+	r.timings = nil
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// Deduplicate removes the duplicated applied records of the same
+// migration (eg. left by a crash or a concurrent run with a migrations
+// table without primary key), keeping only the earliest applied one.
+//
+// Returns the number of the deleted records.
+func (r *Runner) Deduplicate() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	var total int64
+
+	err := r.withTrackingRetry(func() error {
+		total = 0
+
+		return r.db.Transactional(func(tx *dbx.Tx) error {
+			duplicates := []struct {
+				File  string `db:"file"`
+				Set   string `db:"set"`
+				Total int64  `db:"total"`
+			}{}
+
+			err := tx.Select("file", "set", r.Dialect.CountExp()+" AS total").
+				From(r.TableName).
+				Where(appliedRecordExp()).
+				GroupBy("file", "set").
+				Having(dbx.NewExp(r.Dialect.CountExp() + " > 1")).
+				All(&duplicates)
+			if err != nil {
+				return err
+			}
+
+			for _, d := range duplicates {
+				exp := dbx.And(dbx.HashExp{"file": d.File, "set": d.Set}, appliedRecordExp())
+
+				// the duplicated records could have the same apply time
+				// so the earliest one is reinserted instead of deleting
+				// the others by their apply time
+				earliest := AppliedMigration{}
+				err := tx.Select("file", "applied", "applied_by", "set", "run_id", "confirmed", "log").
+					From(r.TableName).
+					Where(exp).
+					OrderBy("applied ASC").
+					Limit(1).
+					One(&earliest)
+				if err != nil {
+					return err
+				}
+
+				if _, err := tx.Delete(r.TableName, exp).Execute(); err != nil {
+					return err
+				}
+
+				_, err = tx.Insert(r.TableName, dbx.Params{
+					"file":       earliest.File,
+					"applied":    earliest.Applied,
+					"applied_by": earliest.AppliedBy,
+					"set":        earliest.Set,
+					"run_id":     earliest.RunId,
+					"confirmed":  earliest.Confirmed,
+					"log":        earliest.Log,
+				}).Execute()
+				if err != nil {
+					return err
+				}
+
+				total += d.Total - 1
+			}
+
+			return nil
+		})
+	})
+
+	return total, err
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerSaveAppliedMigrationNoDuplicates(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate 2 concurrent runs recording the same migration
+	if err := r.saveAppliedMigrationAt(testDB, "", "1_test", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.saveAppliedMigrationAt(testDB, "", "1_test", 200); err == nil {
+		t.Fatal("Expected the duplicated insert to fail")
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 1 || applied[0].Applied != 100 {
+		t.Fatalf("Expected only the earliest 1_test record, got %v", applied)
+	}
+}
+
+func TestRunnerDeduplicate(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// migrations table without primary key
+	if _, err := testDB.NewQuery("CREATE TABLE legacy (file TEXT NOT NULL, applied INTEGER NOT NULL)").Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) { r.TableName = "legacy" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []dbx.Params{
+		{"file": "1_test", "applied": 300, "applied_by": "c"},
+		{"file": "1_test", "applied": 100, "applied_by": "a"},
+		{"file": "1_test", "applied": 100, "applied_by": "b"},
+		{"file": "2_test", "applied": 400},
+		{"file": "3_test", "applied": 500},
+		{"file": "3_test", "applied": 600},
+		{"file": "3_test", "applied": 50, "reverted": 60},
+	}
+	for _, p := range records {
+		if _, err := testDB.Insert("legacy", p).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, err := r.Deduplicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 3 {
+		t.Fatalf("Expected 3 deleted records, got %d", total)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		file    string
+		applied int64
+	}{
+		{"1_test", 100},
+		{"2_test", 400},
+		{"3_test", 500},
+	}
+
+	if len(applied) != len(expected) {
+		t.Fatalf("Expected %d applied records, got %v", len(expected), applied)
+	}
+
+	for i, e := range expected {
+		if applied[i].File != e.file || applied[i].Applied != e.applied {
+			t.Errorf("(%d) Expected %s applied at %d, got %v", i, e.file, e.applied, applied[i])
+		}
+	}
+
+	var reverted int
+	testDB.Select("count(*)").From("legacy").Where(dbx.NewExp("[[reverted]] > 0")).Row(&reverted)
+	if reverted != 1 {
+		t.Fatalf("Expected the reverted record to be kept, got %d", reverted)
+	}
+
+	// nothing to deduplicate
+	if total, err := r.Deduplicate(); err != nil || total != 0 {
+		t.Fatalf("Expected 0 deleted records, got %d (%v)", total, err)
+	}
+}
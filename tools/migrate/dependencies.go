@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// orderedItems returns the runner migrations in their apply order,
+// taking into account the declared dependencies if DependencyOrder is set.
+func (r *Runner) orderedItems() ([]*Migration, error) {
+	items := r.items()
+
+	if !r.DependencyOrder {
+		return items, nil
+	}
+
+	return sortByDependencies(items)
+}
+
+// sortByDependencies topologically sorts the provided migrations
+// based on their DependsOn declarations, preserving the original
+// order of the migrations that don't depend on each other.
+//
+// A dependency is resolved to the migration with the same file name
+// from the same set, falling back to the default set.
+func sortByDependencies(migrations []*Migration) ([]*Migration, error) {
+	index := make(map[string]*Migration, len(migrations))
+	for _, m := range migrations {
+		index[m.set+"/"+m.File] = m
+	}
+
+	deps := make(map[*Migration][]*Migration, len(migrations))
+	for _, m := range migrations {
+		for _, file := range m.DependsOn {
+			dep, ok := index[m.set+"/"+file]
+			if !ok {
+				dep, ok = index["/"+file]
+			}
+			if !ok {
+				return nil, fmt.Errorf("Migration %s depends on the missing migration %s", m.File, file)
+			}
+			deps[m] = append(deps[m], dep)
+		}
+	}
+
+	result := make([]*Migration, 0, len(migrations))
+	done := make(map[*Migration]bool, len(migrations))
+
+	for len(result) < len(migrations) {
+		progressed := false
+
+		for _, m := range migrations {
+			if done[m] || !allDone(deps[m], done) {
+				continue
+			}
+
+			result = append(result, m)
+			done[m] = true
+			progressed = true
+
+			// restart from the beginning to preserve the original order
+			break
+		}
+
+		if !progressed {
+			cyclic := []string{}
+			for _, m := range migrations {
+				if !done[m] {
+					cyclic = append(cyclic, m.File)
+				}
+			}
+			return nil, fmt.Errorf("Cyclic migrations dependencies between: %s", strings.Join(cyclic, ", "))
+		}
+	}
+
+	return result, nil
+}
+
+func allDone(migrations []*Migration, done map[*Migration]bool) bool {
+	for _, m := range migrations {
+		if !done[m] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	scenarios := []struct {
+		migrations  []*Migration
+		expectError bool
+		expected    string
+	}{
+		{
+			[]*Migration{},
+			false,
+			"",
+		},
+		{
+			[]*Migration{{File: "1_a"}, {File: "2_b"}, {File: "3_c"}},
+			false,
+			"1_a,2_b,3_c",
+		},
+		{
+			[]*Migration{{File: "1_a", DependsOn: []string{"3_c"}}, {File: "2_b"}, {File: "3_c"}},
+			false,
+			"2_b,3_c,1_a",
+		},
+		{
+			[]*Migration{{File: "1_a", DependsOn: []string{"missing"}}},
+			true,
+			"",
+		},
+		{
+			[]*Migration{{File: "1_a", DependsOn: []string{"2_b"}}, {File: "2_b", DependsOn: []string{"1_a"}}, {File: "3_c"}},
+			true,
+			"",
+		},
+		{
+			[]*Migration{{File: "1_a", set: "demo", DependsOn: []string{"1_a"}}, {File: "1_a"}, {File: "2_b", set: "demo", DependsOn: []string{"1_a"}}},
+			true, // the demo 1_a is resolved to itself
+			"",
+		},
+		{
+			// fallback to the default set
+			[]*Migration{{File: "1_a", set: "demo", DependsOn: []string{"2_b"}}, {File: "2_b"}},
+			false,
+			"2_b,1_a",
+		},
+	}
+
+	for i, s := range scenarios {
+		result, err := sortByDependencies(s.migrations)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		files := make([]string, len(result))
+		for j, m := range result {
+			files[j] = m.File
+		}
+
+		if joined := strings.Join(files, ","); joined != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, joined)
+		}
+	}
+}
+
+func TestRunnerDependencyOrder(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Add(&Migration{File: "1_posts", Up: noop, Down: noop, DependsOn: []string{"2_users"}})
+	l.Add(&Migration{File: "2_users", Up: noop, Down: noop})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.DependencyOrder = true
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(applied, ",") != "2_users,1_posts" {
+		t.Fatalf("Expected 2_users to be applied first, got %v", applied)
+	}
+
+	reverted, err := r.Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reverted) != 1 || reverted[0] != "1_posts" {
+		t.Fatalf("Expected 1_posts to be reverted, got %v", reverted)
+	}
+}
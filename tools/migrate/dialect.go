@@ -0,0 +1,112 @@
+package migrate
+
+// Dialect defines the database specific parts of the migrations
+// tracking tables SQL (the identifiers quoting is handled by dbx).
+type Dialect interface {
+	// KeyType returns the column type of the string primary key
+	// columns (eg. the migration file name).
+	KeyType() string
+
+	// TextType returns the column type of the other string columns.
+	TextType() string
+
+	// IntegerType returns the column type of the integer columns,
+	// which must be able to store unix timestamps in nanoseconds.
+	IntegerType() string
+
+	// CountExp returns the select expression for counting rows.
+	CountExp() string
+
+	// InsertIgnore returns the INSERT statement verb and suffix that
+	// skip the insert of a row conflicting with an existing one
+	// (eg. "INSERT" and "ON CONFLICT DO NOTHING").
+	InsertIgnore() (verb string, suffix string)
+}
+
+// SQLiteDialect is the default SQLite migrations tracking Dialect.
+type SQLiteDialect struct{}
+
+// KeyType implements the [Dialect] interface.
+func (d SQLiteDialect) KeyType() string { return "VARCHAR(255)" }
+
+// TextType implements the [Dialect] interface.
+func (d SQLiteDialect) TextType() string { return "TEXT" }
+
+// IntegerType implements the [Dialect] interface.
+func (d SQLiteDialect) IntegerType() string { return "INTEGER" }
+
+// CountExp implements the [Dialect] interface.
+func (d SQLiteDialect) CountExp() string { return "count(*)" }
+
+// InsertIgnore implements the [Dialect] interface.
+func (d SQLiteDialect) InsertIgnore() (string, string) { return "INSERT", "ON CONFLICT DO NOTHING" }
+
+// PostgresDialect is a PostgreSQL migrations tracking Dialect.
+type PostgresDialect struct{}
+
+// KeyType implements the [Dialect] interface.
+func (d PostgresDialect) KeyType() string { return "VARCHAR(255)" }
+
+// TextType implements the [Dialect] interface.
+func (d PostgresDialect) TextType() string { return "TEXT" }
+
+// IntegerType implements the [Dialect] interface.
+func (d PostgresDialect) IntegerType() string { return "BIGINT" }
+
+// CountExp implements the [Dialect] interface.
+func (d PostgresDialect) CountExp() string { return "COUNT(*)" }
+
+// InsertIgnore implements the [Dialect] interface.
+func (d PostgresDialect) InsertIgnore() (string, string) { return "INSERT", "ON CONFLICT DO NOTHING" }
+
+// MySQLDialect is a MySQL migrations tracking Dialect.
+//
+// Since MySQL doesn't allow defaults and primary keys for TEXT
+// columns without length, all string columns are VARCHAR(255).
+type MySQLDialect struct{}
+
+// KeyType implements the [Dialect] interface.
+func (d MySQLDialect) KeyType() string { return "VARCHAR(255)" }
+
+// TextType implements the [Dialect] interface.
+func (d MySQLDialect) TextType() string { return "VARCHAR(255)" }
+
+// IntegerType implements the [Dialect] interface.
+func (d MySQLDialect) IntegerType() string { return "BIGINT" }
+
+// CountExp implements the [Dialect] interface.
+func (d MySQLDialect) CountExp() string { return "COUNT(*)" }
+
+// InsertIgnore implements the [Dialect] interface.
+func (d MySQLDialect) InsertIgnore() (string, string) { return "INSERT IGNORE", "" }
+
+// MaxTextLength implements the [TextLengthLimiter] interface.
+func (d MySQLDialect) MaxTextLength() int { return 255 }
+
+// TextLengthLimiter is an optional Dialect interface for the dialects
+// whose TextType columns have a max length (eg. VARCHAR(255)).
+//
+// It is used to truncate the stored migrations logs (see Logf).
+type TextLengthLimiter interface {
+	// MaxTextLength returns the max length (in bytes) of the TextType columns.
+	MaxTextLength() int
+}
+
+// List with the migrations tracking table column kinds.
+const (
+	columnKindKey     = "key"
+	columnKindText    = "text"
+	columnKindInteger = "integer"
+)
+
+// columnType returns the dialect column type of the specified column kind.
+func columnType(d Dialect, kind string) string {
+	switch kind {
+	case columnKindKey:
+		return d.KeyType()
+	case columnKindInteger:
+		return d.IntegerType()
+	default:
+		return d.TextType()
+	}
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+func TestRunnerDialect(t *testing.T) {
+	scenarios := []struct {
+		dialect  Dialect
+		expected []string
+	}{
+		{
+			nil, // fallback to SQLiteDialect
+			[]string{
+				"CREATE TABLE IF NOT EXISTS `_migrations` (file VARCHAR(255) NOT NULL, applied INTEGER NOT NULL, `reverted` INTEGER DEFAULT 0 NOT NULL, `applied_by` TEXT DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` TEXT DEFAULT '' NOT NULL, `confirmed` INTEGER DEFAULT 1 NOT NULL, `log` TEXT DEFAULT '' NOT NULL, PRIMARY KEY (`file`, `set`))",
+				"SELECT count(*) FROM `_migrations` WHERE (`file`='1_test' AND `set`='') AND ((`reverted`=0) AND ([[applied]] > 0)) LIMIT 1",
+			},
+		},
+		{
+			PostgresDialect{},
+			[]string{
+				"CREATE TABLE IF NOT EXISTS `_migrations` (file VARCHAR(255) NOT NULL, applied BIGINT NOT NULL, `reverted` BIGINT DEFAULT 0 NOT NULL, `applied_by` TEXT DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` TEXT DEFAULT '' NOT NULL, `confirmed` BIGINT DEFAULT 1 NOT NULL, `log` TEXT DEFAULT '' NOT NULL, PRIMARY KEY (`file`, `set`))",
+				"SELECT COUNT(*) FROM `_migrations` WHERE (`file`='1_test' AND `set`='') AND ((`reverted`=0) AND ([[applied]] > 0)) LIMIT 1",
+			},
+		},
+		{
+			MySQLDialect{},
+			[]string{
+				"CREATE TABLE IF NOT EXISTS `_migrations` (file VARCHAR(255) NOT NULL, applied BIGINT NOT NULL, `reverted` BIGINT DEFAULT 0 NOT NULL, `applied_by` VARCHAR(255) DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` VARCHAR(255) DEFAULT '' NOT NULL, `confirmed` BIGINT DEFAULT 1 NOT NULL, `log` VARCHAR(255) DEFAULT '' NOT NULL, PRIMARY KEY (`file`, `set`))",
+				"SELECT COUNT(*) FROM `_migrations` WHERE (`file`='1_test' AND `set`='') AND ((`reverted`=0) AND ([[applied]] > 0)) LIMIT 1",
+			},
+		},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+			r.Dialect = s.dialect
+		})
+		if err != nil {
+			testDB.Close()
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		r.IsApplied("1_test")
+
+		testDB.Close()
+
+		for _, q := range s.expected {
+			if !list.ExistInSlice(q, testDB.CalledQueries) {
+				t.Errorf("(%d) Query %s was not found in \n%v", i, q, testDB.CalledQueries)
+			}
+		}
+	}
+}
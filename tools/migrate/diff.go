@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pocketbase/dbx"
+)
+
+// CompareWith compares the applied migrations of the runner db
+// with the applied migrations of the other runner db (eg. staging vs prod).
+//
+// Returns the sorted names of the migrations applied only in the runner db
+// and of the ones applied only in the other runner db. The migrations of
+// a named set are prefixed with the set name (eg. "staging/1656512345_seed.go").
+func (r *Runner) CompareWith(other *Runner) (onlyHere []string, onlyThere []string, err error) {
+	here, err := r.appliedNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	there, err := other.appliedNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	onlyHere = []string{}
+	for name := range here {
+		if _, ok := there[name]; !ok {
+			onlyHere = append(onlyHere, name)
+		}
+	}
+
+	onlyThere = []string{}
+	for name := range there {
+		if _, ok := here[name]; !ok {
+			onlyThere = append(onlyThere, name)
+		}
+	}
+
+	sort.Strings(onlyHere)
+	sort.Strings(onlyThere)
+
+	return onlyHere, onlyThere, nil
+}
+
+// appliedNames returns a set with the names of all applied migrations.
+func (r *Runner) appliedNames() (map[string]struct{}, error) {
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]struct{}, len(applied))
+	for _, a := range applied {
+		result[a.name()] = struct{}{}
+	}
+
+	return result, nil
+}
+
+// writeDiff connects to the db with the specified dsn (using the
+// runner db driver) and writes its applied migrations differences to w.
+func (r *Runner) writeDiff(w io.Writer, dsn string) error {
+	otherDB, err := dbx.Open(r.db.DriverName(), dsn)
+	if err != nil {
+		return err
+	}
+	defer otherDB.Close()
+
+	other, err := NewRunner(otherDB, MigrationsList{}, func(o *Runner) {
+		o.ReadOnly = true
+		o.TableName = r.TableName
+		o.Dialect = r.Dialect
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to read the %s migrations: %w", dsn, err)
+	}
+
+	onlyHere, onlyThere, err := r.CompareWith(other)
+	if err != nil {
+		return err
+	}
+
+	if len(onlyHere) == 0 && len(onlyThere) == 0 {
+		fmt.Fprintln(w, "The applied migrations are the same.")
+		return nil
+	}
+
+	groups := []struct {
+		title string
+		names []string
+	}{
+		{"Applied only here", onlyHere},
+		{"Applied only in " + dsn, onlyThere},
+	}
+
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s (%d):\n", g.title, len(g.names))
+
+		for _, name := range g.names {
+			fmt.Fprintf(w, "- %s\n", name)
+		}
+	}
+
+	return nil
+}
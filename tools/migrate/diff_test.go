@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerCompareWith(t *testing.T) {
+	hereDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hereDB.Close()
+
+	thereDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer thereDB.Close()
+
+	here, err := NewRunner(hereDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	there, err := NewRunner(thereDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	here.saveAppliedMigration(hereDB, "", "1_test")
+	here.saveAppliedMigration(hereDB, "", "3_test")
+	here.saveAppliedMigration(hereDB, "staging", "4_test")
+	there.saveAppliedMigration(thereDB, "", "1_test")
+	there.saveAppliedMigration(thereDB, "", "2_test")
+	there.saveAppliedMigration(thereDB, "", "4_test")
+
+	onlyHere, onlyThere, err := here.CompareWith(there)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "3_test,staging/4_test"; strings.Join(onlyHere, ",") != expected {
+		t.Fatalf("Expected only here %s, got %v", expected, onlyHere)
+	}
+
+	if expected := "2_test,4_test"; strings.Join(onlyThere, ",") != expected {
+		t.Fatalf("Expected only there %s, got %v", expected, onlyThere)
+	}
+}
+
+func TestRunnerRunDiff(t *testing.T) {
+	dir := t.TempDir()
+
+	hereDB, err := dbx.Open("sqlite", filepath.Join(dir, "here.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hereDB.Close()
+
+	thereDSN := filepath.Join(dir, "there.db")
+	thereDB, err := dbx.Open("sqlite", thereDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer thereDB.Close()
+
+	here, err := NewRunner(hereDB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	there, err := NewRunner(thereDB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	here.saveAppliedMigration(hereDB, "", "1_test")
+	there.saveAppliedMigration(thereDB, "", "2_test")
+
+	originalStdout := os.Stdout
+	defer func() { os.Stdout = originalStdout }()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+
+	runErr := here.Run("diff", thereDSN)
+
+	writer.Close()
+	os.Stdout = originalStdout
+
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	output, _ := io.ReadAll(reader)
+
+	expected := strings.Join([]string{
+		"Applied only here (1):",
+		"- 1_test",
+		"Applied only in " + thereDSN + " (1):",
+		"- 2_test",
+		"",
+	}, "\n")
+
+	if string(output) != expected {
+		t.Fatalf("Expected \n%s, \ngot \n%s", expected, output)
+	}
+
+	if err := here.Run("diff", filepath.Join(dir, "missing", "other.db")); err == nil {
+		t.Fatal("Expected error for invalid dsn, got nil")
+	}
+}
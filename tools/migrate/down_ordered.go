@@ -0,0 +1,140 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+// DownOrdered reverts the specified applied migrations in the
+// provided files order (or in the reversed files order if reverse is set).
+//
+// This is intended for the edge cases where the default LIFO Down order
+// is not suitable (eg. for migrations applied out of the main chain).
+//
+// To prevent obvious dependency violations, a migration can't be reverted
+// before another one of the files that is both newer (by its file name
+// timestamp) and applied after it, because the newer migration may rely
+// on it (eg. a column added to the table created by the older migration).
+//
+// On success returns list with the reverted migrations file names.
+// On failure returns the migrations reverted by the already committed
+// transactions together with the error.
+func (r *Runner) DownOrdered(files []string, reverse bool) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	r.startRun()
+
+	records, err := r.AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedTimes := make(map[string]int64, len(records))
+	for _, a := range records {
+		appliedTimes[a.Set+"/"+a.File] = a.Applied
+	}
+
+	ordered := make([]*Migration, 0, len(files))
+	for i := range files {
+		file := files[i]
+		if reverse {
+			file = files[len(files)-1-i]
+		}
+
+		var target *Migration
+		for _, m := range r.items() {
+			if m.File != file {
+				continue
+			}
+			if target != nil {
+				return nil, fmt.Errorf("Migration %s is registered in more than one set", file)
+			}
+			target = m
+		}
+		if target == nil {
+			return nil, fmt.Errorf("Missing migration %s", file)
+		}
+
+		for _, m := range ordered {
+			if m == target {
+				return nil, fmt.Errorf("Duplicated migration %s", file)
+			}
+		}
+
+		if _, ok := appliedTimes[target.set+"/"+target.File]; !ok {
+			return nil, fmt.Errorf("Migration %s is not applied", file)
+		}
+
+		ordered = append(ordered, target)
+	}
+
+	for i, m := range ordered {
+		ts, _ := migrationTimestamp(m.File)
+		appliedAt := appliedTimes[m.set+"/"+m.File]
+
+		for _, next := range ordered[i+1:] {
+			nextTs, _ := migrationTimestamp(next.File)
+			if nextTs > ts && appliedTimes[next.set+"/"+next.File] > appliedAt {
+				return nil, fmt.Errorf(
+					"Migration %s must be reverted before %s because it is newer and applied after it",
+					next.File,
+					m.File,
+				)
+			}
+		}
+	}
+
+	if r.TrackObjects {
+		if err := r.createObjectsTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	reverted := []string{}
+
+	// the migrations of the current group that are reported
+	// as reverted only after the group transaction is committed
+	pending := []string{}
+
+	err = r.runGrouped(ordered, false, func(db dbx.Builder, m *Migration) error {
+		if err := r.migrationDown(db, m); err != nil {
+			return r.mapError(m.File, DirectionDown, fmt.Errorf("Failed to revert migration %s: %w", m.File, err))
+		}
+
+		if err := r.saveRevertedMigration(db, m.set, m.File); err != nil {
+			return fmt.Errorf("Failed to save reverted migration info for %s: %w", m.File, err)
+		}
+
+		if r.TrackObjects {
+			if err := r.deleteCreatedObjects(db, m); err != nil {
+				return err
+			}
+		}
+
+		pending = append(pending, m.File)
+
+		return nil
+	}, func(committed bool) {
+		if committed {
+			reverted = append(reverted, pending...)
+		}
+
+		pending = pending[:0]
+	})
+	if err != nil {
+		// the migrations of the committed transactions are still reverted
+		return reverted, err
+	}
+
+	return reverted, nil
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerDownOrdered(t *testing.T) {
+	calls := []string{}
+	callsFunc := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	l := MigrationsList{}
+	l.Register(callsFunc("1_up"), callsFunc("1_down"), "1_test")
+	l.Register(callsFunc("2_up"), callsFunc("2_down"), "2_test")
+	l.Register(callsFunc("3_up"), callsFunc("3_down"), "3_test")
+	l.Register(callsFunc("4_up"), callsFunc("4_down"), "4_test")
+
+	scenarios := []struct {
+		files         []string
+		reverse       bool
+		expectError   bool
+		expectedCalls string
+	}{
+		{[]string{"missing"}, false, true, ""},
+		{[]string{"4_test"}, false, true, ""}, // not applied
+		{[]string{"1_test", "1_test"}, false, true, ""},
+		// 1_test is older and applied before 2_test
+		{[]string{"1_test", "2_test"}, false, true, ""},
+		{[]string{"1_test", "2_test"}, true, false, "2_down,1_down"},
+		// 3_test was applied out of the main chain (before 2_test)
+		{[]string{"2_test", "3_test"}, false, false, "2_down,3_down"},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.saveAppliedMigration(testDB, "", "1_test")
+		r.saveAppliedMigration(testDB, "", "3_test")
+		r.saveAppliedMigration(testDB, "", "2_test")
+
+		calls = []string{}
+
+		reverted, err := r.DownOrdered(s.files, s.reverse)
+
+		testDB.Close()
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if result := strings.Join(calls, ","); result != s.expectedCalls {
+			t.Errorf("(%d) Expected calls %q, got %q", i, s.expectedCalls, result)
+		}
+
+		if !hasErr && len(reverted) != len(s.files) {
+			t.Errorf("(%d) Expected %d reverted migrations, got %v", i, len(s.files), reverted)
+		}
+	}
+}
+
+func TestRunnerDownOrderedPartialFailure(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, func(db dbx.Builder) error { return errors.New("down failure") }, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.PerMigrationTx = true
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+	r.saveAppliedMigration(testDB, "", "2_test")
+
+	reverted, err := r.DownOrdered([]string{"2_test", "1_test"}, false)
+	if err == nil || !strings.Contains(err.Error(), "down failure") {
+		t.Fatalf("Expected down failure, got %v", err)
+	}
+
+	// the committed 2_test revert is still reported
+	if len(reverted) != 1 || reverted[0] != "2_test" {
+		t.Fatalf("Expected only 2_test to be reverted, got %v", reverted)
+	}
+
+	if r.isMigrationApplied(testDB, "", "2_test") || !r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected only 1_test to remain applied")
+	}
+}
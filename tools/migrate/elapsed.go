@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoAppliedMigrations is returned by TimeSinceLastApplied
+// if there are no applied migrations.
+var ErrNoAppliedMigrations = errors.New("There are no applied migrations")
+
+// TimeSinceLastApplied returns the elapsed real time since the last
+// migration was applied, based on the max applied timestamp of the
+// currently applied migrations (eg. for monitoring whether the deploy
+// pipeline still applies migrations).
+//
+// Returns ErrNoAppliedMigrations if there are no applied migrations.
+func (r *Runner) TimeSinceLastApplied() (time.Duration, error) {
+	last, ok, err := r.LastApplied()
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, ErrNoAppliedMigrations
+	}
+
+	return r.now().Sub(last.AppliedAt()), nil
+}
+
+// formatElapsed returns the human readable representation of d
+// with a days precision unit (eg. "3d 4h 12m 5s").
+//
+// The negative durations (eg. due to clock skew) are formatted as "0s".
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+
+	d = d.Truncate(time.Second)
+
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	parts := []string{}
+	for _, u := range units {
+		if n := d / u.size; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.suffix))
+			d -= n * u.size
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
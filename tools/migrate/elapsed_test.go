@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerTimeSinceLastApplied(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2022, 6, 30, 12, 0, 0, 0, time.UTC)
+	r.Now = func() time.Time { return now }
+
+	if _, err := r.TimeSinceLastApplied(); !errors.Is(err, ErrNoAppliedMigrations) {
+		t.Fatalf("Expected ErrNoAppliedMigrations, got %v", err)
+	}
+
+	applied := now.Add(-50 * time.Hour)
+	if err := r.saveAppliedMigrationAt(testDB, "", "1_test.go", applied.Add(-time.Hour).UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.saveAppliedMigrationAt(testDB, "", "2_test.go", applied.UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	elapsed, err := r.TimeSinceLastApplied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed != 50*time.Hour {
+		t.Fatalf("Expected 50h, got %v", elapsed)
+	}
+
+	output := captureStdout(t, func() {
+		if err := r.Run("since-last"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.TrimSpace(output) != "2d 2h" {
+		t.Fatalf("Expected 2d 2h, got %q", output)
+	}
+
+	output = captureStdout(t, func() {
+		if err := r.Run("since-last", "--seconds"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.TrimSpace(output) != "180000" {
+		t.Fatalf("Expected 180000, got %q", output)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	scenarios := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{-time.Hour, "0s"},
+		{0, "0s"},
+		{999 * time.Millisecond, "0s"},
+		{90 * time.Second, "1m 30s"},
+		{time.Hour + 1500*time.Millisecond, "1h 1s"},
+		{72*time.Hour + 4*time.Minute, "3d 4m"},
+	}
+
+	for i, s := range scenarios {
+		if v := formatElapsed(s.duration); v != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, v)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package migrate
+
+// List with the supported migration event types.
+const (
+	MigrationEventStarted    = "started"
+	MigrationEventApplied    = "applied"
+	MigrationEventFailed     = "failed"
+	MigrationEventCommitted  = "committed"
+	MigrationEventPaused     = "paused"
+	MigrationEventRunStarted = "run_started"
+)
+
+// MigrationEvent defines a single migration progress event.
+type MigrationEvent struct {
+	// Type is one of the MigrationEvent* constants.
+	Type string
+
+	// File is the name of the related migration file
+	// (empty for the run level events, eg. "run_started" and "committed").
+	File string
+
+	// Error is the reason of a "failed" event (or ErrPaused for a "paused" event).
+	Error error
+
+	// RunId is the unique id of the migrations run (see Runner.LastRunId).
+	RunId string
+}
+
+// UpStream executes all unapplied migrations in a separate goroutine
+// and emits a MigrationEvent for each migration on the returned channel.
+//
+// The channel is closed once the run completes.
+//
+// Note that the "applied" events of the migrations executed in a shared
+// transaction are sent only after the transaction is committed. If a
+// migration fails, the transaction is rolled back (without "applied"
+// events for its migrations) and the "failed" event is the last one
+// sent on the channel.
+func (r *Runner) UpStream() (<-chan MigrationEvent, error) {
+	// buffer enough space for all possible events so that
+	// the migrations run is never blocked by a slow consumer
+	events := make(chan MigrationEvent, 2*len(r.items())+1)
+
+	go func() {
+		defer close(events)
+
+		r.up(nil, "", func(e MigrationEvent) {
+			events <- e
+		})
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerUpStream(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		failOn   string
+		expected []MigrationEvent
+	}{
+		{
+			"successful run",
+			"",
+			[]MigrationEvent{
+				{Type: MigrationEventStarted, File: "1_test"},
+				{Type: MigrationEventStarted, File: "2_test"},
+				{Type: MigrationEventApplied, File: "1_test"},
+				{Type: MigrationEventApplied, File: "2_test"},
+				{Type: MigrationEventCommitted},
+			},
+		},
+		{
+			"failed run",
+			"2_test",
+			[]MigrationEvent{
+				{Type: MigrationEventStarted, File: "1_test"},
+				{Type: MigrationEventStarted, File: "2_test"},
+				{Type: MigrationEventFailed, File: "2_test"},
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := MigrationsList{}
+		for _, file := range []string{"1_test", "2_test"} {
+			file := file
+			l.Register(func(db dbx.Builder) error {
+				if file == s.failOn {
+					return errors.New("test error")
+				}
+				return nil
+			}, nil, file)
+		}
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events, err := r.UpStream()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := []MigrationEvent{}
+		for e := range events {
+			result = append(result, e)
+		}
+
+		testDB.Close()
+
+		if len(result) != len(s.expected) {
+			t.Errorf("[%s] Expected %d events, got %d: \n%v", s.name, len(s.expected), len(result), result)
+			continue
+		}
+
+		for i, e := range s.expected {
+			if result[i].Type != e.Type || result[i].File != e.File {
+				t.Errorf("[%s] Expected event %d to be %v, got %v", s.name, i, e, result[i])
+			}
+
+			if e.Type == MigrationEventFailed && result[i].Error == nil {
+				t.Errorf("[%s] Expected the failed event to have an error", s.name)
+			}
+		}
+	}
+}
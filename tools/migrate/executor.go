@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pocketbase/dbx"
+)
+
+// Executor defines an interface for executing raw SQL migrations statements.
+type Executor interface {
+	ExecContext(ctx context.Context, query string) (sql.Result, error)
+}
+
+// NewDBExecutor creates a new Executor that executes the
+// statements with the provided dbx builder (eg. *dbx.Tx).
+func NewDBExecutor(db dbx.Builder) Executor {
+	return &dbExecutor{db: db}
+}
+
+type dbExecutor struct {
+	db dbx.Builder
+}
+
+// ExecContext implements the [Executor] interface.
+func (e *dbExecutor) ExecContext(ctx context.Context, query string) (sql.Result, error) {
+	return e.db.NewQuery(query).WithContext(ctx).Execute()
+}
+
+// migrationUp applies the provided migration, routing
+// its SQL statements to the runner Executor (if set).
+func (r *Runner) migrationUp(db dbx.Builder, m *Migration) error {
+	if r.Executor == nil || !m.isSQL {
+		return m.Up(db)
+	}
+
+	if m.load != nil {
+		if err := m.load(); err != nil {
+			return err
+		}
+	}
+
+	return execSQLStatements(r.executorContext(), r.Executor, m.upSQL)
+}
+
+// migrationDown reverts the provided migration, routing
+// its SQL statements to the runner Executor (if set).
+func (r *Runner) migrationDown(db dbx.Builder, m *Migration) error {
+	if r.Executor == nil || !m.isSQL || m.IrreversibleReason != "" || m.Down == nil {
+		return m.revert(db)
+	}
+
+	if m.load != nil {
+		if err := m.load(); err != nil {
+			return err
+		}
+	}
+
+	// nothing to revert
+	if !m.hasDownSQL {
+		return nil
+	}
+
+	return execSQLStatements(r.executorContext(), r.Executor, m.downSQL)
+}
+
+// executorContext returns the context for the Executor calls.
+func (r *Runner) executorContext() context.Context {
+	if ctx := r.db.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/dbx"
+)
+
+type testExecutor struct {
+	queries []string
+	failOn  string
+}
+
+func (e *testExecutor) ExecContext(ctx context.Context, query string) (sql.Result, error) {
+	if e.failOn != "" && strings.Contains(query, e.failOn) {
+		return nil, errors.New("test error")
+	}
+
+	e.queries = append(e.queries, query)
+
+	return nil, nil
+}
+
+func TestRunnerExecutor(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	goCalls := 0
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		goCalls++
+		return nil
+	}, nil, "1_go.go")
+	if err := l.RegisterSQL("2_sql.sql", "-- +up\nCREATE TABLE a (id TEXT);\nCREATE TABLE b (id TEXT);\n-- +down\nDROP TABLE a;"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RegisterFS(fstest.MapFS{"3_fs.sql": {Data: []byte("-- +up\nCREATE TABLE c (id TEXT);")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &testExecutor{}
+	r.Executor = executor
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 3 || goCalls != 1 {
+		t.Fatalf("Expected all migrations to be applied and the Go one to be called once, got %v (%d)", applied, goCalls)
+	}
+
+	expected := "CREATE TABLE a (id TEXT),CREATE TABLE b (id TEXT),CREATE TABLE c (id TEXT)"
+	if result := strings.Join(executor.queries, ","); result != expected {
+		t.Fatalf("Expected executor queries %q, got %q", expected, result)
+	}
+
+	var total int
+	if err := testDB.Select("count(*)").From("a").Row(&total); err == nil {
+		t.Fatal("Expected the SQL statements to not be executed with the runner db")
+	}
+
+	// missing down section (no-op)
+	executor.queries = nil
+	if reverted, err := r.Down(1); err != nil || len(reverted) != 1 || reverted[0] != "3_fs.sql" {
+		t.Fatalf("Expected 3_fs.sql to be reverted, got %v (%v)", reverted, err)
+	}
+	if len(executor.queries) != 0 {
+		t.Fatalf("Expected no executor queries, got %v", executor.queries)
+	}
+	if err := r.RunOne("2_sql.sql", DirectionDown, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := strings.Join(executor.queries, ","); result != "DROP TABLE a" {
+		t.Fatalf("Expected executor queries %q, got %q", "DROP TABLE a", result)
+	}
+
+	// executor error
+	executor.failOn = "TABLE a"
+	if _, err := r.Up(); err == nil || !strings.Contains(err.Error(), "test error") {
+		t.Fatalf("Expected executor error, got %v", err)
+	}
+}
+
+func TestNewDBExecutor(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	executor := NewDBExecutor(testDB)
+
+	if _, err := executor.ExecContext(context.Background(), "CREATE TABLE demo (id TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	if err := testDB.Select("count(*)").From("demo").Row(&total); err != nil {
+		t.Fatalf("Expected the demo table to be created, got %v", err)
+	}
+}
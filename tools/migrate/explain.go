@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Explain executes the up func of the specified migration within a
+// transaction that is always rolled back and returns the SQL statements
+// that the migration executed (eg. to review a Go migration before applying it).
+//
+// The migrations of a named set must be prefixed with the set name
+// (eg. "staging/1656512345_seed.go").
+//
+// Note that the migration func is still executed, so it shouldn't
+// have side effects outside of the db (and the NonTransactional
+// migrations statements that can't run in a transaction will fail).
+//
+// On migration error returns the SQL statements executed so far
+// together with the error.
+func (r *Runner) Explain(name string) ([]string, error) {
+	var migration *Migration
+	for _, m := range r.items() {
+		if migrationName(m) == name {
+			migration = m
+			break
+		}
+	}
+
+	if migration == nil {
+		return nil, fmt.Errorf("Missing migration %s", name)
+	}
+
+	if migration.Up == nil {
+		return []string{}, nil
+	}
+
+	statements := []string{}
+
+	db := r.db.Clone()
+	db.QueryLogFunc = func(ctx context.Context, t time.Duration, sql string, rows *sql.Rows, err error) {
+		statements = append(statements, sql)
+	}
+	db.ExecLogFunc = func(ctx context.Context, t time.Duration, sql string, result sql.Result, err error) {
+		statements = append(statements, sql)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := migration.Up(tx); err != nil {
+		return statements, fmt.Errorf("Failed to explain migration %s: %w", name, err)
+	}
+
+	return statements, nil
+}
+
+// writeExplain writes the Explain SQL statements of the specified migration to w.
+func (r *Runner) writeExplain(w io.Writer, name string) error {
+	statements, err := r.Explain(name)
+
+	fmt.Fprintf(w, "-- Migration %s\n", name)
+
+	for _, stmt := range statements {
+		io.WriteString(w, stmt)
+		if !strings.HasSuffix(stmt, ";") {
+			io.WriteString(w, ";")
+		}
+		io.WriteString(w, "\n")
+	}
+
+	return err
+}
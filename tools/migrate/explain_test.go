@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerExplain(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		if _, err := db.NewQuery("CREATE TABLE demo (id TEXT)").Execute(); err != nil {
+			return err
+		}
+		_, err := db.Insert("demo", dbx.Params{"id": "test"}).Execute()
+		return err
+	}, nil, "1_test")
+	l.Register(func(db dbx.Builder) error {
+		if _, err := db.NewQuery("CREATE TABLE failing (id TEXT)").Execute(); err != nil {
+			return err
+		}
+		_, err := db.NewQuery("INVALID").Execute()
+		return err
+	}, nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		expectError bool
+		expected    []string
+	}{
+		{"missing", true, nil},
+		{"1_test", false, []string{"CREATE TABLE demo (id TEXT)", "INSERT INTO `demo` (`id`) VALUES ('test')"}},
+		{"2_test", true, []string{"CREATE TABLE failing (id TEXT)", "INVALID"}},
+	}
+
+	for i, s := range scenarios {
+		statements, err := r.Explain(s.name)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if result, expected := strings.Join(statements, "\n"), strings.Join(s.expected, "\n"); result != expected {
+			t.Errorf("(%d) Expected statements \n%s, \ngot \n%s", i, expected, result)
+		}
+	}
+
+	// the db should not be modified
+	var total int
+	testDB.Select("count(*)").From("sqlite_master").Where(dbx.In("name", "demo", "failing")).Row(&total)
+	if total != 0 {
+		t.Fatalf("Expected the explained migrations changes to be rolled back, got %d tables", total)
+	}
+
+	if r.appliedCount() != 0 {
+		t.Fatal("Expected no applied migrations")
+	}
+}
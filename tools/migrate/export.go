@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExportPlan writes all pending SQL migrations up statements as a single
+// reviewable SQL script, including the migrations table insert statements,
+// so that it could be manually executed (eg. by a DBA) while keeping
+// the migrations tracking table consistent.
+//
+// Go migrations can't be exported as SQL and only a note for them is written.
+func (r *Runner) ExportPlan(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("-- Pending migrations plan\n")
+
+	total := 0
+
+	for _, m := range r.items() {
+		applied, err := r.checkMigrationApplied(r.db, m.set, m.File)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		total++
+
+		b.WriteString("\n")
+
+		if !m.isSQL {
+			fmt.Fprintf(&b, "-- NOTE: %s is a Go migration and cannot be exported as SQL (apply it with the migrations runner).\n", m.File)
+			continue
+		}
+
+		if m.load != nil {
+			if err := m.load(); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(&b, "-- Migration %s\n", m.File)
+
+		for _, stmt := range m.upSQL {
+			b.WriteString(stmt)
+			if !strings.HasSuffix(stmt, ";") {
+				b.WriteString(";")
+			}
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(
+			&b,
+			"INSERT INTO %s (%s, %s, %s) VALUES (%s, %s, %d);\n",
+			r.db.QuoteTableName(r.TableName),
+			r.db.QuoteColumnName("file"),
+			r.db.QuoteColumnName("set"),
+			r.db.QuoteColumnName("applied"),
+			quoteSQLString(m.File),
+			quoteSQLString(m.set),
+			r.nextTimestamp(),
+		)
+
+		fmt.Fprintf(&b, "-- End of migration %s\n", m.File)
+	}
+
+	if total == 0 {
+		b.WriteString("\n-- No pending migrations.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// quoteSQLString wraps the provided string in single quotes
+// escaping the existing ones.
+func quoteSQLString(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
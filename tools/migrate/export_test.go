@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunnerExportPlan(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_applied.go")
+	l.Register(nil, nil, "2_go.go")
+	if err := l.RegisterSQL("3_o'sql.sql", "-- +up\nCREATE TABLE demo (id TEXT);\n-- +statement-begin\nCREATE TRIGGER t AFTER INSERT ON demo BEGIN\n  SELECT 1;\nEND;\n-- +statement-end"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_applied.go")
+
+	var b strings.Builder
+	if err := r.ExportPlan(&b); err != nil {
+		t.Fatal(err)
+	}
+	plan := b.String()
+
+	expectedParts := []string{
+		"-- NOTE: 2_go.go is a Go migration",
+		"-- Migration 3_o'sql.sql\nCREATE TABLE demo (id TEXT);\nCREATE TRIGGER t AFTER INSERT ON demo BEGIN\n  SELECT 1;\nEND;\n",
+		"INSERT INTO `_migrations` (`file`, `set`, `applied`) VALUES ('3_o''sql.sql', '', ",
+		"-- End of migration 3_o'sql.sql",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(plan, part) {
+			t.Fatalf("Missing %q in \n%s", part, plan)
+		}
+	}
+
+	if strings.Contains(plan, "1_applied.go") {
+		t.Fatalf("Didn't expect the applied migration to be exported: \n%s", plan)
+	}
+
+	// the exported sql should be executable
+	if _, err := testDB.NewQuery(strings.Split(plan, "-- Migration 3_o'sql.sql\n")[1]).Execute(); err != nil {
+		t.Fatalf("Failed to execute the plan: %v", err)
+	}
+	if !r.isMigrationApplied(testDB, "", "3_o'sql.sql") {
+		t.Fatal("Expected the plan to record the applied migration")
+	}
+}
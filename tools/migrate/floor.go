@@ -0,0 +1,133 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+const floorsTable = "_migrationsFloors"
+
+// Unfloor removes the rollback floor markers recorded by Down
+// (see Runner.FloorOnDown), allowing the next Up calls to
+// re-apply the reverted migrations.
+func (r *Runner) Unfloor() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if err := r.createFloorsTable(); err != nil {
+		return err
+	}
+
+	_, err := r.db.Delete(floorsTable, nil).Execute()
+
+	return err
+}
+
+// saveFloors records the highest of the reverted migrations of each
+// set as the set rollback floor (keeping the existing higher floors).
+func (r *Runner) saveFloors(reverted []*Migration) error {
+	if len(reverted) == 0 {
+		return nil
+	}
+
+	current, err := r.floors()
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]string{}
+	for _, m := range reverted {
+		if m.File > current[m.set] && m.File > updated[m.set] {
+			updated[m.set] = m.File
+		}
+	}
+
+	for set, file := range updated {
+		if _, err := r.builder().Delete(floorsTable, dbx.HashExp{"set": set}).Execute(); err != nil {
+			return err
+		}
+
+		_, err := r.builder().Insert(floorsTable, dbx.Params{
+			"set":     set,
+			"file":    file,
+			"created": r.nextTimestamp(),
+		}).Execute()
+		if err != nil {
+			return fmt.Errorf("Failed to save the rollback floor %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// floors returns the recorded rollback floor file of each set.
+func (r *Runner) floors() (map[string]string, error) {
+	if err := r.createFloorsTable(); err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		Set  string `db:"set"`
+		File string `db:"file"`
+	}{}
+
+	err := r.builder().Select("set", "file").From(floorsTable).All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(rows))
+	for _, row := range rows {
+		result[row.Set] = row.File
+	}
+
+	return result, nil
+}
+
+// excludeFloored returns the provided migrations without the unapplied
+// ones at or below their set rollback floor and the number of the excluded ones.
+func (r *Runner) excludeFloored(migrations []*Migration) ([]*Migration, int, error) {
+	floors, err := r.floors()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(floors) == 0 {
+		return migrations, 0, nil
+	}
+
+	result := make([]*Migration, 0, len(migrations))
+	skipped := 0
+
+	for _, m := range migrations {
+		floor, ok := floors[m.set]
+		if ok && m.File <= floor && !r.isMigrationApplied(r.builder(), m.set, m.File) {
+			skipped++
+			continue
+		}
+
+		result = append(result, m)
+	}
+
+	return result, skipped, nil
+}
+
+func (r *Runner) createFloorsTable() error {
+	rawQuery := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (%s %s PRIMARY KEY NOT NULL, file %s NOT NULL, created %s NOT NULL)",
+		r.db.QuoteTableName(floorsTable),
+		r.db.QuoteColumnName("set"),
+		r.Dialect.KeyType(),
+		r.Dialect.KeyType(),
+		r.Dialect.IntegerType(),
+	)
+
+	_, err := r.builder().NewQuery(rawQuery).Execute()
+
+	return err
+}
@@ -0,0 +1,126 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerFloorOnDown(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.FloorOnDown = true
+	r.Confirm = func(message string) (bool, error) { return true, nil }
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Down(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// newer migration above the floor
+	r.migrationsList.(*MigrationsList).Register(noop, noop, "4_test")
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "4_test"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+
+	// explicit override
+	if err := r.Run("up", "--past-floor"); err != nil {
+		t.Fatal(err)
+	}
+	if r.PastFloor {
+		t.Fatal("Expected PastFloor to be restored after the run")
+	}
+	if r.appliedCount() != 4 {
+		t.Fatalf("Expected 4 applied migrations, got %d", r.appliedCount())
+	}
+
+	// the floor is not lowered by the next down
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	if floors, _ := r.floors(); floors[""] != "4_test" {
+		t.Fatalf("Expected 4_test floor, got %v", floors)
+	}
+	if applied, _ := r.Up(); len(applied) != 0 {
+		t.Fatalf("Expected no applied migrations, got %v", applied)
+	}
+
+	if err := r.Run("unfloor"); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err = r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "4_test"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+}
+
+func TestRunnerFloorOnDownPartialFailure(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, func(db dbx.Builder) error { return errors.New("down failure") }, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.FloorOnDown = true
+	r.PerMigrationTx = true
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := r.Down(3)
+	if err == nil {
+		t.Fatal("Expected down failure, got nil")
+	}
+	if expected := "3_test,2_test"; strings.Join(reverted, ",") != expected {
+		t.Fatalf("Expected reverted %s, got %v", expected, reverted)
+	}
+
+	// the floor of the committed reverts is saved
+	if floors, _ := r.floors(); floors[""] != "3_test" {
+		t.Fatalf("Expected 3_test floor, got %v", floors)
+	}
+	if applied, _ := r.Up(); len(applied) != 0 {
+		t.Fatalf("Expected no applied migrations, got %v", applied)
+	}
+}
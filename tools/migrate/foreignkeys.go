@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+// runWithForeignKeysDisabled executes fn in a transaction of a dedicated
+// connection with the SQLite foreign keys enforcement turned off
+// (see Migration.DisableForeignKeys).
+//
+// The transaction is rolled back if the migration introduces new
+// foreign key violations and the previous foreign keys setting
+// of the connection is restored regardless of the outcome.
+func (r *Runner) runWithForeignKeysDisabled(m *Migration, fn func(tx *dbx.Tx) error) (err error) {
+	if driver := r.db.DriverName(); driver != "sqlite" && driver != "sqlite3" {
+		return fmt.Errorf("Migration %s: DisableForeignKeys is supported only for SQLite databases", m.File)
+	}
+
+	ctx := r.executorContext()
+
+	// the pragma is per connection and it cannot be changed
+	// within a transaction, so both must use the same connection
+	conn, err := r.db.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var enabled bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return err
+	}
+
+	if enabled {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+
+		defer func() {
+			if _, restoreErr := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); restoreErr != nil && err == nil {
+				err = fmt.Errorf("Failed to restore the foreign keys enforcement: %w", restoreErr)
+			}
+		}()
+	}
+
+	existing, err := foreignKeyViolations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	sqlTx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(r.db.Wrap(sqlTx)); err != nil {
+		return err
+	}
+
+	violations, err := foreignKeyViolations(ctx, sqlTx)
+	if err != nil {
+		return err
+	}
+
+	for v := range violations {
+		if _, ok := existing[v]; !ok {
+			return fmt.Errorf("Migration %s introduced foreign key violations (eg. %s)", m.File, v)
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// foreignKeyViolations returns the current "PRAGMA foreign_key_check" violations.
+func foreignKeyViolations(ctx context.Context, db interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}) (map[string]struct{}, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]struct{}{}
+
+	for rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, err
+		}
+
+		result[fmt.Sprintf("%s row %d referencing %s", table, rowid.Int64, parent)] = struct{}{}
+	}
+
+	return result, rows.Err()
+}
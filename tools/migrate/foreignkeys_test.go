@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerDisableForeignKeys(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	exec := func(queries ...string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			for _, q := range queries {
+				if _, err := db.NewQuery(q).Execute(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	_, err = testDB.NewQuery("PRAGMA foreign_keys = ON").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := MigrationsList{}
+	l.Register(exec(
+		"CREATE TABLE parents (id TEXT PRIMARY KEY)",
+		"CREATE TABLE children (id TEXT PRIMARY KEY, parent TEXT REFERENCES parents (id) ON DELETE CASCADE)",
+		"INSERT INTO parents (id) VALUES ('p1')",
+		"INSERT INTO children (id, parent) VALUES ('c1', 'p1')",
+	), nil, "1_init.go")
+
+	l.Add(&Migration{
+		File: "2_rebuild.go",
+		Up: exec(
+			"CREATE TABLE new_parents (id TEXT PRIMARY KEY, title TEXT DEFAULT '')",
+			"INSERT INTO new_parents (id) SELECT id FROM parents",
+			"DROP TABLE parents",
+			"ALTER TABLE new_parents RENAME TO parents",
+		),
+		DisableForeignKeys: true,
+	})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var children int
+	testDB.Select("count(*)").From("children").Row(&children)
+	if children != 1 {
+		t.Fatalf("Expected the rebuild to not cascade delete the children, got %d children", children)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "2_rebuild.go") {
+		t.Fatal("Expected 2_rebuild.go to be applied")
+	}
+
+	assertForeignKeysEnabled(t, testDB)
+
+	// new violations fail the migration
+	r.migrationsList.(*MigrationsList).Add(&Migration{
+		File:               "3_orphan.go",
+		Up:                 exec("INSERT INTO children (id, parent) VALUES ('c2', 'missing')"),
+		DisableForeignKeys: true,
+	})
+
+	_, err = r.Up()
+	if err == nil || !strings.Contains(err.Error(), "introduced foreign key violations") {
+		t.Fatalf("Expected foreign key violations error, got %v", err)
+	}
+
+	testDB.Select("count(*)").From("children").Row(&children)
+	if children != 1 {
+		t.Fatalf("Expected the failed migration to be rolled back, got %d children", children)
+	}
+
+	if r.isMigrationApplied(testDB, "", "3_orphan.go") {
+		t.Fatal("Expected 3_orphan.go to not be applied")
+	}
+
+	assertForeignKeysEnabled(t, testDB)
+
+	// not supported with a caller provided transaction
+	err = testDB.Transactional(func(tx *dbx.Tx) error {
+		_, err := r.UpTx(tx)
+		return err
+	})
+	if err == nil || !strings.Contains(err.Error(), "caller provided transaction") {
+		t.Fatalf("Expected caller provided transaction error, got %v", err)
+	}
+}
+
+func assertForeignKeysEnabled(t *testing.T, db *testDB) {
+	t.Helper()
+
+	var enabled bool
+	if err := db.NewQuery("PRAGMA foreign_keys").Row(&enabled); err != nil {
+		t.Fatal(err)
+	}
+
+	if !enabled {
+		t.Fatal("Expected the foreign keys enforcement to be restored")
+	}
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pocketbaseModule is the PocketBase module path used in the builtin templates.
+const pocketbaseModule = "github.com/pocketbase/pocketbase"
+
+// pocketbaseModuleRegex matches the PocketBase module path
+// (optionally with a major version suffix, eg. ".../pocketbase/v2").
+var pocketbaseModuleRegex = regexp.MustCompile(`^github\.com/pocketbase/pocketbase(/v\d+)?$`)
+
+// findGoMod returns the directory and the content of the nearest
+// go.mod file, searching from dir up to the filesystem root.
+func findGoMod(dir string) (string, []byte, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, false
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		if content, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			return root, content, true
+		}
+
+		if filepath.Dir(root) == root {
+			return "", nil, false
+		}
+	}
+}
+
+// goModulePath returns the module path from the go.mod file content.
+func goModulePath(content []byte) string {
+	for _, fields := range goModLines(content) {
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+
+	return ""
+}
+
+// goModPocketBasePath returns the PocketBase module path (with its
+// major version suffix, if any) that the go.mod content defines or
+// requires (eg. "github.com/pocketbase/pocketbase/v2").
+func goModPocketBasePath(content []byte) (string, bool) {
+	inRequireBlock := false
+
+	for _, fields := range goModLines(content) {
+		if len(fields) == 0 {
+			continue
+		}
+
+		var path string
+
+		switch {
+		case fields[0] == "module" && len(fields) >= 2:
+			path = fields[1]
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inRequireBlock = true
+		case fields[0] == "require" && len(fields) >= 3:
+			path = fields[1]
+		case inRequireBlock && fields[0] == ")":
+			inRequireBlock = false
+		case inRequireBlock && len(fields) >= 2:
+			path = fields[0]
+		}
+
+		path = strings.Trim(path, "\"`")
+		if pocketbaseModuleRegex.MatchString(path) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// goModLines returns the fields of each go.mod content line (without the comments).
+func goModLines(content []byte) [][]string {
+	result := [][]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		result = append(result, strings.Fields(line))
+	}
+
+	return result
+}
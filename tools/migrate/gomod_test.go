@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoModulePath(t *testing.T) {
+	scenarios := []struct {
+		content  string
+		expected string
+	}{
+		{"", ""},
+		{"go 1.18\n", ""},
+		{"module example.com/app\n", "example.com/app"},
+		{"// comment\nmodule \"example.com/quoted\"\n\ngo 1.18\n", "example.com/quoted"},
+	}
+
+	for i, s := range scenarios {
+		if result := goModulePath([]byte(s.content)); result != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result)
+		}
+	}
+}
+
+func TestGoModPocketBasePath(t *testing.T) {
+	scenarios := []struct {
+		content      string
+		expectedPath string
+		expectedOk   bool
+	}{
+		{"module example.com/app\n", "", false},
+		{"module example.com/app\n\nrequire github.com/pocketbase/pocketbase v0.7.0\n", "github.com/pocketbase/pocketbase", true},
+		{"module example.com/app\n\nrequire (\n\tgithub.com/pocketbase/dbx v1.6.0\n\tgithub.com/pocketbase/pocketbase/v2 v2.1.0 // indirect\n)\n", "github.com/pocketbase/pocketbase/v2", true},
+		{"module example.com/app\n\nrequire (\n\tgithub.com/pocketbase/pocketbase-extra v1.0.0\n)\n", "", false},
+		{"// github.com/pocketbase/pocketbase/v3\nmodule github.com/pocketbase/pocketbase/v3\n", "github.com/pocketbase/pocketbase/v3", true},
+	}
+
+	for i, s := range scenarios {
+		path, ok := goModPocketBasePath([]byte(s.content))
+
+		if path != s.expectedPath || ok != s.expectedOk {
+			t.Errorf("(%d) Expected %q (%v), got %q (%v)", i, s.expectedPath, s.expectedOk, path, ok)
+		}
+	}
+}
+
+func TestRunnerTemplateContentPocketBaseImports(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\nrequire github.com/pocketbase/pocketbase/v2 v2.0.0\n"), 0644)
+
+	// the migrations dir is not required to exist
+	dir := filepath.Join(root, "migrations")
+
+	scenarios := []struct {
+		style      string
+		collection string
+	}{
+		{TemplateStyleAuto, ""},
+		{TemplateStyleDBX, ""},
+		{TemplateStyleAuto, "posts"},
+		{TemplateStyleDBX, "posts"},
+	}
+
+	for i, s := range scenarios {
+		r := &Runner{TemplateStyle: s.style}
+
+		result := r.templateContent(dir, s.collection)
+
+		if !strings.Contains(result, `m "github.com/pocketbase/pocketbase/v2/migrations"`) {
+			t.Errorf("(%d) Expected the v2 migrations import, got \n%s", i, result)
+		}
+
+		if strings.Contains(result, `"github.com/pocketbase/pocketbase/migrations"`) {
+			t.Errorf("(%d) Expected no unversioned imports, got \n%s", i, result)
+		}
+
+		if !strings.Contains(result, `"github.com/pocketbase/dbx"`) {
+			t.Errorf("(%d) Expected the dbx import to remain unchanged, got \n%s", i, result)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// HealthCheck checks whether the migrations runner db is ready to serve
+// (eg. for a readiness probe) and returns a descriptive error if:
+//   - the db is not reachable
+//   - the db is corrupted (only if Runner.HealthCheckIntegrity is set)
+//   - there are pending migrations (wrapping ErrPendingMigrations)
+//   - a migrations run of the same Runner instance is in progress
+//
+// The check doesn't wait for the in progress runs, so it is safe
+// to be called periodically during long migrations.
+func (r *Runner) HealthCheck(ctx context.Context) error {
+	if !r.mu.TryLock() {
+		return errors.New("A migrations run is in progress")
+	}
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	db := r.db.WithContext(ctx)
+
+	if _, err := db.NewQuery("SELECT 1").Execute(); err != nil {
+		return fmt.Errorf("The database is not reachable: %w", err)
+	}
+
+	if r.HealthCheckIntegrity {
+		problems, err := integrityProblems(db)
+		if err != nil {
+			return err
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("The database integrity check failed:\n- %s", strings.Join(problems, "\n- "))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pending, err := r.PendingMigrations()
+	if err != nil {
+		return fmt.Errorf("Failed to load the pending migrations: %w", err)
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerHealthCheck(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE parents (id TEXT PRIMARY KEY)").Execute()
+		if err != nil {
+			return err
+		}
+		_, err = db.NewQuery("CREATE TABLE children (id TEXT PRIMARY KEY, parent TEXT REFERENCES parents (id))").Execute()
+		return err
+	}, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pending migrations
+	err = r.HealthCheck(context.Background())
+	if !errors.Is(err, ErrPendingMigrations) || !strings.Contains(err.Error(), "1_test") {
+		t.Fatalf("Expected ErrPendingMigrations with the pending migration, got %v", err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("Expected healthy db, got %v", err)
+	}
+
+	// integrity problems are reported only with HealthCheckIntegrity
+	_, err = testDB.NewQuery("INSERT INTO children (id, parent) VALUES ('c1', 'missing')").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("Expected the integrity to not be checked, got %v", err)
+	}
+
+	r.HealthCheckIntegrity = true
+
+	err = r.HealthCheck(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "foreign key violation in children") {
+		t.Fatalf("Expected integrity check error, got %v", err)
+	}
+
+	r.HealthCheckIntegrity = false
+
+	// in progress run
+	r.mu.Lock()
+	err = r.HealthCheck(context.Background())
+	r.mu.Unlock()
+	if err == nil || !strings.Contains(err.Error(), "in progress") {
+		t.Fatalf("Expected in progress error, got %v", err)
+	}
+
+	// canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.HealthCheck(ctx); err == nil {
+		t.Fatal("Expected canceled context error, got nil")
+	}
+
+	// closed runner
+	r.Close()
+	if err := r.HealthCheck(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+}
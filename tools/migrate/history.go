@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+// AppliedMigration defines a single applied migration record.
+type AppliedMigration struct {
+	File string `db:"file" json:"file"`
+
+	// Applied is the unix timestamp (in nanoseconds)
+	// when the migration was applied.
+	Applied int64 `db:"applied" json:"applied"`
+
+	// AppliedBy is the Runner.Applier at the time of the migration apply.
+	AppliedBy string `db:"applied_by" json:"appliedBy"`
+
+	// Set is the name of the migrations set
+	// (empty for the default set).
+	Set string `db:"set" json:"set"`
+
+	// RunId is the unique id of the run that applied the migration.
+	RunId string `db:"run_id" json:"runId"`
+
+	// Confirmed specifies whether the migration apply was confirmed
+	// (it is false only for the unconfirmed Runner.Tentative applies).
+	Confirmed bool `db:"confirmed" json:"confirmed"`
+
+	// Log is the (truncated) log that the migration
+	// wrote with Logf while it was applied.
+	Log string `db:"log" json:"log"`
+}
+
+// AppliedAt returns the Applied unix timestamp as time.Time.
+func (a AppliedMigration) AppliedAt() time.Time {
+	return time.Unix(0, a.Applied)
+}
+
+// AppliedMigrations returns all currently applied migrations
+// records ordered by their exact apply time.
+func (r *Runner) AppliedMigrations() ([]AppliedMigration, error) {
+	result := []AppliedMigration{}
+
+	err := r.db.Select("file", "applied", "applied_by", "set", "run_id", "confirmed", "log").
+		From(r.TableName).
+		Where(appliedRecordExp()).
+		OrderBy("applied ASC", "file ASC").
+		All(&result)
+
+	return result, err
+}
+
+// AppliedSince returns the currently applied migrations records
+// that were applied in the last d duration (sorted by their apply time).
+func (r *Runner) AppliedSince(d time.Duration) ([]AppliedMigration, error) {
+	result := []AppliedMigration{}
+
+	since := r.now().Add(-d).UnixNano()
+
+	err := r.db.Select("file", "applied", "applied_by", "set", "run_id", "confirmed", "log").
+		From(r.TableName).
+		Where(appliedRecordExp()).
+		AndWhere(dbx.NewExp("[[applied]] >= {:since}", dbx.Params{"since": since})).
+		OrderBy("applied ASC", "file ASC").
+		All(&result)
+
+	return result, err
+}
+
+// parseSinceDuration parses the "--since" flag duration, supporting
+// also a days suffix in addition to the time.ParseDuration units (eg. 12h or 7d).
+func parseSinceDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("Invalid --since duration %q (expected duration like 12h or 7d)", value)
+}
+
+// LastApplied returns the most recently applied migration record
+// (aka. the current schema head).
+//
+// Returns false if there are no applied migrations.
+func (r *Runner) LastApplied() (AppliedMigration, bool, error) {
+	result := AppliedMigration{}
+
+	err := r.db.Select("file", "applied", "applied_by", "set", "run_id", "confirmed", "log").
+		From(r.TableName).
+		Where(appliedRecordExp()).
+		OrderBy("applied DESC", "file DESC").
+		Limit(1).
+		One(&result)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, false, nil
+	}
+
+	if err != nil {
+		return result, false, err
+	}
+
+	return result, true, nil
+}
+
+// MarkApplied records the specified default set migration file as
+// applied at the provided time, without executing its Up func.
+//
+// This is intended for importing the migrations history from other
+// tools while preserving the original apply timestamps.
+func (r *Runner) MarkApplied(file string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if file == "" {
+		return errors.New("Missing migration file name")
+	}
+
+	if at.IsZero() {
+		return fmt.Errorf("Missing applied time for migration %s", file)
+	}
+
+	return r.db.Transactional(func(tx *dbx.Tx) error {
+		applied, err := r.checkMigrationApplied(tx, "", file)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return fmt.Errorf("Migration %s is already applied", file)
+		}
+
+		return r.saveAppliedMigrationAt(tx, "", file, at.UnixNano())
+	})
+}
@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IndexMarker is the optional index file line before which the new
+// entries are inserted (see Runner.IndexFile), eg.:
+//
+//	func init() {
+//		m.Register(m1656512345_init.Up, m1656512345_init.Down)
+//		// migrate:index
+//	}
+const IndexMarker = "// migrate:index"
+
+// updateIndex adds the entry of the created migration file to the
+// runner IndexFile (if not already added).
+//
+// The entry is inserted before the IndexMarker line (if any).
+// Otherwise the default blank import entry is inserted at the end of
+// the index file import block and the custom Runner.IndexEntry lines
+// are appended at the end of the file.
+//
+// Returns false if the entry is already in the index file.
+func (r *Runner) updateIndex(migrationPath string) (bool, error) {
+	var entry string
+	var err error
+	if r.IndexEntry != nil {
+		entry = r.IndexEntry(filepath.Base(migrationPath))
+	} else {
+		entry, err = blankImportEntry(migrationPath, r.IndexFile)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	content, err := os.ReadFile(r.IndexFile)
+	if err != nil {
+		return false, fmt.Errorf("Failed to read the index file %q: %w", r.IndexFile, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == strings.TrimSpace(entry) {
+			return false, nil // already added
+		}
+	}
+
+	insertAt := -1
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == IndexMarker {
+			insertAt = i
+			entry = line[:len(line)-len(strings.TrimLeft(line, " \t"))] + strings.TrimSpace(entry)
+			break
+		}
+	}
+
+	if insertAt < 0 && r.IndexEntry == nil {
+		insertAt = importBlockEnd(lines)
+		if insertAt < 0 {
+			return false, fmt.Errorf("Missing import block or %q line in the index file %q", IndexMarker, r.IndexFile)
+		}
+		entry = "\t" + entry
+	}
+
+	if insertAt < 0 {
+		// append to the end of the file (before the trailing new line)
+		insertAt = len(lines)
+		if insertAt > 0 && lines[insertAt-1] == "" {
+			insertAt--
+		}
+	}
+
+	lines = append(lines[:insertAt], append([]string{entry}, lines[insertAt:]...)...)
+
+	result := []byte(strings.Join(lines, "\n"))
+
+	if strings.HasSuffix(r.IndexFile, ".go") {
+		if formatted, err := format.Source(result); err == nil {
+			result = formatted
+		}
+	}
+
+	if err := os.WriteFile(r.IndexFile, result, 0644); err != nil {
+		return false, fmt.Errorf("Failed to save the index file %q: %w", r.IndexFile, err)
+	}
+
+	return true, nil
+}
+
+// importBlockEnd returns the index of the closing line
+// of the first "import (" block (or -1 if there is none).
+func importBlockEnd(lines []string) int {
+	inBlock := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case !inBlock && trimmed == "import (":
+			inBlock = true
+		case inBlock && trimmed == ")":
+			return i
+		}
+	}
+
+	return -1
+}
+
+// blankImportEntry returns the blank import of the Go package of the
+// migration file, resolved based on the nearest go.mod module path.
+func blankImportEntry(migrationPath string, indexFile string) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(migrationPath))
+	if err != nil {
+		return "", err
+	}
+
+	indexDir, err := filepath.Abs(filepath.Dir(indexFile))
+	if err != nil {
+		return "", err
+	}
+
+	if dir == indexDir {
+		return "", errors.New("The index file is in the migrations package - set Runner.IndexEntry to use an explicit registration entry")
+	}
+
+	root, content, ok := findGoMod(dir)
+	if !ok {
+		return "", fmt.Errorf("Failed to resolve the import path of %q (missing go.mod)", dir)
+	}
+
+	module := goModulePath(content)
+	if module == "" {
+		return "", fmt.Errorf("Missing module path in %q", filepath.Join(root, "go.mod"))
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+
+	importPath := module
+	if rel != "." {
+		importPath += "/" + filepath.ToSlash(rel)
+	}
+
+	return "_ " + strconv.Quote(importPath), nil
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunnerRunCreateIndex(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n\ngo 1.18\n"), 0644)
+
+	indexFile := filepath.Join(root, "migrations", "migrations.go")
+	os.MkdirAll(filepath.Dir(indexFile), os.ModePerm)
+	os.WriteFile(indexFile, []byte("package migrations\n\nimport (\n\t_ \"example.com/app/migrations/logs\"\n)\n"), 0644)
+
+	r.Dir = filepath.Join(root, "migrations", "app")
+
+	// blank import entry
+	for i := 0; i < 2; i++ {
+		if err := r.Run("create", "test", "--index", indexFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if r.IndexFile != "" {
+		t.Fatalf("Expected the IndexFile to be restored, got %q", r.IndexFile)
+	}
+
+	content, _ := os.ReadFile(indexFile)
+
+	expected := "package migrations\n\nimport (\n\t_ \"example.com/app/migrations/app\"\n\t_ \"example.com/app/migrations/logs\"\n)\n"
+	if string(content) != expected {
+		t.Fatalf("Expected index file \n%s, \ngot \n%s", expected, content)
+	}
+
+	// custom entry before the marker
+	os.WriteFile(indexFile, []byte("package migrations\n\nfunc init() {\n\t// migrate:index\n}\n"), 0644)
+
+	r.IndexFile = indexFile
+	r.IndexEntry = func(file string) string {
+		return "register(" + strings.TrimSuffix(file, ".go")[11:] + ")"
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Run("create", "test_entry"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, _ = os.ReadFile(indexFile)
+
+	expected = "package migrations\n\nfunc init() {\n\tregister(test_entry)\n\t// migrate:index\n}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected index file \n%s, \ngot \n%s", expected, content)
+	}
+
+	// the index in the same migrations package requires an explicit entry
+	r.IndexEntry = nil
+	r.Dir = filepath.Dir(indexFile)
+	if err := r.Run("create", "test"); err == nil {
+		t.Fatal("Expected the same package index error, got nil")
+	}
+
+	if err := r.Run("create", "test", "--index"); err == nil {
+		t.Fatal("Expected missing --index path error, got nil")
+	}
+}
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+)
+
+// checkIntegrity runs the SQLite "PRAGMA integrity_check" and
+// "PRAGMA foreign_key_check" and returns an error with the reported
+// problems (if any).
+func (r *Runner) checkIntegrity() error {
+	problems, err := integrityProblems(r.builder())
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf(
+			"The database integrity check failed (the migrations were not applied):\n- %s",
+			strings.Join(problems, "\n- "),
+		)
+	}
+
+	return nil
+}
+
+// integrityProblems returns the problems reported by the SQLite
+// "PRAGMA integrity_check" and "PRAGMA foreign_key_check".
+func integrityProblems(db dbx.Builder) ([]string, error) {
+	problems := []string{}
+
+	err := db.NewQuery("PRAGMA integrity_check").Column(&problems)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check the database integrity: %w", err)
+	}
+
+	if len(problems) == 1 && strings.EqualFold(problems[0], "ok") {
+		problems = problems[:0]
+	}
+
+	violations := []struct {
+		Table  string        `db:"table"`
+		RowId  sql.NullInt64 `db:"rowid"`
+		Parent string        `db:"parent"`
+	}{}
+
+	err = db.NewQuery("PRAGMA foreign_key_check").All(&violations)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check the database foreign keys: %w", err)
+	}
+
+	for _, v := range violations {
+		problems = append(problems, fmt.Sprintf(
+			"foreign key violation in %s (rowid %d) referencing %s",
+			v.Table,
+			v.RowId.Int64,
+			v.Parent,
+		))
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerIntegrityCheckBeforeUp(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	upCalled := false
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		upCalled = true
+		return nil
+	}, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.IntegrityCheckBeforeUp = true
+
+	if err := r.checkIntegrity(); err != nil {
+		t.Fatalf("Expected the empty db to pass the integrity check, got %v", err)
+	}
+
+	// foreign key violation
+	queries := []string{
+		"CREATE TABLE parent (id TEXT PRIMARY KEY)",
+		"CREATE TABLE child (id TEXT PRIMARY KEY, parent TEXT REFERENCES parent (id))",
+		"INSERT INTO child (id, parent) VALUES ('a', 'missing')",
+	}
+	for _, q := range queries {
+		if _, err := testDB.NewQuery(q).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = r.Up()
+	if err == nil || !strings.Contains(err.Error(), "foreign key violation in child (rowid 1) referencing parent") {
+		t.Fatalf("Expected foreign key violation error, got %v", err)
+	}
+
+	if upCalled {
+		t.Fatal("Expected the migration to not be applied")
+	}
+
+	// opt-in
+	r.IntegrityCheckBeforeUp = false
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !upCalled {
+		t.Fatal("Expected the migration to be applied")
+	}
+}
@@ -1,6 +1,8 @@
 package migrate
 
 import (
+	"fmt"
+	"io/fs"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -8,24 +10,149 @@ import (
 	"github.com/pocketbase/dbx"
 )
 
-type migration struct {
-	file string
-	up   func(db dbx.Builder) error
-	down func(db dbx.Builder) error
+// Migration defines a single migration definition.
+type Migration struct {
+	// File is the unique migration file name (eg. "1656512345_init.go").
+	File string
+
+	// Up is the func that applies the migration.
+	Up func(db dbx.Builder) error
+
+	// Down is the func that reverts the migration.
+	Down func(db dbx.Builder) error
+
+	// Validate is an optional func that checks the migration
+	// preconditions (eg. that a column is empty) and it is executed
+	// with the same db builder immediately before the Up func.
+	//
+	// If it fails, the migration is not applied and a
+	// *ValidationError is returned.
+	Validate func(db dbx.Builder) error
+
+	// NonTransactional specifies whether the migration should be executed
+	// outside of a transaction (eg. for VACUUM, REINDEX, etc.).
+	//
+	// Note that the atomicity of such migrations is not guaranteed.
+	// The migration funcs are executed directly against the db and the
+	// migration state is recorded with a separate follow-up statement,
+	// meaning that a failure in the middle of the migration could leave
+	// some of its changes applied. The transactional migrations before
+	// and after it are executed in their own separate transactions.
+	NonTransactional bool
+
+	// Transaction is an optional transaction preference of the migration
+	// that overrides the runner PerMigrationTx default, eg. to apply
+	// a migration atomically with its adjacent migrations even in per
+	// migration mode (TransactionBatch) or in its own transaction
+	// during a batch run (TransactionOwn).
+	//
+	// TransactionNone is the same as NonTransactional.
+	Transaction TransactionMode
+
+	// DisableForeignKeys specifies whether to turn off the SQLite foreign
+	// keys enforcement while executing the migration (eg. for the table
+	// rebuilds where the DROP TABLE of the old table must not cascade).
+	//
+	// The migration is executed in its own transaction on a dedicated
+	// connection and it fails if it introduces new foreign key violations
+	// (checked with "PRAGMA foreign_key_check" before the commit).
+	// The previous foreign keys setting is always restored.
+	DisableForeignKeys bool
+
+	// IrreversibleReason marks the migration as intentionally
+	// irreversible and describes why (eg. "legacy data permanently removed").
+	//
+	// If set, reverting the migration fails with the reason as error
+	// instead of calling its Down func.
+	IrreversibleReason string
+
+	// DependsOn is an optional list with the file names of the
+	// migrations that must be applied before this one.
+	//
+	// It is used for the apply order only when Runner.DependencyOrder is set.
+	DependsOn []string
+
+	// Creates is an optional list with the db objects that the migration
+	// creates (eg. the tables and indexes of a NonTransactional migration).
+	//
+	// It is used by ResumeCleanup ("resume --cleanup") to drop the
+	// partial objects of a failed migration before re-applying it.
+	Creates []MigrationObject
+
+	// AffectedTables is an optional list with the names of the tables
+	// that the migration creates, alters or changes the data of.
+	//
+	// It is used only for informational purposes (see Runner.MigrationsForTable).
+	AffectedTables []string
+
+	// Checkpoint marks the migration as a checkpoint whose Up func
+	// produces the state of all previous migrations of its set
+	// (eg. a full schema snapshot).
+	//
+	// It is used only when Runner.UseCheckpoints is set.
+	Checkpoint bool
+
+	// set is the name of the migrations set the migration belongs to
+	// (empty for the default set, see Runner.RegisterSet).
+	set string
+
+	// SQL migrations only
+	isSQL      bool
+	upSQL      []string
+	downSQL    []string
+	hasDownSQL bool         // whether the migration has a "-- +down" section
+	fsys       fs.FS        // the source fs of lazily loaded migrations
+	load       func() error // lazily loads upSQL and downSQL (if not already)
+}
+
+// Migrations defines a source of migration definitions
+// (eg. MigrationsList, database stored or remote registry migrations).
+//
+// Items must return the migrations in their apply order
+// (sorted by their file name, see MigrationsList.Register).
+type Migrations interface {
+	// Len returns the number of migrations.
+	Len() int
+
+	// Item returns a single migration by its index
+	// (or nil if the index is out of range).
+	Item(index int) *Migration
+
+	// Items returns all migrations in their apply order.
+	Items() []*Migration
 }
 
 // MigrationsList defines a list with migration definitions
+// and it is the default Migrations implementation.
 type MigrationsList struct {
-	list []*migration
+	list []*Migration
+}
+
+// Len returns the number of migrations in the list.
+func (l MigrationsList) Len() int {
+	return len(l.list)
+}
+
+// Get returns a single migration from the list by its index
+// and whether the index is in the list range.
+func (l MigrationsList) Get(index int) (*Migration, bool) {
+	if index < 0 || index >= len(l.list) {
+		return nil, false
+	}
+
+	return l.list[index], true
 }
 
-// Item returns a single migration from the list by its index.
-func (l *MigrationsList) Item(index int) *migration {
-	return l.list[index]
+// Item returns a single migration from the list by its index
+// (or nil if the index is out of range, see also Get).
+func (l MigrationsList) Item(index int) *Migration {
+	m, _ := l.Get(index)
+
+	return m
 }
 
 // Items returns the internal migrations list slice.
-func (l *MigrationsList) Items() []*migration {
+func (l MigrationsList) Items() []*Migration {
 	return l.list
 }
 
@@ -47,13 +174,90 @@ func (l *MigrationsList) Register(
 		file = filepath.Base(path)
 	}
 
-	l.list = append(l.list, &migration{
-		file: file,
-		up:   up,
-		down: down,
+	l.Add(&Migration{
+		File: file,
+		Up:   up,
+		Down: down,
+	})
+}
+
+// RegisterIrreversible adds new intentionally irreversible
+// migration definition to the list (see Migration.IrreversibleReason).
+//
+// If `optFilename` is not provided, it will try to get the name from its .go file.
+func (l *MigrationsList) RegisterIrreversible(
+	up func(db dbx.Builder) error,
+	reason string,
+	optFilename ...string,
+) {
+	var file string
+	if len(optFilename) > 0 {
+		file = optFilename[0]
+	} else {
+		_, path, _, _ := runtime.Caller(1)
+		file = filepath.Base(path)
+	}
+
+	l.Add(&Migration{
+		File:               file,
+		Up:                 up,
+		IrreversibleReason: reason,
 	})
+}
+
+// Add adds the provided migration definition to the list.
+//
+// The list will be sorted automatically based on the migrations file name.
+func (l *MigrationsList) Add(m *Migration) {
+	l.list = append(l.list, m)
 
-	sort.Slice(l.list, func(i int, j int) bool {
-		return l.list[i].file < l.list[j].file
+	sort.SliceStable(l.list, func(i int, j int) bool {
+		return migrationLess(l.list[i], l.list[j])
 	})
 }
+
+// validate executes the migration Validate func (if any).
+func (m *Migration) validate(db dbx.Builder) error {
+	if m.Validate == nil {
+		return nil
+	}
+
+	if err := m.Validate(db); err != nil {
+		return &ValidationError{File: m.File, Err: err}
+	}
+
+	return nil
+}
+
+// ValidationError defines an unmet migration precondition error
+// (see Migration.Validate).
+type ValidationError struct {
+	File string
+	Err  error
+}
+
+// Error implements the [error] interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Cannot apply migration %s: %v", e.File, e.Err)
+}
+
+// Unwrap returns the underlying Validate error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// revert executes the migration Down func.
+//
+// Returns an error if the migration is irreversible and
+// it is no-op if the migration doesn't have a Down func.
+func (m *Migration) revert(db dbx.Builder) error {
+	if m.IrreversibleReason != "" {
+		return fmt.Errorf("Irreversible migration: %s", m.IrreversibleReason)
+	}
+
+	if m.Down == nil {
+		return nil
+	}
+
+	return m.Down(db)
+}
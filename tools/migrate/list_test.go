@@ -1,7 +1,10 @@
 package migrate
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/pocketbase/dbx"
 )
 
 func TestMigrationsList(t *testing.T) {
@@ -26,8 +29,138 @@ func TestMigrationsList(t *testing.T) {
 
 	for i, name := range expected {
 		item := l.Item(i)
-		if item.file != name {
-			t.Fatalf("Expected name %s for index %d, got %s", name, i, item.file)
+		if item.File != name {
+			t.Fatalf("Expected name %s for index %d, got %s", name, i, item.File)
+		}
+	}
+}
+
+func TestMigrationsListBounds(t *testing.T) {
+	empty := MigrationsList{}
+
+	single := MigrationsList{}
+	single.Register(nil, nil, "1_test.go")
+
+	scenarios := []struct {
+		list         MigrationsList
+		index        int
+		expectedLen  int
+		expectedFile string
+	}{
+		{empty, -1, 0, ""},
+		{empty, 0, 0, ""},
+		{single, -1, 1, ""},
+		{single, 0, 1, "1_test.go"},
+		{single, 1, 1, ""},
+	}
+
+	for i, s := range scenarios {
+		if l := s.list.Len(); l != s.expectedLen {
+			t.Errorf("(%d) Expected Len %d, got %d", i, s.expectedLen, l)
+		}
+
+		m, ok := s.list.Get(s.index)
+		if ok != (s.expectedFile != "") {
+			t.Errorf("(%d) Expected ok %v, got %v", i, s.expectedFile != "", ok)
+		}
+
+		item := s.list.Item(s.index)
+		if item != m {
+			t.Errorf("(%d) Expected Item and Get to return the same migration", i)
 		}
+
+		var file string
+		if m != nil {
+			file = m.File
+		}
+		if file != s.expectedFile {
+			t.Errorf("(%d) Expected file %q, got %q", i, s.expectedFile, file)
+		}
+	}
+}
+
+func TestRunnerDownSingleAndEmptyList(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	empty, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted, err := empty.Down(1); err != nil || len(reverted) != 0 {
+		t.Fatalf("Expected no reverted migrations, got %v (%v)", reverted, err)
+	}
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, func(db dbx.Builder) error { return nil }, "1_test.go")
+
+	single, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := single.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := single.Down(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0] != "1_test.go" {
+		t.Fatalf("Expected 1_test.go to be reverted, got %v", reverted)
+	}
+}
+
+func TestMigrationsListRegisterIrreversible(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test.go")
+	l.RegisterIrreversible(func(db dbx.Builder) error { return nil }, "legacy data permanently removed", "2_drop_legacy.go")
+	l.RegisterIrreversible(nil, "test" /* auto detect file name */)
+
+	if file := l.Item(2).File; file != "list_test.go" {
+		t.Fatalf("Expected the auto detected list_test.go file name, got %s", file)
+	}
+
+	if reason := l.Item(1).IrreversibleReason; reason != "legacy data permanently removed" {
+		t.Fatalf("Expected the irreversible reason to be stored, got %q", reason)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test.go")
+	r.saveAppliedMigration(testDB, "", "2_drop_legacy.go")
+
+	var b strings.Builder
+	if err := r.printTree(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "[irreversible: legacy data permanently removed]") {
+		t.Fatalf("Expected the irreversible reason in the tree, got \n%s", b.String())
+	}
+
+	_, err = r.Down(2)
+	if err == nil || !strings.Contains(err.Error(), "2_drop_legacy.go: Irreversible migration: legacy data permanently removed") {
+		t.Fatalf("Expected descriptive irreversible error, got %v", err)
+	}
+
+	if applied, _ := r.IsApplied("1_test.go"); !applied {
+		t.Fatal("Expected 1_test.go to remain applied")
+	}
+
+	// nil Down func
+	if err := r.RunOne("1_test.go", DirectionDown, true); err != nil {
+		t.Fatalf("Expected nil Down func to be no-op, got %v", err)
 	}
 }
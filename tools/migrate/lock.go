@@ -0,0 +1,254 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// DefaultLockTimeout is the default max wait for the migrations lock
+// held by another process (see Runner.LockTimeout).
+const DefaultLockTimeout = time.Minute
+
+// DefaultLockTTL is the default max age of a migrations lock record that
+// is not refreshed by its holder before it is considered stale and taken
+// over by another process (see Runner.LockTTL).
+const DefaultLockTTL = 2 * time.Minute
+
+// lockPollInterval is the delay between the migrations lock attempts.
+const lockPollInterval = 100 * time.Millisecond
+
+// lockId is the id of the single migrations lock record.
+const lockId = "migrations"
+
+// lockTable returns the name of the table with the migrations lock
+// record (eg. "_migrationsLock").
+func (r *Runner) lockTable() string {
+	return r.TableName + "Lock"
+}
+
+func (r *Runner) createLockTable() error {
+	rawQuery := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (id %s PRIMARY KEY NOT NULL, owner %s NOT NULL, acquired %s NOT NULL)",
+		r.db.QuoteTableName(r.lockTable()),
+		r.Dialect.KeyType(),
+		r.Dialect.KeyType(),
+		r.Dialect.IntegerType(),
+	)
+
+	_, err := r.db.NewQuery(rawQuery).Execute()
+
+	return err
+}
+
+// acquireLock waits up to timeout for the migrations lock record to
+// be released by another process and acquires it, returning the func
+// that releases it.
+//
+// The lock is stored as a single row in the lock table (instead of a db
+// specific advisory lock), so it works with every supported db and
+// coordinates also processes that don't share the same connection pool.
+//
+// While the lock is held, its "acquired" time is refreshed in the
+// background every quarter of the runner LockTTL. A lock record that
+// wasn't refreshed for longer than LockTTL (eg. of a crashed process)
+// is considered stale and is taken over.
+//
+// For SQLite the db is expected to be in WAL mode (as the app db), because
+// with the rollback journal the initialization queries of the waiting
+// processes could block the writes of the lock holder until its tracking
+// retries run out.
+func (r *Runner) acquireLock(timeout time.Duration) (func() error, error) {
+	if err := r.withTrackingRetry(r.createLockTable); err != nil {
+		return nil, fmt.Errorf("Failed to create the migrations lock table: %w", err)
+	}
+
+	owner := lockOwner()
+	verb, suffix := r.Dialect.InsertIgnore()
+	deadline := time.Now().Add(timeout)
+
+	for {
+		var acquired bool
+
+		err := r.withTrackingRetry(func() error {
+			// check the lock with a read query first to avoid competing
+			// for the db write lock with the current lock holder run
+			holder, err := r.lockHolder()
+			if err != nil {
+				return err
+			}
+
+			if holder != nil {
+				if !r.isStaleLock(holder) {
+					return nil
+				}
+
+				// the "acquired" condition skips the takeover
+				// if the holder has refreshed the lock meanwhile
+				_, err := r.db.Delete(r.lockTable(), dbx.HashExp{
+					"id":       lockId,
+					"owner":    holder.Owner,
+					"acquired": holder.Acquired,
+				}).Execute()
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := r.db.NewQuery(strings.TrimSpace(fmt.Sprintf(
+				"%s INTO {{%s}} ([[id]], [[owner]], [[acquired]]) VALUES ({:id}, {:owner}, {:acquired}) %s",
+				verb,
+				r.lockTable(),
+				suffix,
+			))).Bind(dbx.Params{
+				"id":       lockId,
+				"owner":    owner,
+				"acquired": r.now().UnixNano(),
+			}).Execute()
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			acquired = affected > 0
+
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to acquire the migrations lock: %w", err)
+		}
+
+		if acquired {
+			break
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, r.lockHeldError(timeout)
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.refreshLock(owner, stop)
+	}()
+
+	release := func() error {
+		close(stop)
+		<-done
+
+		return r.withTrackingRetry(func() error {
+			_, err := r.db.Delete(r.lockTable(), dbx.HashExp{"id": lockId, "owner": owner}).Execute()
+			return err
+		})
+	}
+
+	return release, nil
+}
+
+// lockRecord is a migrations lock table record.
+type lockRecord struct {
+	Owner    string `db:"owner"`
+	Acquired int64  `db:"acquired"`
+}
+
+// lockHolder returns the current migrations lock record
+// or nil if the lock is not held.
+func (r *Runner) lockHolder() (*lockRecord, error) {
+	holder := &lockRecord{}
+
+	err := r.db.Select("owner", "acquired").
+		From(r.lockTable()).
+		Where(dbx.HashExp{"id": lockId}).
+		One(holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return holder, nil
+}
+
+// lockTTL returns the runner LockTTL (fallbacks to DefaultLockTTL).
+func (r *Runner) lockTTL() time.Duration {
+	if r.LockTTL <= 0 {
+		return DefaultLockTTL
+	}
+
+	return r.LockTTL
+}
+
+// isStaleLock reports whether the lock record wasn't refreshed
+// by its holder for longer than the runner LockTTL.
+func (r *Runner) isStaleLock(holder *lockRecord) bool {
+	return r.now().Sub(time.Unix(0, holder.Acquired)) > r.lockTTL()
+}
+
+// refreshLock periodically updates the "acquired" time of the owner
+// lock record until stop is closed.
+//
+// A failed refresh is only reported since it is retried on the next
+// tick (the lock is considered stale only after a full LockTTL).
+func (r *Runner) refreshLock(owner string, stop <-chan struct{}) {
+	ticker := time.NewTicker(r.lockTTL() / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := r.withTrackingRetry(func() error {
+				_, err := r.db.Update(
+					r.lockTable(),
+					dbx.Params{"acquired": r.now().UnixNano()},
+					dbx.HashExp{"id": lockId, "owner": owner},
+				).Execute()
+				return err
+			})
+			if err != nil {
+				color.Yellow("Failed to refresh the migrations lock: %v", err)
+			}
+		}
+	}
+}
+
+// lockHeldError returns the timeout error with the current lock owner.
+func (r *Runner) lockHeldError(timeout time.Duration) error {
+	holder, err := r.lockHolder()
+	if err != nil {
+		return fmt.Errorf("Timed out after %s waiting for the migrations lock: %w", timeout, err)
+	}
+	if holder == nil {
+		holder = &lockRecord{}
+	}
+
+	return fmt.Errorf(
+		"Timed out after %s waiting for the migrations lock held by %q (last refreshed at %s, "+
+			"considered stale after %s without a refresh)",
+		timeout,
+		holder.Owner,
+		time.Unix(0, holder.Acquired).UTC().Format(time.RFC3339),
+		r.lockTTL(),
+	)
+}
+
+// lockOwner returns a unique identifier of the current lock owner
+// in the format "hostname:pid:random".
+func lockOwner() string {
+	hostname, _ := os.Hostname()
+
+	return fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), security.RandomString(8))
+}
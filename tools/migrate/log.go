@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+)
+
+// DefaultMaxLogLength is the default max length (in bytes) of the
+// stored migration log (see Runner.MaxLogLength).
+const DefaultMaxLogLength = 16 * 1024
+
+const logTruncatedSuffix = "... (truncated)"
+
+// logSinks contains the active migration log sinks
+// keyed by the db builder passed to the migration funcs.
+var logSinks sync.Map
+
+// migrationLog collects the log of a single applying migration.
+type migrationLog struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+// Write implements the [io.Writer] interface.
+func (l *migrationLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.buf.Write(p)
+}
+
+// String returns the collected log.
+func (l *migrationLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.buf.String()
+}
+
+// Logf writes a formatted line to the log of the migration that is
+// executed with db (the builder passed to the migration Up func).
+//
+// The log is stored in the "log" column of the migration applied
+// record (see AppliedMigration.Log) and it is truncated to
+// Runner.MaxLogLength.
+//
+// Logf is a no-op if db is not an applying migration builder
+// (eg. when the migration is executed with the Executor).
+func Logf(db dbx.Builder, format string, args ...any) {
+	sink, ok := logSinks.Load(db)
+	if !ok {
+		return
+	}
+
+	line := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	io.WriteString(sink.(*migrationLog), line)
+}
+
+// LogWriter returns an [io.Writer] for the log of the migration
+// that is executed with db (see Logf).
+//
+// Returns [io.Discard] if db is not an applying migration builder.
+func LogWriter(db dbx.Builder) io.Writer {
+	if sink, ok := logSinks.Load(db); ok {
+		return sink.(*migrationLog)
+	}
+
+	return io.Discard
+}
+
+// captureLog executes fn with a log sink registered for db
+// and returns the collected log.
+func captureLog(db dbx.Builder, fn func() error) (string, error) {
+	sink := &migrationLog{}
+
+	logSinks.Store(db, sink)
+	defer logSinks.Delete(db)
+
+	err := fn()
+
+	return sink.String(), err
+}
+
+// maxLogLength returns the max length of the stored migrations log
+// limited by the dialect TextType length (if any).
+func (r *Runner) maxLogLength() int {
+	max := r.MaxLogLength
+	if max <= 0 {
+		max = DefaultMaxLogLength
+	}
+
+	if limiter, ok := r.Dialect.(TextLengthLimiter); ok && limiter.MaxTextLength() < max {
+		max = limiter.MaxTextLength()
+	}
+
+	return max
+}
+
+// saveMigrationLog stores the log of the applied m migration.
+func (r *Runner) saveMigrationLog(db dbx.Builder, m *Migration, log string) error {
+	if log == "" {
+		return nil
+	}
+
+	err := r.withTrackingRetry(func() error {
+		_, err := db.Update(
+			r.TableName,
+			dbx.Params{"log": truncateLog(log, r.maxLogLength())},
+			dbx.And(dbx.HashExp{"file": m.File, "set": m.set}, appliedRecordExp()),
+		).Execute()
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to save the log of %s: %w", m.File, err)
+	}
+
+	return nil
+}
+
+// truncateLog truncates log to max bytes (including the truncated
+// suffix) without splitting a multi-byte character.
+func truncateLog(log string, max int) string {
+	if len(log) <= max {
+		return log
+	}
+
+	cut := max - len(logTruncatedSuffix)
+	if cut <= 0 {
+		return logTruncatedSuffix[:max]
+	}
+
+	for cut > 0 && !utf8.RuneStart(log[cut]) {
+		cut--
+	}
+
+	return log[:cut] + logTruncatedSuffix
+}
@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerMigrationLog(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		Logf(db, "backfilled %d rows", 3)
+		fmt.Fprintln(LogWriter(db), "done")
+		return nil
+	}, nil, "1_test.go")
+	l.Register(func(db dbx.Builder) error {
+		return nil
+	}, nil, "2_test.go")
+	l.Register(func(db dbx.Builder) error {
+		Logf(db, strings.Repeat("a", 50))
+		return nil
+	}, nil, "3_test.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MaxLogLength = 20
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"1_test.go": "backf" + logTruncatedSuffix,
+		"2_test.go": "",
+		"3_test.go": "aaaaa" + logTruncatedSuffix,
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+
+	for _, a := range records {
+		if a.Log != expected[a.File] {
+			t.Errorf("Expected %s log %q, got %q", a.File, expected[a.File], a.Log)
+		}
+	}
+
+	// no-op outside of a migration
+	Logf(testDB.DB, "test")
+	if w := LogWriter(testDB.DB); w == nil {
+		t.Fatal("Expected non-nil writer")
+	}
+}
+
+func TestTruncateLog(t *testing.T) {
+	scenarios := []struct {
+		log      string
+		max      int
+		expected string
+	}{
+		{"", 20, ""},
+		{"abc", 20, "abc"},
+		{strings.Repeat("a", 20), 20, strings.Repeat("a", 20)},
+		{strings.Repeat("a", 21), 20, "aaaaa" + logTruncatedSuffix},
+		// doesn't split the multi-byte characters
+		{"aaaa€€€€€€€€", 20, "aaaa" + logTruncatedSuffix},
+		{strings.Repeat("a", 21), 5, "... ("},
+	}
+
+	for i, s := range scenarios {
+		result := truncateLog(s.log, s.max)
+
+		if result != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result)
+		}
+	}
+}
+
+func TestRunnerMaxLogLength(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		max      int
+		dialect  Dialect
+		expected int
+	}{
+		{0, SQLiteDialect{}, DefaultMaxLogLength},
+		{100, SQLiteDialect{}, 100},
+		{0, MySQLDialect{}, 255},
+		{100, MySQLDialect{}, 100},
+	}
+
+	for i, s := range scenarios {
+		r.MaxLogLength = s.max
+		r.Dialect = s.dialect
+
+		if v := r.maxLogLength(); v != s.expected {
+			t.Errorf("(%d) Expected %d, got %d", i, s.expected, v)
+		}
+	}
+}
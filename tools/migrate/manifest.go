@@ -0,0 +1,242 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+// List with the supported manifest operation types.
+const (
+	ManifestOpCreateTable = "createTable"
+	ManifestOpDropTable   = "dropTable"
+	ManifestOpAddColumn   = "addColumn"
+	ManifestOpDropColumn  = "dropColumn"
+	ManifestOpCreateIndex = "createIndex"
+	ManifestOpDropIndex   = "dropIndex"
+)
+
+// Manifest defines a JSON migrations manifest, allowing non-Go tooling
+// to generate migrations that the runner could apply, eg.:
+//
+//	{
+//	  "migrations": [
+//	    {
+//	      "file": "1656512345_posts.json",
+//	      "up": [
+//	        {"type": "createTable", "table": "posts", "columns": [
+//	          {"name": "id", "type": "TEXT PRIMARY KEY"},
+//	          {"name": "title", "type": "TEXT DEFAULT '' NOT NULL"}
+//	        ]},
+//	        {"type": "createIndex", "table": "posts", "index": "idx_title", "columns": [{"name": "title"}]}
+//	      ],
+//	      "down": [
+//	        {"type": "dropTable", "table": "posts"}
+//	      ]
+//	    }
+//	  ]
+//	}
+type Manifest struct {
+	Migrations []ManifestMigration `json:"migrations"`
+}
+
+// ManifestMigration defines a single manifest migration.
+type ManifestMigration struct {
+	File string              `json:"file"`
+	Up   []ManifestOperation `json:"up"`
+	Down []ManifestOperation `json:"down"`
+}
+
+// ManifestOperation defines a single manifest migration operation.
+type ManifestOperation struct {
+	// Type is one of the ManifestOp* constants.
+	Type string `json:"type"`
+
+	Table string `json:"table"`
+
+	// Columns lists the columns of the createTable, addColumn and
+	// dropColumn operations and the indexed columns of the createIndex
+	// operation (the column type is required only for createTable
+	// and addColumn).
+	Columns []ManifestColumn `json:"columns"`
+
+	// Index is the index name of the createIndex and dropIndex operations.
+	Index string `json:"index"`
+
+	// Unique specifies whether the createIndex index is unique.
+	Unique bool `json:"unique"`
+}
+
+// ManifestColumn defines a single manifest operation column.
+type ManifestColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// RegisterManifest parses and validates the provided JSON migrations
+// manifest and registers each of its migrations (see Manifest).
+//
+// The manifest migrations without "down" operations are registered
+// without a Down func.
+func (l *MigrationsList) RegisterManifest(content []byte) error {
+	manifest := Manifest{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("Failed to parse the migrations manifest: %w", err)
+	}
+
+	files := make(map[string]struct{}, len(manifest.Migrations))
+
+	for i, mm := range manifest.Migrations {
+		if mm.File == "" {
+			return fmt.Errorf("Manifest migration %d: missing file name", i)
+		}
+
+		if _, ok := files[mm.File]; ok {
+			return fmt.Errorf("Manifest migration %s: duplicated file name", mm.File)
+		}
+		files[mm.File] = struct{}{}
+
+		if len(mm.Up) == 0 {
+			return fmt.Errorf("Manifest migration %s: missing up operations", mm.File)
+		}
+
+		for _, ops := range [][]ManifestOperation{mm.Up, mm.Down} {
+			for j, op := range ops {
+				if err := op.validate(); err != nil {
+					return fmt.Errorf("Manifest migration %s operation %d: %w", mm.File, j, err)
+				}
+			}
+		}
+	}
+
+	for _, mm := range manifest.Migrations {
+		m := &Migration{
+			File: mm.File,
+			Up:   manifestOperationsFunc(mm.Up),
+		}
+		if len(mm.Down) > 0 {
+			m.Down = manifestOperationsFunc(mm.Down)
+		}
+
+		l.Add(m)
+	}
+
+	return nil
+}
+
+// validate checks whether the operation has all fields required by its type.
+func (op ManifestOperation) validate() error {
+	if op.Table == "" {
+		return fmt.Errorf("missing table name")
+	}
+
+	switch op.Type {
+	case ManifestOpDropTable:
+		return nil
+	case ManifestOpCreateTable, ManifestOpAddColumn:
+		return op.validateColumns(true)
+	case ManifestOpDropColumn:
+		return op.validateColumns(false)
+	case ManifestOpCreateIndex:
+		if op.Index == "" {
+			return fmt.Errorf("missing index name")
+		}
+		return op.validateColumns(false)
+	case ManifestOpDropIndex:
+		if op.Index == "" {
+			return fmt.Errorf("missing index name")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported operation type %q", op.Type)
+	}
+}
+
+func (op ManifestOperation) validateColumns(requireType bool) error {
+	if len(op.Columns) == 0 {
+		return fmt.Errorf("missing %s columns", op.Type)
+	}
+
+	for _, c := range op.Columns {
+		if c.Name == "" {
+			return fmt.Errorf("missing column name")
+		}
+
+		if requireType && c.Type == "" {
+			return fmt.Errorf("missing column %q type", c.Name)
+		}
+	}
+
+	return nil
+}
+
+// manifestOperationsFunc returns a migration func that executes
+// the provided manifest operations one by one.
+func manifestOperationsFunc(ops []ManifestOperation) func(db dbx.Builder) error {
+	return func(db dbx.Builder) error {
+		for _, op := range ops {
+			if err := op.execute(db); err != nil {
+				return fmt.Errorf("Failed to execute %s %s: %w", op.Type, op.Table, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// execute executes the operation with the dbx builder.
+func (op ManifestOperation) execute(db dbx.Builder) error {
+	names := make([]string, len(op.Columns))
+	for i, c := range op.Columns {
+		names[i] = c.Name
+	}
+
+	switch op.Type {
+	case ManifestOpCreateTable:
+		cols := make(map[string]string, len(op.Columns))
+		for _, c := range op.Columns {
+			cols[c.Name] = c.Type
+		}
+
+		_, err := db.CreateTable(op.Table, cols).Execute()
+
+		return err
+	case ManifestOpDropTable:
+		_, err := db.DropTable(op.Table).Execute()
+
+		return err
+	case ManifestOpAddColumn:
+		for _, c := range op.Columns {
+			if _, err := db.AddColumn(op.Table, c.Name, c.Type).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case ManifestOpDropColumn:
+		for _, name := range names {
+			if _, err := db.DropColumn(op.Table, name).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case ManifestOpCreateIndex:
+		if op.Unique {
+			_, err := db.CreateUniqueIndex(op.Table, op.Index, names...).Execute()
+
+			return err
+		}
+
+		_, err := db.CreateIndex(op.Table, op.Index, names...).Execute()
+
+		return err
+	case ManifestOpDropIndex:
+		_, err := db.DropIndex(op.Table, op.Index).Execute()
+
+		return err
+	default:
+		return fmt.Errorf("unsupported operation type %q", op.Type)
+	}
+}
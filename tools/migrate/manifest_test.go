@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestMigrationsListRegisterManifestValidation(t *testing.T) {
+	scenarios := []struct {
+		content     string
+		expectError bool
+	}{
+		{`invalid`, true},
+		{`{"migrations": []}`, false},
+		{`{"migrations": [{"up": [{"type": "dropTable", "table": "a"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json"}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "unknown", "table": "a"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "dropTable"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "createTable", "table": "a"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "createTable", "table": "a", "columns": [{"name": "id"}]}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "createIndex", "table": "a", "columns": [{"name": "id"}]}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "dropIndex", "table": "a"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "dropTable", "table": "a"}], "down": [{"type": "dropColumn", "table": "a"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "dropTable", "table": "a"}]}, {"file": "1_test.json", "up": [{"type": "dropTable", "table": "b"}]}]}`, true},
+		{`{"migrations": [{"file": "1_test.json", "up": [{"type": "createIndex", "table": "a", "index": "idx", "columns": [{"name": "id"}]}]}]}`, false},
+	}
+
+	for i, s := range scenarios {
+		l := MigrationsList{}
+
+		err := l.RegisterManifest([]byte(s.content))
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if hasErr && len(l.Items()) != 0 {
+			t.Errorf("(%d) Expected no registered migrations on error, got %d", i, len(l.Items()))
+		}
+	}
+}
+
+func TestMigrationsListRegisterManifest(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	content := `{
+		"migrations": [
+			{
+				"file": "2_title.json",
+				"up": [
+					{"type": "addColumn", "table": "posts", "columns": [{"name": "title", "type": "TEXT DEFAULT '' NOT NULL"}]},
+					{"type": "createIndex", "table": "posts", "index": "idx_title", "unique": true, "columns": [{"name": "title"}]}
+				],
+				"down": [
+					{"type": "dropIndex", "table": "posts", "index": "idx_title"},
+					{"type": "dropColumn", "table": "posts", "columns": [{"name": "title"}]}
+				]
+			},
+			{
+				"file": "1_posts.json",
+				"up": [
+					{"type": "createTable", "table": "posts", "columns": [{"name": "id", "type": "TEXT PRIMARY KEY"}]}
+				],
+				"down": [
+					{"type": "dropTable", "table": "posts"}
+				]
+			}
+		]
+	}`
+
+	l := MigrationsList{}
+	if err := l.RegisterManifest([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.Items()) != 2 || l.Item(0).File != "1_posts.json" || l.Item(1).File != "2_title.json" {
+		t.Fatalf("Expected 1_posts.json and 2_title.json migrations, got %v", l.Items())
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testDB.Insert("posts", dbx.Params{"id": "a", "title": "test"}).Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.Insert("posts", dbx.Params{"id": "b", "title": "test"}).Execute(); err == nil {
+		t.Fatal("Expected unique index error, got nil")
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testDB.Insert("posts", dbx.Params{"id": "c", "title": "test"}).Execute(); err == nil {
+		t.Fatal("Expected missing title column error, got nil")
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "table", "name": "posts"}).Row(&total)
+	if total != 0 {
+		t.Fatal("Expected the posts table to be dropped")
+	}
+}
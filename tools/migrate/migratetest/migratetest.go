@@ -0,0 +1,125 @@
+// Package migratetest provides common helpers for testing db migrations.
+package migratetest
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/migrate"
+)
+
+// AssertReversible checks that reverting the specified migration
+// returns the db schema to its state before the migration was applied.
+//
+// The migration is applied and reverted in isolation (aka. without
+// checking whether the previous migrations are applied) and the schema
+// is compared based on the normalized SQLite "sqlite_master" definitions.
+//
+// Note that only the schema reversibility is checked, meaning that it
+// can't detect data-only changes that are not reverted (eg. deleted rows).
+func AssertReversible(t testing.TB, runner *migrate.Runner, file string) {
+	t.Helper()
+
+	// the tracking tables (incl. the KeepRevertedHistory one) are created on demand
+	trackingTables := []string{runner.TableName, runner.TableName + "History"}
+
+	before, err := SchemaSnapshot(runner.DB(), trackingTables...)
+	if err != nil {
+		t.Fatalf("Failed to capture the schema before %s: %v", file, err)
+	}
+
+	if err := runner.RunOne(file, migrate.DirectionUp, true); err != nil {
+		t.Fatalf("Failed to apply %s: %v", file, err)
+	}
+
+	if err := runner.RunOne(file, migrate.DirectionDown, true); err != nil {
+		t.Fatalf("Failed to revert %s: %v", file, err)
+	}
+
+	after, err := SchemaSnapshot(runner.DB(), trackingTables...)
+	if err != nil {
+		t.Fatalf("Failed to capture the schema after %s: %v", file, err)
+	}
+
+	missing, extra := diff(before, after)
+	if len(missing) > 0 || len(extra) > 0 {
+		t.Errorf(
+			"Migration %s is not reversible.\nMissing after revert:\n%s\nLeftover after revert:\n%s",
+			file,
+			strings.Join(missing, "\n"),
+			strings.Join(extra, "\n"),
+		)
+	}
+}
+
+var whitespacesRegex = regexp.MustCompile(`\s+`)
+
+// SchemaSnapshot returns the sorted normalized SQLite schema definitions
+// (excluding the internal sqlite_* objects and the excluded tables).
+func SchemaSnapshot(db dbx.Builder, excludeTables ...string) ([]string, error) {
+	rows := []struct {
+		Type    string `db:"type"`
+		Name    string `db:"name"`
+		TblName string `db:"tbl_name"`
+		Sql     string `db:"sql"`
+	}{}
+
+	err := db.Select("type", "name", "tbl_name", "IFNULL(sql, '') as sql").
+		From("sqlite_master").
+		Where(dbx.NewExp("name NOT LIKE 'sqlite_%'")).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := map[string]bool{}
+	for _, table := range excludeTables {
+		// strip the schema prefix (if any)
+		if pos := strings.LastIndex(table, "."); pos != -1 {
+			table = table[pos+1:]
+		}
+		excluded[strings.ToLower(table)] = true
+	}
+
+	result := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if excluded[strings.ToLower(row.TblName)] {
+			continue
+		}
+
+		sql := strings.TrimSpace(whitespacesRegex.ReplaceAllString(row.Sql, " "))
+
+		result = append(result, row.Type+" "+row.Name+": "+sql)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// diff returns the items from a that are missing in b and vice versa.
+func diff(a []string, b []string) (missing []string, extra []string) {
+	bMap := make(map[string]bool, len(b))
+	for _, item := range b {
+		bMap[item] = true
+	}
+
+	aMap := make(map[string]bool, len(a))
+	for _, item := range a {
+		aMap[item] = true
+		if !bMap[item] {
+			missing = append(missing, item)
+		}
+	}
+
+	for _, item := range b {
+		if !aMap[item] {
+			extra = append(extra, item)
+		}
+	}
+
+	return missing, extra
+}
@@ -0,0 +1,153 @@
+package migratetest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/migrate"
+	_ "modernc.org/sqlite"
+)
+
+// recorderTB is a testing.TB that records the reported failures
+// instead of failing the current test.
+type recorderTB struct {
+	testing.TB
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recorderTB) Helper() {}
+
+func (r *recorderTB) Errorf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorderTB) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// failures runs fn with the recorder and returns the reported failures.
+func (r *recorderTB) failures(fn func(tb testing.TB)) []string {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fn(r)
+	}()
+	wg.Wait()
+
+	return r.errors
+}
+
+func TestAssertReversible(t *testing.T) {
+	db, err := dbx.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// each :memory: connection is a separate db
+	db.DB().SetMaxOpenConns(1)
+
+	l := migrate.MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE posts (id TEXT PRIMARY KEY)").Execute()
+		return err
+	}, func(db dbx.Builder) error {
+		_, err := db.DropTable("posts").Execute()
+		return err
+	}, "1_reversible.go")
+	l.Register(func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE users (id TEXT PRIMARY KEY)").Execute()
+		return err
+	}, func(db dbx.Builder) error {
+		return nil // leaves the users table behind
+	}, "2_leftover.go")
+
+	runner, err := migrate.NewRunner(db, l, func(r *migrate.Runner) {
+		r.KeepRevertedHistory = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		file     string
+		expected []string // the expected parts of the failure message
+	}{
+		{"1_reversible.go", nil},
+		{"2_leftover.go", []string{"Migration 2_leftover.go is not reversible", "table users: CREATE TABLE users"}},
+		{"missing.go", []string{"Failed to apply missing.go"}},
+	}
+
+	for i, s := range scenarios {
+		recorder := &recorderTB{TB: t}
+
+		failures := recorder.failures(func(tb testing.TB) {
+			AssertReversible(tb, runner, s.file)
+		})
+
+		if len(s.expected) == 0 {
+			if len(failures) != 0 {
+				t.Errorf("(%d) Expected no failures, got %v", i, failures)
+			}
+			continue
+		}
+
+		if len(failures) != 1 {
+			t.Errorf("(%d) Expected 1 failure, got %v", i, failures)
+			continue
+		}
+
+		for _, part := range s.expected {
+			if !strings.Contains(failures[0], part) {
+				t.Errorf("(%d) Expected %q in the failure, got %q", i, part, failures[0])
+			}
+		}
+	}
+}
+
+func TestSchemaSnapshot(t *testing.T) {
+	db, err := dbx.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.DB().SetMaxOpenConns(1)
+
+	queries := []string{
+		"CREATE TABLE posts (id TEXT PRIMARY KEY,\n\ttitle TEXT)",
+		"CREATE INDEX idx_title ON posts (title)",
+		"CREATE TABLE _migrations (file TEXT PRIMARY KEY)",
+	}
+	for _, q := range queries {
+		if _, err := db.NewQuery(q).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := SchemaSnapshot(db, "main._migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"index idx_title: CREATE INDEX idx_title ON posts (title)",
+		"table posts: CREATE TABLE posts (id TEXT PRIMARY KEY, title TEXT)",
+	}
+
+	if strings.Join(snapshot, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected snapshot \n%v, got \n%v", expected, snapshot)
+	}
+}
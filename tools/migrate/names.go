@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// namePattern returns the migration file name pattern used to validate
+// the new migration files (see Runner.NamePattern).
+//
+// If not set, fallbacks to the default "1656512345_migration_name.go"
+// convention (with the runner FileExtension).
+func (r *Runner) namePattern() *regexp.Regexp {
+	if r.NamePattern != nil {
+		return r.NamePattern
+	}
+
+	if ext := r.fileExtension(); ext != DefaultFileExtension {
+		return regexp.MustCompile(`^\d+_\w+` + regexp.QuoteMeta(ext) + `$`)
+	}
+
+	return migrationFileRegex
+}
+
+// checkFileName returns an error if the migration file
+// name doesn't match the runner name pattern.
+func (r *Runner) checkFileName(file string) error {
+	_, base := splitNamespace(file)
+
+	if pattern := r.namePattern(); !pattern.MatchString(base) {
+		return fmt.Errorf("Invalid migration file name %q (expected to match %s)", base, pattern)
+	}
+
+	return nil
+}
+
+// checkRegisteredNames validates the file names of the provided
+// registered migrations against the explicitly set Runner.NamePattern.
+//
+// The registered migrations are not validated with the default
+// pattern to preserve the compatibility with the existing lists.
+func (r *Runner) checkRegisteredNames(migrations Migrations) error {
+	if r.NamePattern == nil {
+		return nil
+	}
+
+	for _, m := range migrations.Items() {
+		if err := r.checkFileName(m.File); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestNewRunnerNamePattern(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	pattern := regexp.MustCompile(`^\d{14}_[a-z0-9_]+\.go$`)
+
+	scenarios := []struct {
+		pattern     *regexp.Regexp
+		files       []string
+		expectError bool
+	}{
+		{nil, []string{"1_test", "Invalid Name"}, false},
+		{pattern, []string{}, false},
+		{pattern, []string{"20220629120000_init.go", "plugin:20220629120001_seed.go"}, false},
+		{pattern, []string{"20220629120000_init.go", "1656512345_init.go"}, true},
+		{pattern, []string{"20220629120000_Init.go"}, true},
+	}
+
+	for i, s := range scenarios {
+		l := MigrationsList{}
+		for _, file := range s.files {
+			l.Register(nil, nil, file)
+		}
+
+		_, err := NewRunner(testDB.DB, l, func(r *Runner) {
+			r.NamePattern = s.pattern
+		})
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+}
+
+func TestRunnerRegisterSetNamePattern(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+		r.NamePattern = regexp.MustCompile(`^\d+_seed_\w+\.go$`)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := MigrationsList{}
+	invalid.Register(nil, nil, "1_test.go")
+	if err := r.RegisterSet("invalid", invalid); err == nil {
+		t.Fatal("Expected name pattern error, got nil")
+	}
+
+	valid := MigrationsList{}
+	valid.Register(nil, nil, "1_seed_users.go")
+	if err := r.RegisterSet("valid", valid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunnerRunCreateNamePattern(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+
+	scenarios := []struct {
+		pattern     *regexp.Regexp
+		extension   string
+		name        string
+		expectError bool
+	}{
+		{nil, "", "add_posts", false},
+		{nil, ".sql", "add_posts", false},
+		{regexp.MustCompile(`^\d+_add_\w+\.go$`), "", "add_posts", false},
+		{regexp.MustCompile(`^\d+_add_\w+\.go$`), "", "drop_posts", true},
+		{regexp.MustCompile(`^\d{14}_\w+\.go$`), "", "add_posts", true},
+	}
+
+	for i, s := range scenarios {
+		r.Dir = t.TempDir()
+		r.NamePattern = s.pattern
+		r.FileExtension = s.extension
+
+		err := r.Run("create", s.name)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(r.Dir, "*"))
+		if s.expectError && len(files) > 0 {
+			t.Errorf("(%d) Expected no files to be created, got %v", i, files)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namespaceSeparator separates the namespace and the file name
+// of a namespaced migration (eg. "plugin-a:001_init.go").
+const namespaceSeparator = ":"
+
+// namespaceRegex defines the allowed migrations namespace format.
+var namespaceRegex = regexp.MustCompile(`^[\w-]+$`)
+
+// RegisterNamespace adds all migrations of the provided list to l,
+// prefixing their file names with the namespace (eg. "plugin-a:001_init.go"),
+// so that the migrations of independent modules with the same file
+// names are tracked separately in the same migrations table.
+//
+// The namespaced migrations are ordered by their file name (aka. timestamp)
+// and then by their namespace.
+//
+// Note that the list migrations are renamed in place and
+// the same list shouldn't be registered more than once.
+func (l *MigrationsList) RegisterNamespace(namespace string, migrationsList MigrationsList) error {
+	if !namespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("Invalid migrations namespace %q", namespace)
+	}
+
+	for _, m := range migrationsList.Items() {
+		if ns, _ := splitNamespace(m.File); ns != "" {
+			return fmt.Errorf("Migration %s is already namespaced", m.File)
+		}
+	}
+
+	for _, m := range migrationsList.Items() {
+		m.File = namespace + namespaceSeparator + m.File
+		l.Add(m)
+	}
+
+	return nil
+}
+
+// splitNamespace splits the migration file into its namespace
+// (empty for the not namespaced migrations) and its base file name.
+func splitNamespace(file string) (string, string) {
+	if ns, base, ok := strings.Cut(file, namespaceSeparator); ok {
+		return ns, base
+	}
+
+	return "", file
+}
+
+// migrationLess reports whether the migration a should be applied
+// before b, comparing their numeric timestamp prefixes, base file
+// names, namespaces and sets.
+//
+// The migrations with the same timestamp prefix (eg. after a merge of
+// two branches) are ordered by their full base file name, so that
+// every environment applies them in the same order (see checkTimestampCollisions).
+func migrationLess(a *Migration, b *Migration) bool {
+	aNamespace, aBase := splitNamespace(a.File)
+	bNamespace, bBase := splitNamespace(b.File)
+
+	aTs, aOk := migrationTimestamp(aBase)
+	bTs, bOk := migrationTimestamp(bBase)
+	if aOk && bOk && aTs != bTs {
+		return aTs < bTs
+	}
+
+	if aBase != bBase {
+		return aBase < bBase
+	}
+
+	if aNamespace != bNamespace {
+		return aNamespace < bNamespace
+	}
+
+	return a.set < b.set
+}
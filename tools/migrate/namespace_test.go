@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestMigrationsListRegisterNamespace(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := []string{}
+	callsFunc := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	pluginA := MigrationsList{}
+	pluginA.Register(callsFunc("a_init"), callsFunc("a_init_down"), "001_init.go")
+	pluginA.Register(callsFunc("a_3"), callsFunc("a_3_down"), "003_a.go")
+
+	pluginB := MigrationsList{}
+	pluginB.Register(callsFunc("b_init"), callsFunc("b_init_down"), "001_init.go")
+
+	pluginC := MigrationsList{}
+	pluginC.RegisterFS(fstest.MapFS{"004_c.sql": {Data: []byte("-- +up\nCREATE TABLE c (id TEXT);\n-- +down\nDROP TABLE c;")}})
+
+	l := MigrationsList{}
+	l.Register(callsFunc("core_2"), callsFunc("core_2_down"), "002_core.go")
+
+	if err := l.RegisterNamespace("invalid namespace", MigrationsList{}); err == nil {
+		t.Fatal("Expected invalid namespace error, got nil")
+	}
+
+	for ns, list := range map[string]MigrationsList{"plugin-a": pluginA, "plugin-b": pluginB, "plugin-c": pluginC} {
+		if err := l.RegisterNamespace(ns, list); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := l.RegisterNamespace("plugin-d", pluginA); err == nil {
+		t.Fatal("Expected already namespaced error, got nil")
+	}
+
+	expectedFiles := []string{"plugin-a:001_init.go", "plugin-b:001_init.go", "002_core.go", "plugin-a:003_a.go", "plugin-c:004_c.sql"}
+	files := []string{}
+	for _, m := range l.Items() {
+		files = append(files, m.File)
+	}
+	if strings.Join(files, ",") != strings.Join(expectedFiles, ",") {
+		t.Fatalf("Expected files %v, got %v", expectedFiles, files)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "a_init,b_init,core_2,a_3"; strings.Join(calls, ",") != expected {
+		t.Fatalf("Expected calls %s, got %v", expected, calls)
+	}
+
+	for _, file := range expectedFiles {
+		if !r.isMigrationApplied(testDB, "", file) {
+			t.Fatalf("Expected %s to be applied", file)
+		}
+	}
+
+	var total int
+	testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "table", "name": "c"}).Row(&total)
+	if total != 1 {
+		t.Fatal("Expected the namespaced fs migration to be applied")
+	}
+
+	// revert plugin-c:004_c.sql, plugin-a:003_a.go and 002_core.go
+	calls = []string{}
+	if _, err := r.Down(3); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "a_3_down,core_2_down"; strings.Join(calls, ",") != expected {
+		t.Fatalf("Expected calls %s, got %v", expected, calls)
+	}
+
+	if ts, ok := migrationTimestamp("plugin-a:1656512345_init.go"); !ok || ts != 1656512345 {
+		t.Fatalf("Expected the namespaced migration timestamp, got %d (%v)", ts, ok)
+	}
+}
@@ -0,0 +1,280 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+const objectsTable = "_migrationsObjects"
+
+const objectIdentifier = "([\\w$.`\"\\[\\]]+)"
+
+var (
+	createObjectRegex = regexp.MustCompile(
+		`(?is)^\s*CREATE\s+(?:TEMP(?:ORARY)?\s+)?(?:UNIQUE\s+)?(?:VIRTUAL\s+)?(TABLE|INDEX|VIEW|TRIGGER)\s+(?:IF\s+NOT\s+EXISTS\s+)?` + objectIdentifier,
+	)
+	addColumnRegex = regexp.MustCompile(
+		`(?is)^\s*ALTER\s+TABLE\s+` + objectIdentifier + `\s+ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + objectIdentifier,
+	)
+)
+
+// tableConstraintKeywords lists the keywords of the CREATE TABLE
+// definitions that are table constraints and not columns.
+var tableConstraintKeywords = []string{"CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN"}
+
+// WhoCreated returns the migration that created the specified db
+// object - a table, index, view, trigger (eg. "posts") or a column
+// in the format "table.column" (eg. "posts.author_id").
+//
+// The returned migration name is prefixed with its set name for the
+// named sets migrations (eg. "staging/1656512345_seed.go").
+//
+// The objects are recorded only while Runner.TrackObjects is enabled.
+// Returns false if there is no recorded migration for the object.
+func (r *Runner) WhoCreated(object string) (string, bool, error) {
+	if !r.ReadOnly {
+		if err := r.createObjectsTable(); err != nil {
+			return "", false, err
+		}
+	}
+
+	row := struct {
+		Set  string `db:"set"`
+		File string `db:"file"`
+	}{}
+
+	err := r.builder().Select("set", "file").
+		From(objectsTable).
+		Where(dbx.HashExp{"object": normalizeObjectName(object)}).
+		Limit(1).
+		One(&row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if row.Set != "" {
+		return row.Set + "/" + row.File, true, nil
+	}
+
+	return row.File, true, nil
+}
+
+// objectsRecorder collects the statements executed
+// by the current Up run migration.
+type objectsRecorder struct {
+	statements []string
+	capturing  bool
+}
+
+// startObjectsTracking starts capturing the statements executed with
+// the runner db (see Runner.TrackObjects).
+//
+// The returned func restores the runner db.
+func (r *Runner) startObjectsTracking() (func(), error) {
+	if err := r.createObjectsTable(); err != nil {
+		return nil, err
+	}
+
+	recorder := &objectsRecorder{}
+
+	original := r.db
+
+	db := original.Clone()
+	db.QueryLogFunc = func(ctx context.Context, t time.Duration, sql string, rows *sql.Rows, err error) {
+		recorder.recordStatement(sql, err)
+		if original.QueryLogFunc != nil {
+			original.QueryLogFunc(ctx, t, sql, rows, err)
+		}
+	}
+	db.ExecLogFunc = func(ctx context.Context, t time.Duration, sql string, result sql.Result, err error) {
+		recorder.recordStatement(sql, err)
+		if original.ExecLogFunc != nil {
+			original.ExecLogFunc(ctx, t, sql, result, err)
+		}
+	}
+
+	r.db = db
+	r.objects = recorder
+
+	return func() {
+		r.db = original
+		r.objects = nil
+	}, nil
+}
+
+// capture executes fn as a migration, collecting its executed statements.
+func (o *objectsRecorder) capture(fn func() error) error {
+	if o == nil {
+		return fn()
+	}
+
+	o.statements = o.statements[:0]
+	o.capturing = true
+	defer func() { o.capturing = false }()
+
+	return fn()
+}
+
+// recordStatement records the statement if it is successfully
+// executed as part of a migration (eg. not a tracking table query).
+func (o *objectsRecorder) recordStatement(statement string, err error) {
+	if !o.capturing || err != nil {
+		return
+	}
+
+	o.statements = append(o.statements, statement)
+}
+
+// saveCreatedObjects records the objects created by the last
+// captured migration statements as created by m.
+//
+// An object that was already recorded (eg. dropped and recreated
+// by a later migration) is reassigned to m.
+func (r *Runner) saveCreatedObjects(db dbx.Builder, m *Migration) error {
+	if r.objects == nil {
+		return nil
+	}
+
+	for _, object := range createdObjects(r.objects.statements) {
+		if _, err := db.Delete(objectsTable, dbx.HashExp{"object": object}).Execute(); err != nil {
+			return err
+		}
+
+		_, err := db.Insert(objectsTable, dbx.Params{
+			"object":  object,
+			"set":     m.set,
+			"file":    m.File,
+			"created": r.nextTimestamp(),
+		}).Execute()
+		if err != nil {
+			return fmt.Errorf("Failed to save the created object %s: %w", object, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteCreatedObjects removes the recorded objects of the reverted m migration.
+func (r *Runner) deleteCreatedObjects(db dbx.Builder, m *Migration) error {
+	_, err := db.Delete(objectsTable, dbx.HashExp{"set": m.set, "file": m.File}).Execute()
+
+	return err
+}
+
+// createdObjects returns the normalized names of the objects created
+// by the provided statements (the created tables, their columns,
+// indexes, views, triggers and the added columns).
+func createdObjects(statements []string) []string {
+	result := []string{}
+
+	for _, statement := range statements {
+		if match := addColumnRegex.FindStringSubmatch(statement); match != nil {
+			result = append(result, normalizeObjectName(match[1])+"."+normalizeObjectName(match[2]))
+			continue
+		}
+
+		match := createObjectRegex.FindStringSubmatchIndex(statement)
+		if match == nil {
+			continue
+		}
+
+		kind := strings.ToUpper(statement[match[2]:match[3]])
+		name := normalizeObjectName(statement[match[4]:match[5]])
+
+		result = append(result, name)
+
+		if kind == "TABLE" {
+			for _, column := range tableColumns(statement[match[5]:]) {
+				result = append(result, name+"."+column)
+			}
+		}
+	}
+
+	return result
+}
+
+// tableColumns returns the normalized column names of the
+// provided CREATE TABLE statement definitions part (eg. "(id INTEGER, title TEXT)").
+func tableColumns(definitions string) []string {
+	definitions = strings.TrimSpace(definitions)
+	if !strings.HasPrefix(definitions, "(") {
+		return nil // eg. CREATE TABLE ... AS SELECT
+	}
+
+	// split the top level definitions
+	parts := []string{}
+	depth := 0
+	start := 1
+	for i := 1; i < len(definitions) && depth >= 0; i++ {
+		switch definitions[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				parts = append(parts, definitions[start:i])
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, definitions[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	columns := []string{}
+	for _, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		isConstraint := false
+		for _, keyword := range tableConstraintKeywords {
+			if strings.EqualFold(fields[0], keyword) {
+				isConstraint = true
+				break
+			}
+		}
+
+		if !isConstraint {
+			columns = append(columns, normalizeObjectName(fields[0]))
+		}
+	}
+
+	return columns
+}
+
+// normalizeObjectName returns the lowercased object name without its
+// identifier quotes (eg. `"Posts".[Author_Id]` -> "posts.author_id").
+func normalizeObjectName(name string) string {
+	return strings.ToLower(strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(strings.TrimSpace(name)))
+}
+
+func (r *Runner) createObjectsTable() error {
+	rawQuery := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (object %s PRIMARY KEY NOT NULL, %s %s NOT NULL, file %s NOT NULL, created %s NOT NULL)",
+		r.db.QuoteTableName(objectsTable),
+		r.Dialect.KeyType(),
+		r.db.QuoteColumnName("set"),
+		r.Dialect.KeyType(),
+		r.Dialect.KeyType(),
+		r.Dialect.IntegerType(),
+	)
+
+	_, err := r.builder().NewQuery(rawQuery).Execute()
+
+	return err
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestCreatedObjects(t *testing.T) {
+	statements := []string{
+		"CREATE TABLE `posts` (`id` TEXT PRIMARY KEY NOT NULL, title TEXT DEFAULT '', created NUMERIC(10, 2), CONSTRAINT fk FOREIGN KEY (id) REFERENCES users(id))",
+		"create table if not exists \"Tags\" (id TEXT, UNIQUE (id))",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_title ON posts (title)",
+		"CREATE VIEW posts_view AS SELECT * FROM posts",
+		"CREATE TABLE posts_copy AS SELECT * FROM posts",
+		"ALTER TABLE [posts] ADD COLUMN [Author_Id] TEXT",
+		"ALTER TABLE posts RENAME TO articles",
+		"INSERT INTO posts (id) VALUES ('a')",
+	}
+
+	expected := []string{
+		"posts",
+		"posts.id",
+		"posts.title",
+		"posts.created",
+		"tags",
+		"tags.id",
+		"idx_posts_title",
+		"posts_view",
+		"posts_copy",
+		"posts.author_id",
+	}
+
+	objects := createdObjects(statements)
+
+	if strings.Join(objects, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected objects \n%v, \ngot \n%v", expected, objects)
+	}
+}
+
+func TestRunnerWhoCreated(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	exec := func(queries ...string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			for _, q := range queries {
+				if _, err := db.NewQuery(q).Execute(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	l := MigrationsList{}
+	l.Register(exec("CREATE TABLE posts (id TEXT PRIMARY KEY, title TEXT)"), exec("DROP TABLE posts"), "1_posts.go")
+	l.Register(exec("ALTER TABLE posts ADD COLUMN author_id TEXT", "CREATE INDEX idx_author ON posts (author_id)"), exec("DROP INDEX idx_author", "ALTER TABLE posts DROP COLUMN author_id"), "2_author.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.TrackObjects = true
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		object       string
+		expectedFile string
+		expectedOk   bool
+	}{
+		{"posts", "1_posts.go", true},
+		{"POSTS.Title", "1_posts.go", true},
+		{"posts.author_id", "2_author.go", true},
+		{"`posts`.`author_id`", "2_author.go", true},
+		{"idx_author", "2_author.go", true},
+		{"_migrations", "", false},
+		{"missing", "", false},
+	}
+
+	for i, s := range scenarios {
+		file, ok, err := r.WhoCreated(s.object)
+		if err != nil {
+			t.Errorf("(%d) Expected nil error, got %v", i, err)
+			continue
+		}
+
+		if ok != s.expectedOk || file != s.expectedFile {
+			t.Errorf("(%d) Expected %q (%v), got %q (%v)", i, s.expectedFile, s.expectedOk, file, ok)
+		}
+	}
+
+	// the reverted migration objects are removed
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := r.WhoCreated("posts.author_id"); ok {
+		t.Fatal("Expected the reverted migration objects to be removed")
+	}
+
+	if file, ok, _ := r.WhoCreated("posts"); !ok || file != "1_posts.go" {
+		t.Fatalf("Expected posts to be still created by 1_posts.go, got %q (%v)", file, ok)
+	}
+
+	// without TrackObjects nothing is recorded
+	r.TrackObjects = false
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := r.WhoCreated("posts.author_id"); ok {
+		t.Fatal("Expected the untracked run objects to not be recorded")
+	}
+}
@@ -0,0 +1,236 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+// List with the supported Runner.GapsCheck modes.
+const (
+	GapsCheckOff   = ""
+	GapsCheckWarn  = "warn"
+	GapsCheckError = "error"
+)
+
+// migrationTimestamp extracts the numeric timestamp prefix
+// of a migration file name (eg. "1656512345_init.go" -> 1656512345).
+//
+// Returns false if the file name doesn't have a numeric prefix.
+func migrationTimestamp(file string) (int64, bool) {
+	_, file = splitNamespace(file)
+
+	prefix, _, _ := strings.Cut(file, "_")
+
+	ts, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return ts, true
+}
+
+// checkOutOfOrder returns an error if any of the pending migrations
+// has an older timestamp prefix than the latest applied migration.
+func (r *Runner) checkOutOfOrder() error {
+	var maxApplied int64
+	var maxAppliedFile string
+	pending := []*Migration{}
+
+	items, err := r.checkpointItems(r.items())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range items {
+		applied, err := r.checkMigrationApplied(r.builder(), m.set, m.File)
+		if err != nil {
+			return err
+		}
+
+		if !applied {
+			pending = append(pending, m)
+			continue
+		}
+
+		if ts, ok := migrationTimestamp(m.File); ok && ts > maxApplied {
+			maxApplied = ts
+			maxAppliedFile = m.File
+		}
+	}
+
+	for _, m := range pending {
+		if ts, ok := migrationTimestamp(m.File); ok && ts < maxApplied {
+			return fmt.Errorf(
+				"Pending migration %s is older than the already applied %s. "+
+					"Applying migrations out of order could break the schema assumptions of the newer migrations "+
+					"(rename the migration with a newer timestamp or explicitly allow out of order migrations).",
+				m.File,
+				maxAppliedFile,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkGaps returns an error listing the unapplied migrations
+// that are followed by already applied ones in the ordered list
+// (aka. when the applied migrations are not a gap-free prefix of the list).
+func (r *Runner) checkGaps() error {
+	gaps := []string{}
+	unapplied := []string{}
+
+	items, err := r.checkpointItems(r.items())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range items {
+		applied, err := r.checkMigrationApplied(r.builder(), m.set, m.File)
+		if err != nil {
+			return err
+		}
+
+		if !applied {
+			unapplied = append(unapplied, m.File)
+			continue
+		}
+
+		if len(unapplied) > 0 {
+			gaps = append(gaps, fmt.Sprintf("%s (before the applied %s)", strings.Join(unapplied, ", "), m.File))
+			unapplied = unapplied[:0]
+		}
+	}
+
+	if len(gaps) > 0 {
+		return fmt.Errorf("Detected unapplied migrations gaps: %s", strings.Join(gaps, "; "))
+	}
+
+	return nil
+}
+
+// createTimestamp returns the timestamp prefix for a new migration file.
+//
+// If the current time is not after the newest registered or existing
+// migration in dir (eg. because the system clock went backwards),
+// a warning is printed and the newest timestamp + 1 is returned
+// to preserve the migrations order.
+func (r *Runner) createTimestamp(dir string) int64 {
+	var maxTs int64
+	var maxFile string
+
+	files := []string{}
+	for _, m := range r.items() {
+		files = append(files, m.File)
+	}
+	existing, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, f := range existing {
+		files = append(files, filepath.Base(f))
+	}
+
+	for _, file := range files {
+		if ts, ok := migrationTimestamp(file); ok && ts > maxTs {
+			maxTs = ts
+			maxFile = file
+		}
+	}
+
+	now := r.now().Unix()
+	if now > maxTs {
+		return now
+	}
+
+	color.Yellow(
+		"The current time is not after the newest migration %s (the system clock may have gone backwards). "+
+			"Using timestamp %d to preserve the migrations order.",
+		maxFile,
+		maxTs+1,
+	)
+
+	return maxTs + 1
+}
+
+// checkTimestampCollisions prints a warning for each group of
+// registered migrations that share the same timestamp prefix.
+//
+// The colliding migrations are applied in their file name order
+// (see migrationLess), but they are usually an unintended result of
+// merging two branches and one of them should be renamed.
+func (r *Runner) checkTimestampCollisions() {
+	for _, collision := range timestampCollisions(r.items()) {
+		color.Yellow(
+			"The migrations %s share the same timestamp prefix and are applied in their file name order "+
+				"(consider renaming one of them).",
+			strings.Join(collision, ", "),
+		)
+	}
+}
+
+// timestampCollisions returns the groups of the provided migrations
+// base file names that share the same timestamp prefix.
+//
+// The same file name registered in multiple sets or namespaces
+// is not considered a collision.
+func timestampCollisions(migrations []*Migration) [][]string {
+	groups := map[int64][]string{}
+	order := []int64{}
+
+	for _, m := range migrations {
+		_, base := splitNamespace(m.File)
+
+		ts, ok := migrationTimestamp(base)
+		if !ok {
+			continue
+		}
+
+		group, exists := groups[ts]
+		if !exists {
+			order = append(order, ts)
+		}
+		if !list.ExistInSlice(base, group) {
+			groups[ts] = append(group, base)
+		}
+	}
+
+	result := [][]string{}
+	for _, ts := range order {
+		if len(groups[ts]) > 1 {
+			result = append(result, groups[ts])
+		}
+	}
+
+	return result
+}
+
+// checkClock prints a warning if the last applied migration has an
+// apply time in the future (eg. due to clock skew) and ensures that
+// the new records are still stamped after it.
+func (r *Runner) checkClock() {
+	var last int64
+
+	err := r.builder().Select("max([[applied]])").
+		From(r.TableName).
+		Row(&last)
+	if err != nil {
+		return // no records
+	}
+
+	if now := r.now().UnixNano(); last > now {
+		color.Yellow(
+			"The last migration was applied at %s, which is after the current time %s "+
+				"(the system clock may have gone backwards).",
+			r.formatTime(time.Unix(0, last)),
+			r.formatTime(time.Unix(0, now)),
+		)
+
+		if last > r.lastTimestamp {
+			r.lastTimestamp = last
+		}
+	}
+}
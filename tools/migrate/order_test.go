@@ -0,0 +1,288 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestMigrationTimestamp(t *testing.T) {
+	scenarios := []struct {
+		file       string
+		expectedTs int64
+		expectedOk bool
+	}{
+		{"", 0, false},
+		{"init.go", 0, false},
+		{"abc_init.go", 0, false},
+		{"1656512345_init.go", 1656512345, true},
+		{"1656512345.go", 0, false},
+		{"1_test", 1, true},
+	}
+
+	for i, s := range scenarios {
+		ts, ok := migrationTimestamp(s.file)
+		if ts != s.expectedTs || ok != s.expectedOk {
+			t.Errorf("(%d) Expected %d, %v, got %d, %v", i, s.expectedTs, s.expectedOk, ts, ok)
+		}
+	}
+}
+
+func TestRunnerStrictOrder(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate merged older migration
+	r.saveAppliedMigration(testDB, "", "1_test")
+	r.saveAppliedMigration(testDB, "", "3_test")
+
+	r.StrictOrder = true
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected out of order error")
+	}
+
+	r.AllowOutOfOrder = true
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 1 || applied[0] != "2_test" {
+		t.Fatalf("Expected 2_test to be applied, got %v", applied)
+	}
+}
+
+func TestRunnerGapsCheck(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+	l.Register(noop, noop, "4_test")
+	l.Register(noop, noop, "5_test")
+
+	scenarios := []struct {
+		mode            string
+		allowOutOfOrder bool
+		expectError     bool
+	}{
+		{GapsCheckOff, false, false},
+		{GapsCheckWarn, false, false},
+		{GapsCheckError, false, true},
+		{GapsCheckError, true, false},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.saveAppliedMigration(testDB, "", "1_test")
+		r.saveAppliedMigration(testDB, "", "4_test")
+
+		r.GapsCheck = s.mode
+		r.AllowOutOfOrder = s.allowOutOfOrder
+
+		applied, err := r.Up()
+
+		testDB.Close()
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if hasErr {
+			if !strings.Contains(err.Error(), "2_test, 3_test (before the applied 4_test)") {
+				t.Errorf("(%d) Expected the gap files in the error, got %v", i, err)
+			}
+		} else if len(applied) != 3 {
+			t.Errorf("(%d) Expected 3 applied migrations, got %v", i, applied)
+		}
+	}
+}
+
+func TestRunnerCreateTimestamp(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1656512345_init.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	if ts := r.createTimestamp(dir); ts < time.Now().Unix()-1 {
+		t.Fatalf("Expected the current timestamp, got %d", ts)
+	}
+
+	// existing migration file with a future timestamp
+	future := time.Now().Add(24 * time.Hour).Unix()
+	os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d_future.go", future)), nil, 0644)
+
+	if ts := r.createTimestamp(dir); ts != future+1 {
+		t.Fatalf("Expected %d, got %d", future+1, ts)
+	}
+
+	// registered migration with a future timestamp
+	r.migrationsList.(*MigrationsList).Register(nil, nil, fmt.Sprintf("%d_registered.go", future+10))
+
+	if ts := r.createTimestamp(dir); ts != future+11 {
+		t.Fatalf("Expected %d, got %d", future+11, ts)
+	}
+}
+
+func TestRunnerCheckClock(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	r.MarkApplied("1_test", future)
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 2 || applied[1].File != "2_test" || applied[1].Applied <= future.UnixNano() {
+		t.Fatalf("Expected 2_test to be stamped after the future 1_test, got %v", applied)
+	}
+}
+
+func TestRunnerRunCreateFixedClock(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+	r.Dir = t.TempDir()
+	r.Now = func() time.Time {
+		return time.Date(2022, 6, 29, 12, 0, 0, 0, time.UTC)
+	}
+
+	if err := r.Run("create", "add posts"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPath := filepath.Join(r.Dir, "1656504000_add_posts.go")
+	if _, err := os.Stat(expectedPath); err != nil {
+		t.Fatalf("Expected migration file %q to be created, got %v", expectedPath, err)
+	}
+
+	// a second file at the same fixed time still follows the first one
+	if err := r.Run("create", "add tags"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPath = filepath.Join(r.Dir, "1656504001_add_tags.go")
+	if _, err := os.Stat(expectedPath); err != nil {
+		t.Fatalf("Expected migration file %q to be created, got %v", expectedPath, err)
+	}
+}
+
+func TestMigrationsListTimestampTieBreak(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	// registered in different orders (eg. by different branches)
+	orders := [][]string{
+		{"1656512345_b.go", "9_old.go", "1656512345_a.go", "1656512346_c.go"},
+		{"1656512346_c.go", "1656512345_a.go", "1656512345_b.go", "9_old.go"},
+	}
+
+	for i, files := range orders {
+		l := MigrationsList{}
+		for _, file := range files {
+			l.Register(noop, noop, file)
+		}
+
+		names := []string{}
+		for _, m := range l.Items() {
+			names = append(names, m.File)
+		}
+
+		expected := "9_old.go,1656512345_a.go,1656512345_b.go,1656512346_c.go"
+		if v := strings.Join(names, ","); v != expected {
+			t.Errorf("(%d) Expected order %s, got %s", i, expected, v)
+		}
+	}
+}
+
+func TestTimestampCollisions(t *testing.T) {
+	migrations := []*Migration{
+		{File: "1_init.go"},
+		{File: "2_a.go"},
+		{File: "2_b.go"},
+		{File: "2_a.go", set: "staging"},
+		{File: "plugin" + namespaceSeparator + "2_a.go"},
+		{File: "3_c.go"},
+		{File: "no_timestamp.go"},
+		{File: "4_d.go"},
+		{File: "4_e.sql"},
+		{File: "4_f.go"},
+	}
+
+	result := timestampCollisions(migrations)
+
+	expected := "[[2_a.go 2_b.go] [4_d.go 4_e.sql 4_f.go]]"
+	if v := fmt.Sprint(result); v != expected {
+		t.Fatalf("Expected collisions %s, got %s", expected, v)
+	}
+}
@@ -0,0 +1,29 @@
+package migrate
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrPaused is returned by Up when the run was paused (see Runner.PauseFile).
+var ErrPaused = errors.New("The migrations run was paused")
+
+// pauseRequested reports whether the current Up run should be paused
+// before starting the next transaction.
+func (r *Runner) pauseRequested() bool {
+	if r.PauseFile != "" {
+		if _, err := os.Stat(r.PauseFile); err == nil {
+			return true
+		}
+	}
+
+	if r.Pause != nil {
+		select {
+		case <-r.Pause:
+			return true
+		default:
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerPauseFile(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+
+	l := MigrationsList{}
+	for i := 1; i <= 5; i++ {
+		i := i
+		l.Register(func(db dbx.Builder) error {
+			// request a pause while the 3rd migration is running
+			if i == 3 {
+				return os.WriteFile(pauseFile, nil, 0644)
+			}
+			return nil
+		}, nil, fmt.Sprintf("%d_test", i))
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CommitEvery = 2
+	r.PauseFile = pauseFile
+
+	events := []string{}
+
+	// the batch with the 3rd migration is committed before pausing
+	applied, err := r.up(nil, "", func(e MigrationEvent) {
+		if e.Type == MigrationEventPaused {
+			events = append(events, e.Type)
+		}
+	})
+	if !errors.Is(err, ErrPaused) {
+		t.Fatalf("Expected ErrPaused, got %v", err)
+	}
+
+	if expected := "1_test,2_test,3_test,4_test"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected a single paused event, got %v", events)
+	}
+
+	if total := r.appliedCount(); total != 4 {
+		t.Fatalf("Expected 4 applied migrations, got %d", total)
+	}
+
+	// nothing is executed while the pause file exists
+	applied, err = r.Up()
+	if !errors.Is(err, ErrPaused) || len(applied) != 0 {
+		t.Fatalf("Expected no applied migrations and ErrPaused, got %v (%v)", applied, err)
+	}
+
+	if err := os.Remove(pauseFile); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err = r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "5_test"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+}
+
+func TestRunnerPauseChannel(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	pause := make(chan struct{}, 1)
+
+	l := MigrationsList{}
+	for i := 1; i <= 3; i++ {
+		l.Register(func(db dbx.Builder) error {
+			select {
+			case pause <- struct{}{}:
+			default:
+			}
+			return nil
+		}, nil, fmt.Sprintf("%d_test", i))
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Pause = pause
+
+	// without separate transactions the run is never paused
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 3 {
+		t.Fatalf("Expected 3 applied migrations, got %v", applied)
+	}
+
+	if _, err := r.Down(r.appliedCount()); err != nil {
+		t.Fatal(err)
+	}
+	<-pause
+
+	r.PerMigrationTx = true
+
+	for i, expected := range []string{"1_test", "2_test", "3_test"} {
+		applied, err := r.Up()
+		if i < 2 && !errors.Is(err, ErrPaused) {
+			t.Fatalf("(%d) Expected ErrPaused, got %v", i, err)
+		}
+		if i == 2 && err != nil {
+			t.Fatalf("(%d) Expected nil error, got %v", i, err)
+		}
+		if strings.Join(applied, ",") != expected {
+			t.Fatalf("(%d) Expected applied %s, got %v", i, expected, applied)
+		}
+	}
+}
+
+func TestRunnerRunUpPauseFile(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+	if err := os.WriteFile(pauseFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Run("up", "--pause-file="+pauseFile); err != nil {
+		t.Fatalf("Expected the paused run to succeed, got %v", err)
+	}
+
+	if r.PauseFile != "" {
+		t.Fatalf("Expected the PauseFile to be restored, got %q", r.PauseFile)
+	}
+
+	if r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected 1_test to not be applied while paused")
+	}
+
+	if err := r.Run("up", "--pause-file"); err == nil {
+		t.Fatal("Expected missing --pause-file path error, got nil")
+	}
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrPendingMigrations is returned by the "pending --check" command
+// when there are unapplied migrations.
+var ErrPendingMigrations = errors.New("There are pending migrations")
+
+// PendingMigrations returns the names of all unapplied migrations
+// in their apply order.
+//
+// The migrations of a named set are prefixed with the set name
+// (eg. "staging/1656512345_seed.go").
+func (r *Runner) PendingMigrations() ([]string, error) {
+	pending, err := r.pendingItems()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(pending))
+
+	for _, m := range pending {
+		result = append(result, migrationName(m))
+	}
+
+	return result, nil
+}
+
+// pendingItems returns the unapplied migrations in their apply order.
+func (r *Runner) pendingItems() ([]*Migration, error) {
+	appliedRecords, err := r.AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedMap := make(map[string]struct{}, len(appliedRecords))
+	for _, a := range appliedRecords {
+		appliedMap[a.Set+"/"+a.File] = struct{}{}
+	}
+
+	items, err := r.checkpointItems(r.items())
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Migration{}
+
+	for _, m := range items {
+		if _, ok := appliedMap[m.set+"/"+m.File]; !ok {
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+// migrationName returns the migration file name,
+// prefixed with its set name (if any).
+func migrationName(m *Migration) string {
+	if m.set != "" {
+		return m.set + "/" + m.File
+	}
+
+	return m.File
+}
+
+// WritePending writes the unapplied migrations names to w,
+// either one per line or as a single JSON array.
+//
+// Returns the number of the written pending migrations.
+func (r *Runner) WritePending(w io.Writer, asJSON bool) (int, error) {
+	pending, err := r.PendingMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if asJSON {
+		raw, err := json.Marshal(pending)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = fmt.Fprintln(w, string(raw))
+
+		return len(pending), err
+	}
+
+	for _, name := range pending {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// runPendingCommand handles the "pending [--json] [--output PATH] [--check]" command.
+func (r *Runner) runPendingCommand(a RunArgs) error {
+	var w io.Writer = os.Stdout
+
+	if a.Has("--output") {
+		if a.Output == "" {
+			return fmt.Errorf("Missing --output file path")
+		}
+
+		f, err := os.Create(a.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	total, err := r.WritePending(w, a.Has("--json"))
+	if err != nil {
+		return err
+	}
+
+	if total > 0 && a.Has("--check") {
+		return ErrPendingMigrations
+	}
+
+	return nil
+}
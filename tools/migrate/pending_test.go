@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunnerPendingMigrations(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test")
+	l.Register(nil, nil, "2_test")
+	l.Register(nil, nil, "4_test")
+
+	staging := MigrationsList{}
+	staging.Register(nil, nil, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.saveAppliedMigration(testDB, "", "1_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		asJSON   bool
+		expected string
+	}{
+		{false, "2_test\nstaging/3_test\n4_test\n"},
+		{true, `["2_test","staging/3_test","4_test"]` + "\n"},
+	}
+
+	for i, s := range scenarios {
+		var b strings.Builder
+
+		total, err := r.WritePending(&b, s.asJSON)
+		if err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		if total != 3 {
+			t.Errorf("(%d) Expected 3 pending migrations, got %d", i, total)
+		}
+
+		if result := b.String(); result != s.expected {
+			t.Errorf("(%d) Expected %q, got %q", i, s.expected, result)
+		}
+	}
+}
+
+func TestRunnerRunPending(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "pending.json")
+
+	if err := r.Run("pending", "--json", "--output", output, "--check"); err != ErrPendingMigrations {
+		t.Fatalf("Expected ErrPendingMigrations, got %v", err)
+	}
+
+	raw, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `["1_test"]` + "\n"; string(raw) != expected {
+		t.Fatalf("Expected %q, got %q", expected, raw)
+	}
+
+	if err := r.saveAppliedMigration(testDB, "", "1_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Run("pending", "--json", "--output", output, "--check"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	raw, _ = os.ReadFile(output)
+	if expected := "[]\n"; string(raw) != expected {
+		t.Fatalf("Expected %q, got %q", expected, raw)
+	}
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Plan defines the ordered actions that a migrations command would
+// execute (eg. to snapshot test the migrations order).
+type Plan struct {
+	Command string       `json:"command"`
+	Actions []PlanAction `json:"actions"`
+}
+
+// PlanAction defines a single Plan migration action.
+type PlanAction struct {
+	// Direction is one of the DirectionUp and DirectionDown constants.
+	Direction string `json:"direction"`
+
+	// File is the migration file name, prefixed with
+	// its set name (if any, eg. "staging/1656512345_seed.go").
+	File string `json:"file"`
+}
+
+// String returns the plan actions in a human readable
+// form (eg. "up 1_init.go, up 2_posts.go").
+func (p Plan) String() string {
+	actions := make([]string, len(p.Actions))
+	for i, a := range p.Actions {
+		actions[i] = a.Direction + " " + a.File
+	}
+
+	return strings.Join(actions, ", ")
+}
+
+// Plan returns the ordered migration actions that the specified "up"
+// or "down [number]" command would execute based on the current applied
+// migrations, without executing them.
+//
+// The "--set NAMES" flag is supported the same way as with Run.
+func (r *Runner) Plan(cmd string, args ...string) (Plan, error) {
+	a, err := ParseRunArgs(append([]string{cmd}, args...)...)
+	if err != nil {
+		return Plan{Command: cmd, Actions: []PlanAction{}}, err
+	}
+
+	return r.plan(a)
+}
+
+// plan returns the Plan of the parsed "up" or "down" command arguments.
+func (r *Runner) plan(a RunArgs) (Plan, error) {
+	if len(a.Sets) > 0 {
+		defer func(original []string) { r.Sets = original }(r.Sets)
+		r.Sets = a.Sets
+	}
+
+	plan := Plan{Command: a.Command, Actions: []PlanAction{}}
+
+	if err := r.checkSets(); err != nil {
+		return plan, err
+	}
+
+	items, err := r.orderedItems()
+	if err != nil {
+		return plan, err
+	}
+
+	applied, err := r.appliedNames()
+	if err != nil {
+		return plan, err
+	}
+
+	switch a.Command {
+	case "up":
+		if items, err = r.checkpointItems(items); err != nil {
+			return plan, err
+		}
+
+		if r.FloorOnDown && !r.PastFloor && !a.Has("--past-floor") {
+			if items, _, err = r.excludeFloored(items); err != nil {
+				return plan, err
+			}
+		}
+
+		for _, m := range items {
+			if _, ok := applied[migrationName(m)]; !ok {
+				plan.Actions = append(plan.Actions, PlanAction{Direction: DirectionUp, File: migrationName(m)})
+			}
+		}
+	case "down":
+		toRevertCount, err := a.revertCount()
+		if err != nil {
+			return plan, err
+		}
+
+		for i := len(items) - 1; i >= 0; i-- {
+			if toRevertCount >= 0 && len(plan.Actions) >= toRevertCount {
+				break
+			}
+
+			if _, ok := applied[migrationName(items[i])]; ok {
+				plan.Actions = append(plan.Actions, PlanAction{Direction: DirectionDown, File: migrationName(items[i])})
+			}
+		}
+	default:
+		return plan, fmt.Errorf("Unsupported plan command: %q", a.Command)
+	}
+
+	return plan, nil
+}
+
+// printDryRun prints the plan actions of the "up --dry-run"
+// and "down --dry-run" commands without executing them.
+func (r *Runner) printDryRun(a RunArgs) error {
+	plan, err := r.plan(a)
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	if len(plan.Actions) == 0 {
+		if a.Command == "down" {
+			r.printSuccess("No migrations to revert.")
+		} else {
+			r.printSuccess("No new migrations to apply.")
+		}
+		return nil
+	}
+
+	for _, action := range plan.Actions {
+		if action.Direction == DirectionDown {
+			fmt.Printf("Would revert %s\n", action.File)
+		} else {
+			fmt.Printf("Would apply %s\n", action.File)
+		}
+	}
+
+	return nil
+}
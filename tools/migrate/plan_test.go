@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRunnerPlan(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test")
+	l.Register(nil, nil, "2_test")
+	l.Register(nil, nil, "3_test")
+	l.Register(nil, nil, "4_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+	r.saveAppliedMigration(testDB, "", "3_test")
+
+	scenarios := []struct {
+		cmd         string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{"up", nil, false, "up 2_test, up 4_test"},
+		{"down", nil, false, "down 3_test"},
+		{"down", []string{"5"}, false, "down 3_test, down 1_test"},
+		{"down", []string{"-1"}, false, "down 3_test, down 1_test"},
+		{"down", []string{"0"}, true, ""},
+		{"down", []string{"abc"}, true, ""},
+		{"up", []string{"--set", "missing"}, true, ""},
+		{"invalid", nil, true, ""},
+	}
+
+	for i, s := range scenarios {
+		plan, err := r.Plan(s.cmd, s.args...)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if hasErr {
+			continue
+		}
+
+		if plan.String() != s.expected {
+			t.Errorf("(%d) Expected plan %q, got %q", i, s.expected, plan.String())
+		}
+	}
+
+	// the planning shouldn't apply or revert anything
+	if total := r.appliedCount(); total != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %d", total)
+	}
+
+	// deterministic and serializable
+	planA, _ := r.Plan("up")
+	planB, _ := r.Plan("up")
+	if !reflect.DeepEqual(planA, planB) {
+		t.Fatalf("Expected equal plans, got %v and %v", planA, planB)
+	}
+
+	raw, err := json.Marshal(planA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJSON := `{"command":"up","actions":[{"direction":"up","file":"2_test"},{"direction":"up","file":"4_test"}]}`
+	if string(raw) != expectedJSON {
+		t.Fatalf("Expected json %s, got %s", expectedJSON, raw)
+	}
+}
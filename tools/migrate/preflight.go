@@ -0,0 +1,103 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PreflightReversible checks whether all pending migrations could be
+// reverted, aka. each of them has a Down func (or a "-- +down" SQL
+// section) or is explicitly marked as irreversible (see RegisterIrreversible).
+//
+// Returns an error listing the pending migrations without a down
+// (eg. to be used as a CI gate before a deploy).
+func (r *Runner) PreflightReversible() error {
+	pending, err := r.pendingItems()
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+
+	for _, m := range pending {
+		if m.IrreversibleReason != "" {
+			continue
+		}
+
+		hasDown, err := m.hasDown()
+		if err != nil {
+			return err
+		}
+
+		if !hasDown {
+			missing = append(missing, migrationName(m))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"The following pending migrations don't have a down migration: %s",
+			strings.Join(missing, ", "),
+		)
+	}
+
+	return nil
+}
+
+// preflightSQLRoundTrip runs the SQL migrations round trip check
+// (see CheckSQLRoundTrip), printing the result of each checked migration.
+//
+// The check is skipped for the non-SQLite runner dbs.
+func (r *Runner) preflightSQLRoundTrip() error {
+	if driver := r.db.DriverName(); driver != "sqlite" && driver != "sqlite3" {
+		color.Yellow("Skipped the SQL migrations round trip check (supported only for SQLite databases).")
+		return nil
+	}
+
+	results, err := r.CheckSQLRoundTrip()
+	if err != nil {
+		return err
+	}
+
+	failed := []string{}
+
+	for _, result := range results {
+		if result.Error != nil {
+			failed = append(failed, result.Migration)
+			color.Red("✗ %s: %v", result.Migration, result.Error)
+		} else if !r.Quiet {
+			color.Green("✓ %s", result.Migration)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf(
+			"The down section of the following SQL migrations doesn't reverse their up section: %s",
+			strings.Join(failed, ", "),
+		)
+	}
+
+	return nil
+}
+
+// hasDown checks whether the migration has a down migration,
+// loading the lazily registered SQL migrations if necessary.
+func (m *Migration) hasDown() (bool, error) {
+	if m.Down == nil {
+		return false, nil
+	}
+
+	if !m.isSQL {
+		return true, nil
+	}
+
+	if m.load != nil {
+		if err := m.load(); err != nil {
+			return false, err
+		}
+	}
+
+	return m.hasDownSQL, nil
+}
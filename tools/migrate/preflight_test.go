@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerPreflightReversible(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, nil, "1_applied")
+	l.Register(noop, noop, "2_go")
+	l.Register(noop, nil, "3_go_missing")
+	l.RegisterIrreversible(noop, "data removal", "4_irreversible")
+	l.RegisterSQL("5_sql.sql", "-- +up\nSELECT 1;\n-- +down\nSELECT 2;")
+	l.RegisterSQL("6_sql_missing.sql", "-- +up\nSELECT 1;")
+	l.RegisterFS(fstest.MapFS{
+		"7_fs.sql":         {Data: []byte("-- +up\nSELECT 1;\n-- +down\nSELECT 2;")},
+		"8_fs_missing.sql": {Data: []byte("-- +up\nSELECT 1;")},
+	})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.saveAppliedMigration(testDB, "", "1_applied"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.PreflightReversible()
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	expected := "The following pending migrations don't have a down migration: 3_go_missing, 6_sql_missing.sql, 8_fs_missing.sql"
+	if err.Error() != expected {
+		t.Fatalf("Expected error %q, got %q", expected, err.Error())
+	}
+
+	// all reversible
+	l2 := MigrationsList{}
+	l2.Register(noop, noop, "1_test")
+
+	r2, err := NewRunner(testDB.DB, l2, func(r *Runner) { r.TableName = "_migrations2" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r2.PreflightReversible(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+}
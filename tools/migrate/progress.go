@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// List with the "up --progress" flag defaults.
+const (
+	DefaultProgressEvery    = 25
+	DefaultProgressInterval = 500 * time.Millisecond
+)
+
+// progressEnabled reports whether the "up" command output
+// should be collapsed into periodic progress lines.
+func (r *Runner) progressEnabled() bool {
+	return r.ProgressEvery > 0 || r.ProgressInterval > 0
+}
+
+// progressPrinter collapses the "up" command per migration output into
+// periodic "Applied N/TOTAL..." lines, printed after every ProgressEvery
+// applied migrations or when ProgressInterval has elapsed since the
+// last line (whichever comes first).
+//
+// The failed migrations are always printed individually.
+type progressPrinter struct {
+	r         *Runner
+	total     int
+	applied   int
+	lastPrint time.Time
+	now       func() time.Time
+}
+
+func newProgressPrinter(r *Runner, total int) *progressPrinter {
+	return &progressPrinter{
+		r:         r,
+		total:     total,
+		lastPrint: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// notify handles a single up MigrationEvent.
+func (p *progressPrinter) notify(e MigrationEvent) {
+	switch e.Type {
+	case MigrationEventFailed:
+		if e.File != "" {
+			color.Red("Failed %s: %v", e.File, e.Error)
+		}
+	case MigrationEventApplied:
+		p.applied++
+
+		now := p.now()
+
+		everyReached := p.r.ProgressEvery > 0 && p.applied%p.r.ProgressEvery == 0
+		intervalReached := p.r.ProgressInterval > 0 && now.Sub(p.lastPrint) >= p.r.ProgressInterval
+
+		if (everyReached || intervalReached) && p.applied < p.total {
+			p.lastPrint = now
+			p.r.printSuccess("Applied %d/%d...", p.applied, p.total)
+		}
+	}
+}
+
+// summary prints the final progress line.
+func (p *progressPrinter) summary(applied []string) {
+	if len(applied) == 0 {
+		p.r.printSuccess("No new migrations to apply.")
+		return
+	}
+
+	p.r.printSuccess("Applied %d/%d migration(s) (run %s)", len(applied), p.total, p.r.LastRunId())
+}
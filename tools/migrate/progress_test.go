@@ -0,0 +1,103 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerRunUpProgress(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	for i := 1; i <= 60; i++ {
+		up := func(db dbx.Builder) error { return nil }
+		if i == 30 {
+			up = func(db dbx.Builder) error { return errors.New("test") }
+		}
+		l.Register(up, nil, fmt.Sprintf("%02d_test", i))
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.PerMigrationTx = true
+	r.ContinueOnError = true
+
+	originalOutput := color.Output
+	defer func() { color.Output = originalOutput }()
+
+	var b strings.Builder
+	color.Output = &b
+
+	if err := r.Run("up", "--progress"); err == nil {
+		t.Fatal("Expected the failed migration error, got nil")
+	}
+
+	output := b.String()
+
+	for _, expected := range []string{"Applied 25/60...", "Applied 50/60...", "Failed 30_test: "} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output, got \n%s", expected, output)
+		}
+	}
+
+	if strings.Contains(output, "Applied 01_test") {
+		t.Errorf("Expected no per migration lines, got \n%s", output)
+	}
+
+	if r.ProgressEvery != 0 || r.ProgressInterval != 0 {
+		t.Errorf("Expected the progress options to be restored, got %d, %v", r.ProgressEvery, r.ProgressInterval)
+	}
+
+	// summary
+	b.Reset()
+	r.migrationsList = &MigrationsList{}
+	r.migrationsList.(*MigrationsList).Register(func(db dbx.Builder) error { return nil }, nil, "61_test")
+	r.ProgressEvery = 25
+
+	if err := r.Run("up"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "Applied 1/1 migration(s) (run " + r.LastRunId() + ")"; !strings.Contains(b.String(), expected) {
+		t.Fatalf("Expected the %q summary, got \n%s", expected, b.String())
+	}
+}
+
+func TestProgressPrinterInterval(t *testing.T) {
+	originalOutput := color.Output
+	defer func() { color.Output = originalOutput }()
+
+	var b strings.Builder
+	color.Output = &b
+
+	now := time.Now()
+
+	p := newProgressPrinter(&Runner{ProgressInterval: time.Second}, 10)
+	p.lastPrint = now
+	p.now = func() time.Time { return now }
+
+	p.notify(MigrationEvent{Type: MigrationEventApplied, File: "1_test"})
+	p.notify(MigrationEvent{Type: MigrationEventStarted, File: "2_test"})
+
+	now = now.Add(time.Second)
+	p.notify(MigrationEvent{Type: MigrationEventApplied, File: "2_test"})
+
+	now = now.Add(100 * time.Millisecond)
+	p.notify(MigrationEvent{Type: MigrationEventApplied, File: "3_test"})
+
+	if b.String() != "Applied 2/10...\n" {
+		t.Fatalf("Expected a single progress line, got %q", b.String())
+	}
+}
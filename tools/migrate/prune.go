@@ -0,0 +1,112 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+// PruneHistory deletes the reverted migrations history records
+// (see KeepRevertedHistory) that were reverted before the specified time.
+//
+// The records of the currently applied migrations are never deleted.
+//
+// Returns the number of the deleted records.
+func (r *Runner) PruneHistory(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	var total int64
+
+	err := r.withTrackingRetry(func() error {
+		total = 0
+
+		return r.db.Transactional(func(tx *dbx.Tx) error {
+			if err := r.createHistoryTable(tx); err != nil {
+				return err
+			}
+
+			// the history table and the not yet archived reverted records
+			for _, table := range []string{r.historyTable(), r.TableName} {
+				result, err := tx.Delete(table, r.prunableExp(before)).Execute()
+				if err != nil {
+					return err
+				}
+
+				affected, err := result.RowsAffected()
+				if err != nil {
+					return err
+				}
+
+				total += affected
+			}
+
+			return nil
+		})
+	})
+
+	return total, err
+}
+
+// prunableCount returns the number of history records
+// that will be deleted by PruneHistory(before).
+func (r *Runner) prunableCount(before time.Time) (int, error) {
+	if r.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	if err := r.withTrackingRetry(func() error { return r.createHistoryTable(r.db) }); err != nil {
+		return 0, err
+	}
+
+	total := 0
+
+	for _, table := range []string{r.historyTable(), r.TableName} {
+		var count int
+
+		err := r.withTrackingRetry(func() error {
+			return r.db.Select(r.Dialect.CountExp()).
+				From(table).
+				Where(r.prunableExp(before)).
+				Row(&count)
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		total += count
+	}
+
+	return total, nil
+}
+
+func (r *Runner) prunableExp(before time.Time) dbx.Expression {
+	return dbx.NewExp(
+		"[[reverted]] > 0 AND [[reverted]] < {:before}",
+		dbx.Params{"before": before.UnixNano()},
+	)
+}
+
+// parsePruneCutoff parses the "prune" command cutoff argument, which
+// could be either a positive duration relative to the runner clock
+// (eg. "720h") or a date (eg. "2022-06-30").
+func (r *Runner) parsePruneCutoff(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("Invalid prune cutoff %q (the duration must be positive)", value)
+		}
+
+		return r.now().Add(-d), nil
+	}
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid prune cutoff %q (expected duration like 720h or date like 2022-06-30)", value)
+}
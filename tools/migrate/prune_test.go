@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerParsePruneCutoff(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	r := &Runner{Now: func() time.Time { return now }}
+
+	scenarios := []struct {
+		value       string
+		expectError bool
+		expected    time.Time
+	}{
+		{"", true, time.Time{}},
+		{"invalid", true, time.Time{}},
+		{"0s", true, time.Time{}},
+		{"-24h", true, time.Time{}},
+		{"2022-06-30", false, time.Date(2022, 6, 30, 0, 0, 0, 0, time.UTC)},
+		{"24h", false, now.Add(-24 * time.Hour)},
+	}
+
+	for i, s := range scenarios {
+		result, err := r.parsePruneCutoff(s.value)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if !result.Equal(s.expected) {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestRunnerPruneHistory(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour).UnixNano()
+	recent := time.Now().Add(-1 * time.Hour).UnixNano()
+
+	records := []dbx.Params{
+		{"file": "1_applied", "applied": old},
+		{"file": "2_old_reverted", "applied": old, "reverted": old},
+		{"file": "3_recent_reverted", "applied": old, "reverted": recent},
+		{"file": "4_old_reverted", "applied": old, "reverted": old},
+	}
+	for _, record := range records {
+		if _, err := testDB.Insert(r.TableName, record).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.createHistoryTable(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	historyRecords := []dbx.Params{
+		{"file": "1_applied", "applied": old - 2, "reverted": old - 1},
+		{"file": "5_recent_reverted", "applied": old, "reverted": recent},
+	}
+	for _, record := range historyRecords {
+		if _, err := testDB.Insert(r.historyTable(), record).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var confirmMessage string
+	r.Confirm = func(message string) (bool, error) {
+		confirmMessage = message
+		return false, nil
+	}
+
+	if err := r.Run("prune", "24h"); err != nil {
+		t.Fatal(err)
+	}
+
+	if confirmMessage != "Do you really want to prune 3 reverted history record(s)?" {
+		t.Fatalf("Unexpected confirm message %q", confirmMessage)
+	}
+
+	pruned, err := r.PruneHistory(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 3 {
+		t.Fatalf("Expected 3 pruned records, got %d", pruned)
+	}
+
+	var files []string
+	testDB.Select("file").From(r.TableName).OrderBy("file ASC").Column(&files)
+	if len(files) != 2 || files[0] != "1_applied" || files[1] != "3_recent_reverted" {
+		t.Fatalf("Expected only 1_applied and 3_recent_reverted to remain, got %v", files)
+	}
+
+	var historyFiles []string
+	testDB.Select("file").From(r.historyTable()).Column(&historyFiles)
+	if len(historyFiles) != 1 || historyFiles[0] != "5_recent_reverted" {
+		t.Fatalf("Expected only the 5_recent_reverted history record to remain, got %v", historyFiles)
+	}
+}
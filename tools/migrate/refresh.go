@@ -0,0 +1,47 @@
+package migrate
+
+// Refresh re-snapshots the migrations of the list passed to NewRunner
+// and of the registered sets (see RegisterSet), making the migrations
+// registered to them after the runner creation visible to the
+// next runner calls (eg. after a lazily loaded plugin registration).
+//
+// The current snapshot is kept if any of the new migrations
+// names doesn't match the Runner.NamePattern.
+func (r *Runner) Refresh() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	migrationsList := snapshotMigrations(r.migrationsSource)
+	if err := r.checkRegisteredNames(migrationsList); err != nil {
+		return err
+	}
+
+	sets := make([]migrationsSet, len(r.sets))
+	for i, s := range r.sets {
+		snapshot := snapshotMigrations(s.source)
+		if err := r.checkRegisteredNames(snapshot); err != nil {
+			return err
+		}
+
+		sets[i] = migrationsSet{name: s.name, list: snapshot, source: s.source}
+	}
+
+	for _, s := range sets {
+		for _, m := range s.list.Items() {
+			m.set = s.name
+		}
+	}
+
+	r.migrationsList = migrationsList
+	r.sets = sets
+
+	return nil
+}
+
+// snapshotMigrations returns a copy of the provided migrations list
+// that is not affected by the later list registrations.
+//
+// The copy shares the migration definitions with the original list.
+func snapshotMigrations(migrations Migrations) *MigrationsList {
+	return &MigrationsList{list: append([]*Migration{}, migrations.Items()...)}
+}
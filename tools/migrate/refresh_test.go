@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerLateRegistration(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	scenarios := []struct {
+		name                 string
+		factory              func(l *MigrationsList) Migrations
+		expectedAfterRefresh string
+	}{
+		// the value list is a fixed copy
+		{"value list", func(l *MigrationsList) Migrations { return *l }, "5_seed.go"},
+		{"pointer list", func(l *MigrationsList) Migrations { return l }, "1_test.go,4_test.go,5_seed.go"},
+	}
+
+	for _, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// with a spare capacity to share the backing array with the value copy
+		l := &MigrationsList{list: make([]*Migration, 0, 10)}
+		l.Register(noop, noop, "2_test.go")
+		l.Register(noop, noop, "3_test.go")
+
+		staging := &MigrationsList{}
+
+		r, err := NewRunner(testDB.DB, s.factory(l))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := r.RegisterSet("staging", staging); err != nil {
+			t.Fatal(err)
+		}
+
+		// late registrations (including one that sorts before the existing ones)
+		l.Register(noop, noop, "1_test.go")
+		l.Register(noop, noop, "4_test.go")
+		staging.Register(noop, noop, "5_seed.go")
+
+		applied, err := r.Up()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := strings.Join(applied, ","); v != "2_test.go,3_test.go" {
+			t.Errorf("[%s] Expected only the snapshotted migrations to be applied, got %v", s.name, v)
+		}
+
+		if err := r.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err = r.Up()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := strings.Join(applied, ","); v != s.expectedAfterRefresh {
+			t.Errorf("[%s] Expected %s to be applied after Refresh, got %v", s.name, s.expectedAfterRefresh, v)
+		}
+
+		if !r.isMigrationApplied(testDB, "staging", "5_seed.go") {
+			t.Errorf("[%s] Expected the late registered set migration to be applied in its set", s.name)
+		}
+
+		testDB.Close()
+	}
+}
+
+func TestRunnerRefreshNamePattern(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := &MigrationsList{}
+	l.Register(noop, noop, "1_test.go")
+
+	r, err := NewRunner(testDB.DB, l, func(r *Runner) {
+		r.NamePattern = regexp.MustCompile(`^\d+_\w+\.go$`)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Register(noop, noop, "2_test.go")
+	l.Register(noop, noop, "invalid.go")
+
+	if err := r.Refresh(); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// the current snapshot is kept
+	if total := r.migrationsList.Len(); total != 1 {
+		t.Fatalf("Expected 1 migration, got %d", total)
+	}
+}
@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+const releasesTable = "_migrationsReleases"
+
+// MarkRelease records a named release marker (eg. "v1.2.3") at the current time.
+//
+// The marker can be later used with MigrationsSinceRelease to
+// find out which migrations were applied after the release deploy.
+func (r *Runner) MarkRelease(tag string) error {
+	if r.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if tag == "" {
+		return errors.New("Missing release tag")
+	}
+
+	if err := r.createReleasesTable(); err != nil {
+		return err
+	}
+
+	_, err := r.db.Insert(releasesTable, dbx.Params{
+		"tag":     tag,
+		"created": r.nextTimestamp(),
+	}).Execute()
+	if err != nil {
+		return fmt.Errorf("Failed to save release marker %q: %w", tag, err)
+	}
+
+	return nil
+}
+
+// MigrationsSinceRelease returns the file names of the migrations
+// applied after the specified release marker (ordered by their apply time).
+func (r *Runner) MigrationsSinceRelease(tag string) ([]string, error) {
+	if !r.ReadOnly {
+		if err := r.createReleasesTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	var created int64
+
+	err := r.db.Select("created").
+		From(releasesTable).
+		Where(dbx.HashExp{"tag": tag}).
+		Limit(1).
+		Row(&created)
+	if err != nil {
+		return nil, fmt.Errorf("Missing or invalid release marker %q: %w", tag, err)
+	}
+
+	files := []string{}
+
+	err = r.db.Select("file").
+		From(r.TableName).
+		Where(appliedRecordExp()).
+		AndWhere(dbx.NewExp("[[applied]] > {:created}", dbx.Params{"created": created})).
+		OrderBy("applied ASC", "file ASC").
+		Column(&files)
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (r *Runner) createReleasesTable() error {
+	rawQuery := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (tag %s PRIMARY KEY NOT NULL, created %s NOT NULL)",
+		r.db.QuoteTableName(releasesTable),
+		r.Dialect.KeyType(),
+		r.Dialect.IntegerType(),
+	)
+
+	_, err := r.db.NewQuery(rawQuery).Execute()
+
+	return err
+}
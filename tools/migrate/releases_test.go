@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerMigrationsSinceRelease(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.MigrationsSinceRelease("missing"); err == nil {
+		t.Fatal("Expected error for missing release marker")
+	}
+
+	if err := r.MarkRelease(""); err == nil {
+		t.Fatal("Expected error for empty release tag")
+	}
+
+	if err := r.MarkRelease("v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// move the marker in the past to simulate older release
+	testDB.Update(releasesTable, dbx.Params{"created": 100}, dbx.HashExp{"tag": "v1.0.0"}).Execute()
+
+	rows := []dbx.Params{
+		{"file": "1_test", "applied": 50},
+		{"file": "2_test", "applied": 150},
+		{"file": "3_test", "applied": 120},
+		{"file": "4_test", "applied": 200, "reverted": 250},
+	}
+	for _, row := range rows {
+		if _, err := testDB.Insert(r.TableName, row).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := r.MigrationsSinceRelease("v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"3_test", "2_test"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, files)
+	}
+	for i, file := range expected {
+		if files[i] != file {
+			t.Fatalf("Expected %v, got %v", expected, files)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+)
+
+// migrationFileRegex defines the default migration file name convention
+// (eg. "1656512345_add_posts_table.go").
+var migrationFileRegex = regexp.MustCompile(`^\d+_\w+\.go$`)
+
+// Rename renames the applied oldFile migration to newFile.
+//
+// The migration of a named set could be specified in the "set/file"
+// format (eg. "seeds/1_init.go") and is renamed within its set
+// (the set prefix of newFile is optional).
+//
+// The migration record is updated so that the migration is not
+// reapplied under its new name, and if the oldFile source of a default
+// set migration exists in the runner migrations dir, it is also
+// renamed on disk.
+//
+// Only the runner migrations snapshot is updated with the new name,
+// meaning that the list passed to NewRunner (or RegisterSet) keeps the
+// old name until the migration is registered again with its new name
+// (eg. on the next build), so avoid calling Refresh in the meantime.
+func (r *Runner) Rename(oldFile string, newFile string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return ErrReadOnly
+	}
+
+	set, oldFile := splitMigrationName(oldFile)
+
+	newSet, newFile := splitMigrationName(newFile)
+	if newSet != "" && newSet != set {
+		return fmt.Errorf("Migration %s cannot be moved to the %q set", oldFile, newSet)
+	}
+
+	if err := r.checkFileName(newFile); err != nil {
+		return err
+	}
+
+	if oldFile == newFile {
+		return errors.New("The old and new migration file names must be different")
+	}
+
+	var l *MigrationsList
+	if set == "" {
+		l, _ = r.migrationsList.(*MigrationsList)
+	} else {
+		found := false
+		for _, s := range r.sets {
+			if s.name == set {
+				l, _ = s.list.(*MigrationsList)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("Migrations set %q is not registered", set)
+		}
+	}
+	if l == nil {
+		return errors.New("The runner migrations list doesn't support renaming")
+	}
+
+	// load the lazy migrations content before copying them
+	for _, m := range l.list {
+		if m.File == oldFile && m.load != nil {
+			if err := m.load(); err != nil {
+				return err
+			}
+		}
+	}
+
+	dir, err := r.migrationsDir()
+	if err != nil {
+		return err
+	}
+	oldPath := path.Join(dir, oldFile)
+	newPath := path.Join(dir, newFile)
+
+	var renamedFile bool
+
+	err = r.db.Transactional(func(tx *dbx.Tx) error {
+		if !r.isMigrationApplied(tx, set, oldFile) {
+			return fmt.Errorf("Migration %s is not applied", oldFile)
+		}
+
+		if r.isMigrationApplied(tx, set, newFile) {
+			return fmt.Errorf("Migration %s is already applied", newFile)
+		}
+
+		// remove previously reverted record (if any) to avoid primary key conflicts
+		if err := r.cleanupInactiveRecords(tx, set, newFile); err != nil {
+			return err
+		}
+
+		_, err := tx.Update(
+			r.TableName,
+			dbx.Params{"file": newFile},
+			dbx.HashExp{"file": oldFile, "set": set},
+		).Execute()
+		if err != nil {
+			return fmt.Errorf("Failed to update migration record %s: %w", oldFile, err)
+		}
+
+		// the migrations dir contains only the default set sources
+		if set != "" {
+			return nil
+		}
+
+		// rename the source file (if exists) as the last step
+		// so that it could be restored if the commit fails
+		if _, err := os.Stat(oldPath); err == nil {
+			if err := os.Rename(oldPath, newPath); err != nil {
+				return fmt.Errorf("Failed to rename migration file %q: %w", oldPath, err)
+			}
+			renamedFile = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		if renamedFile {
+			if restoreErr := os.Rename(newPath, oldPath); restoreErr != nil {
+				return fmt.Errorf("%w (also failed to restore the migration file %q: %v)", err, oldPath, restoreErr)
+			}
+		}
+
+		return err
+	}
+
+	// sync the registered migration name only in the runner snapshot
+	// (the caller migration definitions and lists are not modified)
+	for i, m := range l.list {
+		if m.File == oldFile {
+			renamed := *m
+			renamed.File = newFile
+			l.list[i] = &renamed
+		}
+	}
+	sort.SliceStable(l.list, func(i int, j int) bool {
+		return migrationLess(l.list[i], l.list[j])
+	})
+
+	return nil
+}
+
+// splitMigrationName splits the "set/file" migration name into its
+// set (empty for the default set migrations) and file name.
+func splitMigrationName(name string) (string, string) {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+
+	return "", name
+}
@@ -0,0 +1,237 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerRename(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_old.go")
+	l.Register(noop, noop, "2_pending.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Dir = t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(r.Dir, "1_old.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_old.go")
+
+	scenarios := []struct {
+		oldFile     string
+		newFile     string
+		expectError bool
+	}{
+		{"1_old.go", "invalid", true},
+		{"1_old.go", "1_old.go", true},
+		{"2_pending.go", "2_new.go", true},
+		{"1_old.go", "1_new.go", false},
+	}
+
+	for i, s := range scenarios {
+		err := r.Rename(s.oldFile, s.newFile)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Fatalf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+
+	if r.isMigrationApplied(testDB, "", "1_old.go") || !r.isMigrationApplied(testDB, "", "1_new.go") {
+		t.Fatal("Expected the migration record to be renamed")
+	}
+
+	if _, err := os.Stat(filepath.Join(r.Dir, "1_new.go")); err != nil {
+		t.Fatalf("Expected the migration file to be renamed, got %v", err)
+	}
+
+	if l.Items()[0].File != "1_old.go" {
+		t.Fatalf("Expected the caller migration to be unchanged, got %q", l.Items()[0].File)
+	}
+
+	// the renamed migration shouldn't be reapplied
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0] != "2_pending.go" {
+		t.Fatalf("Expected only 2_pending.go to be applied, got %v", applied)
+	}
+}
+
+func TestRunnerRenameRestoresFileOnError(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_old.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Dir = t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(r.Dir, "1_old.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_old.go")
+
+	// fail the commit after the file rename
+	_, err = testDB.NewQuery("PRAGMA foreign_keys = ON").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testDB.NewQuery("CREATE TABLE parent (id TEXT PRIMARY KEY)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testDB.NewQuery("CREATE TABLE child (parent TEXT REFERENCES parent (id) DEFERRABLE INITIALLY DEFERRED)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testDB.NewQuery("CREATE TRIGGER fail_commit AFTER UPDATE ON _migrations BEGIN INSERT INTO child VALUES ('missing'); END").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Rename("1_old.go", "1_new.go"); err == nil {
+		t.Fatal("Expected the rename to fail")
+	}
+
+	// SQLite keeps the transaction open after a failed deferred constraints COMMIT
+	testDB.NewQuery("ROLLBACK").Execute()
+
+	if _, err := os.Stat(filepath.Join(r.Dir, "1_old.go")); err != nil {
+		t.Fatalf("Expected the migration file to be restored, got %v", err)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_old.go") {
+		t.Fatal("Expected the migration record to be unchanged")
+	}
+
+	if r.migrationsList.Items()[0].File != "1_old.go" {
+		t.Fatalf("Expected the runner migration name to be unchanged, got %q", r.migrationsList.Items()[0].File)
+	}
+}
+
+func TestRunnerRenameSet(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_seed.go")
+
+	seeds := MigrationsList{}
+	seeds.Register(noop, noop, "1_seed.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterSet("seeds", &seeds); err != nil {
+		t.Fatal(err)
+	}
+	r.Dir = t.TempDir()
+
+	r.saveAppliedMigration(testDB, "", "1_seed.go")
+	r.saveAppliedMigration(testDB, "seeds", "1_seed.go")
+
+	scenarios := []struct {
+		oldFile     string
+		newFile     string
+		expectError bool
+	}{
+		{"missing/1_seed.go", "1_new.go", true},
+		{"seeds/1_seed.go", "other/1_new.go", true},
+		{"seeds/1_seed.go", "seeds/invalid", true},
+		{"seeds/1_seed.go", "seeds/1_new.go", false},
+	}
+
+	for i, s := range scenarios {
+		err := r.Rename(s.oldFile, s.newFile)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Fatalf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+
+	if r.isMigrationApplied(testDB, "seeds", "1_seed.go") || !r.isMigrationApplied(testDB, "seeds", "1_new.go") {
+		t.Fatal("Expected the set migration record to be renamed")
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_seed.go") {
+		t.Fatal("Expected the default set migration record to be unchanged")
+	}
+
+	// the renamed migration shouldn't be reapplied
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("Expected no applied migrations, got %v", applied)
+	}
+}
+
+func TestRunnerRenameFileExtension(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_old.sql")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Dir = t.TempDir()
+	r.FileExtension = ".sql"
+
+	r.saveAppliedMigration(testDB, "", "1_old.sql")
+
+	if err := r.Rename("1_old.sql", "1_new.go"); err == nil {
+		t.Fatal("Expected the name not matching the file extension to fail")
+	}
+
+	if err := r.Rename("1_old.sql", "1_new.sql"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_new.sql") {
+		t.Fatal("Expected the migration record to be renamed")
+	}
+}
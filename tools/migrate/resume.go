@@ -0,0 +1,183 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// List with the supported MigrationObject types.
+const (
+	ObjectTable   = "table"
+	ObjectIndex   = "index"
+	ObjectView    = "view"
+	ObjectTrigger = "trigger"
+)
+
+// MigrationObject defines a single db object created by a migration
+// (see Migration.Creates).
+type MigrationObject struct {
+	// Type is one of the Object* constants.
+	Type string
+
+	// Name is the object name (eg. "posts" or "idx_posts_title").
+	Name string
+}
+
+// ResumeCleanup drops the declared partial objects (see Migration.Creates)
+// of the first pending migration (aka. the one where a crashed or failed
+// PerMigrationTx run stopped), so that it could be re-applied.
+//
+// Returns the name of the cleaned migration.
+func (r *Runner) ResumeCleanup() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.resumeTarget()
+	if err != nil {
+		return "", err
+	}
+
+	if len(m.Creates) == 0 {
+		return "", fmt.Errorf("Migration %s doesn't declare its created objects (see Migration.Creates)", migrationName(m))
+	}
+
+	// drop in reverse order to handle the objects depending on the earlier ones
+	for i := len(m.Creates) - 1; i >= 0; i-- {
+		obj := m.Creates[i]
+
+		switch obj.Type {
+		case ObjectTable, ObjectIndex, ObjectView, ObjectTrigger:
+		default:
+			return "", fmt.Errorf("Unsupported %s object type %q", migrationName(m), obj.Type)
+		}
+
+		query := fmt.Sprintf("DROP %s IF EXISTS %s", strings.ToUpper(obj.Type), r.db.QuoteTableName(obj.Name))
+
+		if _, err := r.db.NewQuery(query).Execute(); err != nil {
+			return "", fmt.Errorf("Failed to drop the %s %s %s: %w", migrationName(m), obj.Type, obj.Name, err)
+		}
+	}
+
+	return migrationName(m), nil
+}
+
+// ResumeSkip records the first pending migration (aka. the one where a
+// crashed or failed PerMigrationTx run stopped) as applied without
+// executing its Up func (eg. when its partial changes were completed manually).
+//
+// Returns the name of the skipped migration.
+func (r *Runner) ResumeSkip() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.resumeTarget()
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.saveAppliedMigration(r.db, m.set, m.File); err != nil {
+		return "", fmt.Errorf("Failed to save applied migration info for %s: %w", migrationName(m), err)
+	}
+
+	return migrationName(m), nil
+}
+
+// resumeTarget returns the first pending migration.
+func (r *Runner) resumeTarget() (*Migration, error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	pending, err := r.pendingItems()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return nil, errors.New("There are no pending migrations to resume")
+	}
+
+	return pending[0], nil
+}
+
+// runResumeCommand handles the "resume --cleanup|--skip" command.
+func (r *Runner) runResumeCommand(a RunArgs) error {
+	cleanup := a.Has("--cleanup")
+	skip := a.Has("--skip")
+
+	if cleanup == skip {
+		return errors.New("Specify either --cleanup (to drop the failed migration partial objects) or --skip (to mark it as applied)")
+	}
+
+	pending, err := r.pendingItems()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		r.printSuccess("No new migrations to apply.")
+		return nil
+	}
+
+	target := pending[0]
+
+	var message string
+	if cleanup {
+		objects := make([]string, len(target.Creates))
+		for i, obj := range target.Creates {
+			objects[i] = obj.Type + " " + obj.Name
+		}
+		message = fmt.Sprintf(
+			"Do you really want to drop the %s objects (%s) and continue with the pending migrations?",
+			migrationName(target),
+			strings.Join(objects, ", "),
+		)
+	} else {
+		message = fmt.Sprintf(
+			"Do you really want to mark %s as applied without executing it and continue with the pending migrations?",
+			migrationName(target),
+		)
+	}
+
+	confirm, err := r.Confirm(message)
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		fmt.Println("The command has been cancelled")
+		return nil
+	}
+
+	if cleanup {
+		_, err = r.ResumeCleanup()
+	} else {
+		_, err = r.ResumeSkip()
+	}
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	if skip {
+		r.printSuccess("Marked %s as applied", migrationName(target))
+	}
+
+	applied, err := r.Up()
+	if err != nil {
+		color.Red(err.Error())
+		return err
+	}
+
+	for _, file := range applied {
+		r.printSuccess("Applied %s (run %s)", file, r.LastRunId())
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerResume(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	createTable := func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE demo (id TEXT PRIMARY KEY)").Execute()
+		if err != nil {
+			return err
+		}
+		_, err = db.NewQuery("CREATE INDEX idx_demo ON demo (id)").Execute()
+		return err
+	}
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Add(&Migration{
+		File:             "2_test",
+		Up:               createTable,
+		Down:             noop,
+		NonTransactional: true,
+		Creates: []MigrationObject{
+			{Type: ObjectTable, Name: "demo"},
+			{Type: ObjectIndex, Name: "idx_demo"},
+		},
+	})
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.PerMigrationTx = true
+
+	// simulate a crashed run that left the 2_test partial objects
+	r.saveAppliedMigration(testDB, "", "1_test")
+	if err := createTable(testDB); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected already exists error, got nil")
+	}
+
+	if err := r.Run("resume"); err == nil {
+		t.Fatal("Expected missing resume mode error, got nil")
+	}
+
+	if err := r.Run("resume", "--cleanup", "--skip"); err == nil {
+		t.Fatal("Expected ambiguous resume mode error, got nil")
+	}
+
+	// cancelled
+	r.Confirm = func(message string) (bool, error) {
+		return false, nil
+	}
+	if err := r.Run("resume", "--cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected 2_test to not be applied after a cancelled resume")
+	}
+
+	confirmMessage := ""
+	r.Confirm = func(message string) (bool, error) {
+		confirmMessage = message
+		return true, nil
+	}
+
+	if err := r.Run("resume", "--cleanup"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMessage := "Do you really want to drop the 2_test objects (table demo, index idx_demo) and continue with the pending migrations?"
+	if confirmMessage != expectedMessage {
+		t.Fatalf("Expected confirm message %q, got %q", expectedMessage, confirmMessage)
+	}
+
+	for _, file := range []string{"1_test", "2_test", "3_test"} {
+		if !r.isMigrationApplied(testDB, "", file) {
+			t.Fatalf("Expected %s to be applied", file)
+		}
+	}
+
+	if _, err := r.ResumeSkip(); err == nil {
+		t.Fatal("Expected no pending migrations error, got nil")
+	}
+}
+
+func TestRunnerResumeSkip(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := []string{}
+	callsFunc := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	l := MigrationsList{}
+	l.Register(callsFunc("1_up"), nil, "1_test")
+	l.Register(callsFunc("2_up"), nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Confirm = func(message string) (bool, error) {
+		return true, nil
+	}
+
+	if _, err := r.ResumeCleanup(); err == nil {
+		t.Fatal("Expected missing declared objects error, got nil")
+	}
+
+	if err := r.Run("resume", "--skip"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 1 || calls[0] != "2_up" {
+		t.Fatalf("Expected only 2_up to be called, got %v", calls)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected the skipped 1_test to be marked as applied")
+	}
+
+	r.ReadOnly = true
+	if _, err := r.ResumeSkip(); err != ErrReadOnly {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
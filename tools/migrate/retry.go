@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default tracking table retry settings
+// (max ~1.5s total wait before giving up).
+const (
+	DefaultTrackingRetries    = 5
+	DefaultTrackingRetryDelay = 50 * time.Millisecond
+)
+
+// withTrackingRetry executes fn and retries it with exponential backoff
+// in case the migrations tracking table is temporarily locked
+// (eg. by an external backup tool).
+//
+// Note that this is intended to be used only for the tracking table
+// queries and not for the user defined migration funcs.
+func (r *Runner) withTrackingRetry(fn func() error) error {
+	delay := r.TrackingRetryDelay
+	var totalWait time.Duration
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isLockedError(err) {
+			return err
+		}
+
+		if attempt >= r.TrackingRetries {
+			return fmt.Errorf(
+				"The migrations table is still locked after %d retries (waited %s): %w",
+				attempt,
+				totalWait,
+				err,
+			)
+		}
+
+		time.Sleep(delay)
+		totalWait += delay
+		delay *= 2
+	}
+}
+
+// isLockedError checks whether the provided error is a SQLite
+// "database is locked" or "database table is locked" error.
+func isLockedError(err error) bool {
+	msg := strings.ToLower(err.Error())
+
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "sqlite_busy") ||
+		strings.Contains(msg, "sqlite_locked")
+}
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsLockedError(t *testing.T) {
+	scenarios := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New("test"), false},
+		{errors.New("no such table: _migrations"), false},
+		{errors.New("database is locked (5) (SQLITE_BUSY)"), true},
+		{errors.New("database table is locked: _migrations"), true},
+		{errors.New("SQLITE_LOCKED"), true},
+	}
+
+	for i, s := range scenarios {
+		if result := isLockedError(s.err); result != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
+
+func TestRunnerWithTrackingRetry(t *testing.T) {
+	lockedErr := errors.New("database is locked")
+	otherErr := errors.New("other")
+
+	scenarios := []struct {
+		retries        int
+		errs           []error
+		expectAttempts int
+		expectError    bool
+	}{
+		{3, nil, 1, false},
+		{3, []error{otherErr}, 1, true},
+		{3, []error{lockedErr, lockedErr}, 3, false},
+		{2, []error{lockedErr, lockedErr, lockedErr, lockedErr}, 3, true},
+	}
+
+	for i, s := range scenarios {
+		r := &Runner{TrackingRetries: s.retries, TrackingRetryDelay: time.Millisecond}
+
+		attempts := 0
+		err := r.withTrackingRetry(func() error {
+			attempts++
+			if attempts <= len(s.errs) {
+				return s.errs[attempts-1]
+			}
+			return nil
+		})
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if attempts != s.expectAttempts {
+			t.Errorf("(%d) Expected %d attempts, got %d", i, s.expectAttempts, attempts)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/dbx"
+)
+
+// historyTable returns the name of the table with the reverted
+// migrations records (see Runner.KeepRevertedHistory).
+func (r *Runner) historyTable() string {
+	return r.TableName + "History"
+}
+
+// createHistoryTable creates the reverted migrations history table (if missing).
+//
+// The same migration could be applied and reverted multiple times,
+// so its records are identified also by their apply time.
+func (r *Runner) createHistoryTable(tx dbx.Builder) error {
+	_, err := tx.NewQuery(r.createMigrationsTableQuery(r.historyTable(), "file", "set", "applied")).Execute()
+
+	return err
+}
+
+// archiveRevertedRecords moves the reverted records of the specified
+// migration from the migrations table to the history table.
+func (r *Runner) archiveRevertedRecords(tx dbx.Builder, set string, file string) error {
+	if err := r.createHistoryTable(tx); err != nil {
+		return err
+	}
+
+	params := dbx.Params{"file": file, "set": set}
+
+	_, err := tx.NewQuery(fmt.Sprintf(
+		"INSERT INTO %v (%s) SELECT %s FROM %v WHERE [[file]] = {:file} AND [[set]] = {:set} AND [[reverted]] > 0",
+		r.db.QuoteTableName(r.historyTable()),
+		r.migrationsTableColumnsList(),
+		r.migrationsTableColumnsList(),
+		r.db.QuoteTableName(r.TableName),
+	)).Bind(params).Execute()
+	if err != nil {
+		return fmt.Errorf("Failed to archive the reverted records of %s: %w", AppliedMigration{Set: set, File: file}.name(), err)
+	}
+
+	_, err = tx.Delete(r.TableName, dbx.And(
+		dbx.HashExp(params),
+		dbx.NewExp("[[reverted]] > 0"),
+	)).Execute()
+
+	return err
+}
+
+// cleanupInactiveRecords removes the not applied records of the specified
+// migration from the migrations table before its new record is inserted
+// (the legacy applied=0 records and the reverted records that are
+// moved to the history table if KeepRevertedHistory is enabled).
+func (r *Runner) cleanupInactiveRecords(tx dbx.Builder, set string, file string) error {
+	if r.KeepRevertedHistory {
+		if err := r.archiveRevertedRecords(tx, set, file); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Delete(r.TableName, dbx.And(
+		dbx.HashExp{"file": file, "set": set},
+		dbx.Or(dbx.NewExp("[[reverted]] > 0"), dbx.NewExp("[[applied]] <= 0")),
+	)).Execute()
+
+	return err
+}
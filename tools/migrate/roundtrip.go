@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+)
+
+// SQLRoundTripResult is the reversibility check result of a single
+// SQL migration (see CheckSQLRoundTrip).
+type SQLRoundTripResult struct {
+	// Migration is the migration name (prefixed with its set name
+	// for the named sets migrations).
+	Migration string
+
+	// Error is nil if the down section restored the schema
+	// to its state before the up section.
+	Error error
+}
+
+// CheckSQLRoundTrip validates that the "-- +down" section of each SQL
+// migration reverses its "-- +up" section.
+//
+// The SQL migrations are applied in their apply order to a scratch
+// in-memory SQLite db (the runner db is not modified) and for each
+// reversible one the schema is snapshotted before the up, after the
+// down and the two snapshots are compared. The up section is then
+// applied again so the next migrations could build on it.
+//
+// Note that only the SQL migrations are applied to the scratch db, so
+// a SQL migration that depends on the schema created by a Go migration
+// is reported as failed.
+//
+// Returns one result per SQL migration with a down section or an error
+// if the scratch db cannot be created (eg. for a non-SQLite runner db).
+func (r *Runner) CheckSQLRoundTrip() ([]SQLRoundTripResult, error) {
+	driver := r.db.DriverName()
+	if driver != "sqlite" && driver != "sqlite3" {
+		return nil, fmt.Errorf("The SQL migrations round trip check is supported only for SQLite databases (got %q)", driver)
+	}
+
+	items, err := r.orderedItems()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := sql.Open(driver, ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// each :memory: connection is a separate db
+	sqlDB.SetMaxOpenConns(1)
+
+	scratch := dbx.NewFromDB(sqlDB, driver)
+	defer scratch.Close()
+
+	results := []SQLRoundTripResult{}
+
+	for _, m := range items {
+		if !m.isSQL {
+			continue
+		}
+
+		hasDown, err := m.hasDown()
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasDown || m.IrreversibleReason != "" {
+			if err := m.Up(scratch); err != nil {
+				return results, fmt.Errorf("Failed to apply %s to the scratch db: %w", migrationName(m), err)
+			}
+			continue
+		}
+
+		results = append(results, SQLRoundTripResult{
+			Migration: migrationName(m),
+			Error:     roundTrip(scratch, m),
+		})
+	}
+
+	return results, nil
+}
+
+// roundTrip applies the up, down and again the up section of the
+// m SQL migration, checking that the down restored the db schema.
+func roundTrip(db *dbx.DB, m *Migration) error {
+	before, err := schemaSnapshot(db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(db); err != nil {
+		return fmt.Errorf("Failed to apply the up section: %w", err)
+	}
+
+	if err := m.Down(db); err != nil {
+		return fmt.Errorf("Failed to apply the down section: %w", err)
+	}
+
+	after, err := schemaSnapshot(db)
+	if err != nil {
+		return err
+	}
+
+	if diff := schemaDiff(before, after); len(diff) > 0 {
+		// best effort reapply so that the next migrations
+		// could still be checked against the schema they expect
+		m.Up(db)
+
+		return fmt.Errorf("The down section didn't restore the schema: %s", strings.Join(diff, ", "))
+	}
+
+	if err := m.Up(db); err != nil {
+		return fmt.Errorf("Failed to reapply the up section: %w", err)
+	}
+
+	return nil
+}
+
+// schemaSnapshot returns the SQLite schema objects definitions
+// keyed by their type and name (eg. "table posts").
+func schemaSnapshot(db dbx.Builder) (map[string]string, error) {
+	rows := []struct {
+		Type string         `db:"type"`
+		Name string         `db:"name"`
+		SQL  sql.NullString `db:"sql"`
+	}{}
+
+	err := db.NewQuery("SELECT [[type]], [[name]], [[sql]] FROM sqlite_master WHERE [[name]] NOT LIKE 'sqlite_%'").All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(rows))
+	for _, row := range rows {
+		result[row.Type+" "+row.Name] = row.SQL.String
+	}
+
+	return result, nil
+}
+
+// schemaDiff returns the sorted list with the differences between
+// the before and after schema snapshots, prefixed with "+" for the
+// left over, "-" for the missing and "~" for the changed objects.
+func schemaDiff(before map[string]string, after map[string]string) []string {
+	diff := []string{}
+
+	for object, definition := range after {
+		previous, ok := before[object]
+		if !ok {
+			diff = append(diff, "+"+object)
+		} else if previous != definition {
+			diff = append(diff, "~"+object)
+		}
+	}
+
+	for object := range before {
+		if _, ok := after[object]; !ok {
+			diff = append(diff, "-"+object)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][1:] < diff[j][1:]
+	})
+
+	return diff
+}
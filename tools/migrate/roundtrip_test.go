@@ -0,0 +1,113 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerCheckSQLRoundTrip(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.RegisterSQL("1_posts.sql", "-- +up\nCREATE TABLE posts (id INTEGER);\n-- +down\nDROP TABLE posts;")
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "2_go.go")
+	l.RegisterSQL("3_no_down.sql", "-- +up\nCREATE TABLE tags (id INTEGER);")
+	l.RegisterSQL("4_leftover.sql", "-- +up\nCREATE TABLE users (id INTEGER);\nCREATE INDEX idx_posts ON posts (id);\n-- +down\nDROP TABLE users;")
+	l.RegisterSQL("5_changed.sql", "-- +up\nALTER TABLE tags ADD COLUMN title TEXT;\n-- +down\nSELECT 1;")
+	l.RegisterSQL("6_invalid.sql", "-- +up\nCREATE TABLE missing_ref AS SELECT * FROM missing;\n-- +down\nDROP TABLE missing_ref;")
+	l.RegisterSQL("7_ok.sql", "-- +up\nCREATE INDEX idx_tags ON tags (id);\n-- +down\nDROP INDEX idx_tags;")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := r.CheckSQLRoundTrip()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		migration string
+		err       string
+	}{
+		{"1_posts.sql", ""},
+		{"4_leftover.sql", "+index idx_posts"},
+		{"5_changed.sql", "~table tags"},
+		{"6_invalid.sql", "Failed to apply the up section"},
+		{"7_ok.sql", ""},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %v", len(expected), results)
+	}
+
+	for i, s := range expected {
+		result := results[i]
+
+		if result.Migration != s.migration {
+			t.Errorf("(%d) Expected migration %q, got %q", i, s.migration, result.Migration)
+		}
+
+		if s.err == "" {
+			if result.Error != nil {
+				t.Errorf("(%d) Expected nil error, got %v", i, result.Error)
+			}
+			continue
+		}
+
+		if result.Error == nil || !strings.Contains(result.Error.Error(), s.err) {
+			t.Errorf("(%d) Expected error containing %q, got %v", i, s.err, result.Error)
+		}
+	}
+
+	// the runner db is not modified
+	var total int
+	if err := testDB.NewQuery("SELECT COUNT(*) FROM sqlite_master WHERE name IN ('posts', 'tags', 'users')").Row(&total); err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Fatalf("Expected no scratch tables in the runner db, got %d", total)
+	}
+
+}
+
+func TestRunnerRunPreflightSQLRoundTrip(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.RegisterSQL("1_posts.sql", "-- +up\nCREATE TABLE posts (id INTEGER);\n-- +down\nDROP TABLE posts;")
+	l.RegisterSQL("2_leftover.sql", "-- +up\nCREATE TABLE users (id INTEGER);\n-- +down\nSELECT 1;")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Run("preflight", "--quiet")
+	if err == nil || !strings.Contains(err.Error(), "SQL migrations doesn't reverse their up section: 2_leftover.sql") {
+		t.Fatalf("Expected round trip error, got %v", err)
+	}
+
+	l2 := MigrationsList{}
+	l2.RegisterSQL("1_posts.sql", "-- +up\nCREATE TABLE posts (id INTEGER);\n-- +down\nDROP TABLE posts;")
+
+	r2, err := NewRunner(testDB.DB, l2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r2.Run("preflight", "--quiet"); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+)
+
+// RunOne executes only the up or down func (based on direction)
+// of the specified default set migration file and records its new state.
+//
+// This is intended to be used as a debugging tool while authoring
+// a migration and it is not a replacement for the ordered Up and Down.
+//
+// By default all previous migrations in the list must be already
+// applied. Set isolated to true to skip the prerequisites check
+// (eg. when running the migration against an empty scratch db).
+func (r *Runner) RunOne(file string, direction string, isolated bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrClosed
+	}
+
+	if r.ReadOnly {
+		return ErrReadOnly
+	}
+
+	r.startRun()
+
+	if direction != DirectionUp && direction != DirectionDown {
+		return fmt.Errorf("Invalid direction %q (expected %q or %q)", direction, DirectionUp, DirectionDown)
+	}
+
+	var target *Migration
+	prerequisites := []string{}
+	for _, m := range r.migrationsList.Items() {
+		if m.File == file {
+			target = m
+			break
+		}
+		prerequisites = append(prerequisites, m.File)
+	}
+	if target == nil {
+		return fmt.Errorf("Missing migration %s", file)
+	}
+
+	if !isolated {
+		missing := []string{}
+		for _, p := range prerequisites {
+			if !r.isMigrationApplied(r.db, "", p) {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("Migration %s requires the following unapplied migrations: %s", file, strings.Join(missing, ", "))
+		}
+	}
+
+	return r.runGrouped([]*Migration{target}, false, func(db dbx.Builder, m *Migration) error {
+		applied := r.isMigrationApplied(db, m.set, m.File)
+
+		if direction == DirectionUp {
+			if applied {
+				return fmt.Errorf("Migration %s is already applied", m.File)
+			}
+
+			if err := m.validate(db); err != nil {
+				return err
+			}
+
+			if err := r.migrationUp(db, m); err != nil {
+				return r.mapError(m.File, DirectionUp, fmt.Errorf("Failed to apply migration %s: %w", m.File, err))
+			}
+
+			return r.saveAppliedMigration(db, m.set, m.File)
+		}
+
+		if !applied {
+			return fmt.Errorf("Migration %s is not applied", m.File)
+		}
+
+		if err := r.migrationDown(db, m); err != nil {
+			return r.mapError(m.File, DirectionDown, fmt.Errorf("Failed to revert migration %s: %w", m.File, err))
+		}
+
+		return r.saveRevertedMigration(db, m.set, m.File)
+	}, nil)
+}
@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerRunOne(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := []string{}
+	track := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	l := MigrationsList{}
+	l.Register(track("1_up"), track("1_down"), "1_test")
+	l.Register(track("2_up"), track("2_down"), "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		file        string
+		direction   string
+		isolated    bool
+		expectError bool
+	}{
+		{"missing", DirectionUp, false, true},
+		{"1_test", "invalid", false, true},
+		{"2_test", DirectionUp, false, true},  // 1_test is not applied
+		{"2_test", DirectionDown, true, true}, // not applied
+		{"2_test", DirectionUp, true, false},
+		{"2_test", DirectionUp, true, true}, // already applied
+		{"1_test", DirectionUp, false, false},
+		{"1_test", DirectionDown, false, false},
+	}
+
+	for i, s := range scenarios {
+		err := r.RunOne(s.file, s.direction, s.isolated)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Fatalf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+
+	expectedCalls := []string{"2_up", "1_up", "1_down"}
+	if len(calls) != len(expectedCalls) {
+		t.Fatalf("Expected calls %v, got %v", expectedCalls, calls)
+	}
+	for i, c := range expectedCalls {
+		if calls[i] != c {
+			t.Fatalf("Expected calls %v, got %v", expectedCalls, calls)
+		}
+	}
+
+	if r.isMigrationApplied(testDB, "", "1_test") || !r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected only 2_test to be applied")
+	}
+}
@@ -1,86 +1,809 @@
 package migrate
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/tools/inflector"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/security"
 	"github.com/spf13/cast"
 )
 
 const migrationsTable = "_migrations"
 
+// ErrReadOnly is returned when trying to modify
+// the database with a ReadOnly runner.
+var ErrReadOnly = errors.New("The migrations runner is in read-only mode")
+
+// ErrIncompatibleMigrationsTable is returned by NewRunner when the existing
+// migrations table is missing columns that the runner expects and
+// it couldn't be (or it is not allowed to be, eg. in ReadOnly mode) upgraded.
+var ErrIncompatibleMigrationsTable = errors.New("The migrations table schema is incompatible with the current migrations runner")
+
+// requiredMigrationsTableColumns lists the migrations table
+// columns that couldn't be added by upgradeMigrationsTable.
+var requiredMigrationsTableColumns = []string{"file", "applied"}
+
+// List with the supported migration directions.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+type migrationsTableColumn struct {
+	name        string
+	kind        string // one of the columnKind* constants
+	constraints string
+}
+
+// definition returns the column definition for the specified dialect.
+func (c migrationsTableColumn) definition(d Dialect) string {
+	return columnType(d, c.kind) + " " + c.constraints
+}
+
+// tableNameRegex defines the allowed migrations table name format
+// (optionally schema-qualified, eg. "_migrations" or "meta._migrations").
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)?$`)
+
+// legacyTimestampThreshold is the max `applied` value that is considered
+// to be a legacy unix timestamp in seconds rather than in nanoseconds.
+const legacyTimestampThreshold int64 = 1e12
+
+// optionalMigrationsTableColumns lists the migrations table columns
+// introduced after the initial `file` and `applied` ones.
+var optionalMigrationsTableColumns = []migrationsTableColumn{
+	{"reverted", columnKindInteger, "DEFAULT 0 NOT NULL"},
+	{"applied_by", columnKindText, "DEFAULT '' NOT NULL"},
+	{"set", columnKindKey, "DEFAULT '' NOT NULL"},
+	{"run_id", columnKindText, "DEFAULT '' NOT NULL"},
+	{"confirmed", columnKindInteger, "DEFAULT 1 NOT NULL"},
+	{"log", columnKindText, "DEFAULT '' NOT NULL"},
+}
+
 // Runner defines a simple struct for managing the execution of db migrations.
 type Runner struct {
 	db             *dbx.DB
-	migrationsList MigrationsList
-	tableName      string
+	migrationsList Migrations // the snapshot of migrationsSource (see Refresh)
+	lastTimestamp  int64
+	sets           []migrationsSet
+	runId          string
+
+	// migrationsSource is the migrations list passed to NewRunner.
+	migrationsSource Migrations
+
+	// tx is the caller provided transaction of the current
+	// UpTx/DownTx run (nil for the regular runs).
+	tx *dbx.Tx
+
+	// pausable specifies whether the current run could be paused
+	// between its transactions (only for the regular Up runs).
+	pausable bool
+
+	// timings is the durations recorder of the current Up run
+	// (nil if Runner.TimingsWriter is not set).
+	timings *timingsRecorder
+
+	// objects is the created objects recorder of the current Up run
+	// (nil if Runner.TrackObjects is not set).
+	objects *objectsRecorder
+
+	// traceCtx is the context of the current Up/Down run span
+	// (nil if Runner.Tracer is not set).
+	traceCtx context.Context
+
+	// closed specifies whether Close was called.
+	closed bool
+
+	// mu serializes the concurrent migrations runs of the same Runner instance.
+	//
+	// Note that it protects only a single Runner instance and it doesn't
+	// prevent concurrent runs from other Runner instances or processes.
+	mu sync.Mutex
+
+	// ReadOnly specifies whether the runner should refuse any database
+	// modification (eg. Up, Down, RunOne) with ErrReadOnly, allowing
+	// only the inspection of the migrations state.
+	//
+	// In read-only mode the migrations table is not created or upgraded
+	// and NewRunner fails if it doesn't exist or it is outdated.
+	//
+	// NB! To take effect for the table initialization, it must be
+	// changed with one of the NewRunner optConfigure funcs.
+	ReadOnly bool
+
+	// SkipCreateTable specifies whether NewRunner should only verify the
+	// existing migrations table instead of creating or upgrading it
+	// (eg. for a least-privilege db user without CREATE and ALTER rights
+	// when the table is pre-provisioned by a privileged bootstrap).
+	//
+	// NewRunner fails if the table doesn't exist or it is outdated.
+	// Note that the other optional side tables (eg. of TrackObjects)
+	// are still created on demand.
+	//
+	// NB! To take effect for the table initialization, it must be
+	// changed with one of the NewRunner optConfigure funcs.
+	SkipCreateTable bool
+
+	// Dialect is the database specific tracking tables SQL
+	// (default to SQLiteDialect).
+	//
+	// NB! To take effect for the table initialization, it must be
+	// changed with one of the NewRunner optConfigure funcs.
+	Dialect Dialect
+
+	// TableName is the name of the migrations tracking table
+	// (default to "_migrations").
+	//
+	// It could be also schema-qualified (eg. "meta._migrations"),
+	// in which case each part is quoted separately.
+	//
+	// NB! To take effect for the table initialization, it must be
+	// changed with one of the NewRunner optConfigure funcs.
+	TableName string
+
+	// CreateTableSQL is an optional builder of the migrations table DDL.
+	//
+	// It receives the default "CREATE TABLE IF NOT EXISTS" statement and
+	// returns the statements to execute instead (eg. the same statement
+	// followed by a "CREATE INDEX IF NOT EXISTS" on the applied column,
+	// or a fully custom table definition).
+	//
+	// The statements are executed in a single transaction and the
+	// resulting table must have at least the "file" and "applied"
+	// columns (the other tracking columns are added automatically if
+	// missing), otherwise NewRunner fails with ErrIncompatibleMigrationsTable.
+	//
+	// NB! The statements are executed on every NewRunner call, so they
+	// must be idempotent, and the builder must be set with one of the
+	// NewRunner optConfigure funcs to take effect.
+	CreateTableSQL func(defaultSQL string) []string
+
+	// Confirm is used to ask the user for confirmation before
+	// executing the "down" and "create" commands.
+	//
+	// Defaults to an interactive terminal prompt.
+	Confirm func(message string) (bool, error)
+
+	// AutoConfirmCreate specifies whether to skip the "create" command
+	// confirmation (eg. for scripted scaffolding).
+	//
+	// The other commands still ask for confirmation. The confirmation
+	// of a single "create" run could be also skipped with "--no-confirm".
+	AutoConfirmCreate bool
+
+	// Dir is the directory with the migrations source files
+	// (used by the "create" and "rename" commands).
+	//
+	// If not set, fallbacks to the "migrations" folder in the current working directory.
+	Dir string
+
+	// MapError is an optional func that is invoked whenever a migration
+	// Up or Down func fails, allowing the error to be reclassified or
+	// wrapped before it is returned (direction is "up" or "down").
+	MapError func(file string, direction string, err error) error
+
+	// OnSkip is an optional func that is invoked whenever Up skips
+	// a migration because it is already applied (eg. to debug why an
+	// edited migration wasn't executed again).
+	//
+	// The file of the named sets migrations is prefixed with the set name.
+	// The "up --verbose" flag prints the skipped migrations.
+	OnSkip func(file string)
+
+	// OnEvent is an optional callback that is called with the Up run
+	// events - "run_started", the "started", "applied" and "failed" events
+	// of each migration and the final "committed", "failed" or "paused"
+	// event (see MigrationEvent and NewWebhook).
+	//
+	// The callback is called synchronously, so it must not block
+	// (eg. by delivering the events in a separate goroutine).
+	// The "up --webhook URL" flag posts the events to the URL.
+	OnEvent func(e MigrationEvent)
+
+	// Tracer is an optional Tracer for instrumenting the migrations runs
+	// (eg. an OpenTelemetry adapter).
+	//
+	// If set, Up and Down create a run span with a child span for
+	// each executed migration (with its file, direction and duration).
+	Tracer Tracer
+
+	// TargetVersion is an optional target migration version that limits
+	// Up to apply only the migrations up to and including it (see UpTo).
+	TargetVersion string
+
+	// RequireNonEmpty specifies whether Up should fail if the runner
+	// migrations list is empty (usually a sign of a wiring issue).
+	RequireNonEmpty bool
+
+	// StrictOrder specifies whether Up should fail if any of the pending
+	// migrations has an older timestamp prefix than the latest applied one
+	// (eg. a migration created in a branch that was merged later).
+	StrictOrder bool
+
+	// AllowOutOfOrder explicitly overrides the StrictOrder and GapsCheck checks.
+	AllowOutOfOrder bool
+
+	// IntegrityCheckBeforeUp specifies whether Up should run the SQLite
+	// "PRAGMA integrity_check" and "PRAGMA foreign_key_check" before
+	// applying any migration and abort with the reported problems
+	// (eg. for a db file that may have been improperly copied or restored).
+	IntegrityCheckBeforeUp bool
+
+	// HealthCheckIntegrity specifies whether HealthCheck should also run
+	// the (potentially slow for large db files) SQLite integrity checks.
+	HealthCheckIntegrity bool
+
+	// FloorOnDown specifies whether Down should record a rollback floor
+	// (the highest reverted migration of each set) and Up should not
+	// re-apply the migrations at or below it until Unfloor is called,
+	// preventing revert/re-apply loops of misconfigured auto deploys.
+	FloorOnDown bool
+
+	// PastFloor explicitly overrides the FloorOnDown rollback floor for Up.
+	PastFloor bool
+
+	// TimingsWriter is an optional writer for the Up migrations timings
+	// (eg. for profiling a slow migrations chain).
+	//
+	// The timings are written at the end of each Up run as tab separated
+	// "file", "statement" and "duration_us" values, with a row for each
+	// migration statement followed by a "(total)" row with the migration
+	// total duration. The statements executed with the caller provided
+	// UpTx transaction are not recorded (only the migrations totals).
+	TimingsWriter io.Writer
+
+	// DependencyOrder specifies whether the migrations should be ordered
+	// by their declared Migration.DependsOn instead of only by their file
+	// names (the file names order is preserved for the independent ones).
+	//
+	// Up and Down fail if there are missing or cyclic dependencies.
+	DependencyOrder bool
+
+	// UseCheckpoints specifies whether Up should start from the latest
+	// applied checkpoint migration of each set (see Migration.Checkpoint),
+	// without checking the state of the migrations before it.
+	//
+	// A fresh database is migrated only from the latest checkpoint
+	// forward and the migrations before it are never recorded. An existing
+	// database applies the remaining migrations before the checkpoint and
+	// records the checkpoint as applied without executing its Up func.
+	//
+	// Down could still revert past a checkpoint the applied migrations
+	// before it (eg. of a database created prior to the checkpoint), in
+	// which case the checkpoint is only marked as reverted without executing
+	// its Down func. On a database migrated from the checkpoint, reverting
+	// the checkpoint is the last possible step and its Down func is
+	// expected to revert the entire state.
+	UseCheckpoints bool
+
+	// GapsCheck specifies how Up should handle applied migrations that
+	// have unapplied earlier migrations in the list (eg. due to manual db edits).
+	//
+	// Supported values are GapsCheckOff (default), GapsCheckWarn and GapsCheckError.
+	GapsCheck string
+
+	// TrackingRetries is the max number of retries of the migrations
+	// tracking table queries when the table is temporarily locked
+	// by another connection or tool (default to DefaultTrackingRetries).
+	//
+	// The user defined migration funcs are never retried.
+	TrackingRetries int
+
+	// TrackingRetryDelay is the initial delay between the tracking
+	// table query retries, doubled after each attempt
+	// (default to DefaultTrackingRetryDelay).
+	TrackingRetryDelay time.Duration
+
+	// LockTimeout is the max time that Bootstrap waits for the
+	// migrations lock held by another process (default to DefaultLockTimeout).
+	LockTimeout time.Duration
+
+	// LockTTL is the max time without a refresh after which the
+	// migrations lock of another process (eg. a crashed one) is
+	// considered stale and taken over (default to DefaultLockTTL).
+	//
+	// The lock holder refreshes the lock every LockTTL/4, but note that
+	// with SQLite the refresh is blocked while a migrations transaction
+	// is running, so LockTTL should exceed the longest migration run.
+	LockTTL time.Duration
+
+	// Applier is an optional identifier of who/what applies the
+	// migrations (eg. hostname and build version) that is stored
+	// in the "applied_by" column of the applied migrations records.
+	Applier string
+
+	// PerMigrationTx specifies whether to execute each migration in
+	// its own transaction instead of a single transaction for all.
+	//
+	// This trades the all-or-nothing atomicity of the run in favor of
+	// keeping the successfully applied migrations if a later one fails.
+	PerMigrationTx bool
+
+	// CommitEvery specifies the max number of migrations executed in a
+	// single transaction, committing the applied migrations (and their
+	// records) every N migrations and starting a new transaction for
+	// the next batch (0 means no limit).
+	//
+	// Similar to PerMigrationTx, this trades the all-or-nothing atomicity
+	// of the run for crash resilience of long migration chains. Since each
+	// batch records its migrations in the same transaction, a failure
+	// (or a crash) mid-batch rolls back only the current batch and the
+	// migrations table reflects exactly the committed batches.
+	CommitEvery int
+
+	// PauseFile and Pause allow gracefully pausing a long Up run
+	// (eg. to let a maintenance window close).
+	//
+	// Before starting each new transaction, the run checks whether the
+	// PauseFile exists or whether a value was received on (or the closing
+	// of) the Pause channel and if so, it stops after the already committed
+	// transactions, returning the applied migrations and ErrPaused.
+	// The next Up call resumes with the remaining migrations.
+	//
+	// Since the run could be paused only between transactions, they
+	// take effect only with PerMigrationTx or CommitEvery.
+	// The "up --pause-file PATH" flag sets PauseFile.
+	PauseFile string
+	Pause     <-chan struct{}
+
+	// TrackObjects specifies whether to record the db objects (tables,
+	// columns, indexes, views and triggers) created by the statements of
+	// the applied migrations in a side table (see WhoCreated).
+	//
+	// Only the statements executed with the runner db are recorded
+	// (eg. not the ones routed to the Executor or executed in the
+	// UpTx caller provided transaction).
+	TrackObjects bool
+
+	// MaxLogLength is the max length (in bytes) of the migration log
+	// written with Logf that is stored in the applied record
+	// (default to DefaultMaxLogLength).
+	MaxLogLength int
+
+	// Tentative specifies whether to record the applied migrations as
+	// unconfirmed until they are explicitly confirmed with ConfirmApplied
+	// (eg. for two-phase deploys where the new schema is confirmed only
+	// after the application health check).
+	//
+	// The unconfirmed migrations are treated as applied (they are not
+	// executed again by the next Up) and are only flagged in the status.
+	// If the deploy has to be rolled back, the unconfirmed migrations
+	// could be reverted as any other applied migration with Down.
+	Tentative bool
+
+	// ContinueOnError specifies whether Up should continue with the next
+	// migrations after a failed one (must be used with PerMigrationTx).
+	//
+	// Only the failed migration transaction is rolled back and Up returns
+	// both the successfully applied migrations and a *MigrationsError
+	// listing all failures.
+	ContinueOnError bool
+
+	// KeepRevertedHistory specifies whether to keep the records of the
+	// reverted migrations (marked with a "reverted" timestamp) instead
+	// of deleting them.
+	//
+	// The reverted records are moved to the TableName + "History" table
+	// (eg. "_migrationsHistory"), so they are kept even after the
+	// migration is applied again.
+	KeepRevertedHistory bool
+
+	// Executor is an optional custom executor for the SQL migrations
+	// statements (eg. to run them through a database gateway).
+	//
+	// Go migrations and the migrations tracking records are always
+	// executed with the runner db, and because the statements routed to
+	// the Executor are not part of the runner db transaction, a failed
+	// run could leave the Executor changes applied without their record.
+	Executor Executor
+
+	// TemplateStyle specifies the migration template used by the "create"
+	// command (currently only TemplateStyleDBX, since the migrations are
+	// registered only with dbx.Builder callbacks).
+	//
+	// Defaults to TemplateStyleAuto, which resolves to TemplateStyleDBX.
+	TemplateStyle string
+
+	// FileExtension is the "create" migration file extension
+	// (default to DefaultFileExtension).
+	//
+	// ".go" and ".sql" have builtin templates. Any other extension
+	// requires a Templates entry.
+	//
+	// The "create --lang sql" flag overrides it for a single command.
+	FileExtension string
+
+	// Templates is an optional map with custom "create" migration
+	// templates keyed by their file extension (eg. ".ts").
+	Templates map[string]string
+
+	// AnnotateAuthor specifies whether the "create" command should prepend
+	// a comment header with the author identity and the creation time
+	// (in RFC3339) to the new migration file.
+	//
+	// The author is read from the GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL
+	// env variables, falling back to the git "user.name" and "user.email"
+	// config. The "create --author" flag enables it for a single command.
+	AnnotateAuthor bool
+
+	// IndexFile is an optional path of an index/registry file that the
+	// "create" command updates with an entry for each new migration
+	// (could be also set for a single run with "--index PATH").
+	//
+	// By default the entry is a blank import of the migrations package
+	// (resolved based on the nearest go.mod). The entries are added only
+	// once and inserted before the IndexMarker line (if any).
+	IndexFile string
+
+	// IndexEntry is an optional func that returns the IndexFile entry
+	// for the new migration file (eg. an explicit registration call).
+	IndexEntry func(file string) string
+
+	// NamePattern is an optional migration file name convention
+	// (eg. `^\d{14}_[a-z0-9_]+\.go$`) that the "create" and "rename"
+	// commands validate the new file names against.
+	//
+	// If set, NewRunner and RegisterSet also reject the registered
+	// migrations that don't match it (the namespace prefix is ignored).
+	//
+	// Defaults to the "1656512345_migration_name.go" convention
+	// (with the FileExtension), used only for the new file names.
+	//
+	// NB! To take effect for the registered migrations, it must be
+	// changed with one of the NewRunner optConfigure funcs.
+	NamePattern *regexp.Regexp
+
+	// Quiet specifies whether to suppress the Run success messages
+	// (eg. the applied and reverted migrations lines).
+	//
+	// The errors and warnings are still printed.
+	Quiet bool
+
+	// ProgressEvery and ProgressInterval collapse the "up" command
+	// per migration output into periodic "Applied N/TOTAL..." lines,
+	// printed after every ProgressEvery applied migrations or when
+	// ProgressInterval has elapsed since the last line.
+	//
+	// The failed migrations are still printed individually, followed
+	// by a final summary line. Both are disabled by default and the
+	// "up --progress" flag enables them with the Default* values.
+	ProgressEvery    int
+	ProgressInterval time.Duration
+
+	// TimeFormat is the layout used to format the timestamps in the
+	// human readable commands output (default to DefaultTimeFormat).
+	//
+	// The machine readable output (eg. export-plan) always
+	// contains the raw unix timestamps.
+	TimeFormat string
+
+	// Sets is the names of the registered sets (see RegisterSet) whose
+	// migrations to run in addition to the default set.
+	//
+	// If empty, the migrations of all registered sets are run.
+	Sets []string
+
+	// TimeLocation is the location used to format the timestamps in the
+	// human readable commands output (default to time.UTC).
+	TimeLocation *time.Location
+
+	// Now is an optional clock func used for the "create" file names
+	// timestamps and the migrations records times (default to time.Now).
+	//
+	// It is intended mainly for the tests that need deterministic
+	// generated file names.
+	Now func() time.Time
 }
 
 // NewRunner creates and initializes a new db migrations Runner instance.
-func NewRunner(db *dbx.DB, migrationsList MigrationsList) (*Runner, error) {
+//
+// The optional optConfigure funcs are invoked before the migrations
+// table initialization and could be used to change the runner defaults, eg.:
+//
+//	migrate.NewRunner(db, list, func(r *migrate.Runner) {
+//		r.TableName = "meta._migrations"
+//	})
+//
+// The migrations could be a MigrationsList or any other
+// custom Migrations implementation (eg. a remote registry).
+//
+// The runner works with a snapshot of the migrations list taken at its
+// creation, so the migrations registered to the list afterwards (eg.
+// by a lazily loaded plugin) are not visible until Refresh is called.
+// Note that a MigrationsList passed by value is a fixed copy that
+// Refresh cannot observe - pass a *MigrationsList for late registrations.
+func NewRunner(db *dbx.DB, migrationsList Migrations, optConfigure ...func(r *Runner)) (*Runner, error) {
+	switch l := migrationsList.(type) {
+	case nil:
+		migrationsList = &MigrationsList{}
+	case MigrationsList:
+		// detach from the caller list backing array
+		// (its later registrations could reorder it in place)
+		migrationsList = snapshotMigrations(l)
+	}
+
 	runner := &Runner{
-		db:             db,
-		migrationsList: migrationsList,
-		tableName:      migrationsTable,
+		db:               db,
+		migrationsSource: migrationsList,
+		migrationsList:   snapshotMigrations(migrationsList),
+		TableName:        migrationsTable,
+		Confirm:          surveyConfirm,
+		Dialect:          SQLiteDialect{},
+
+		TrackingRetries:    DefaultTrackingRetries,
+		TrackingRetryDelay: DefaultTrackingRetryDelay,
+		LockTimeout:        DefaultLockTimeout,
+		LockTTL:            DefaultLockTTL,
+	}
+
+	for _, configure := range optConfigure {
+		configure(runner)
+	}
+
+	if runner.Dialect == nil {
+		runner.Dialect = SQLiteDialect{}
+	}
+
+	if !tableNameRegex.MatchString(runner.TableName) {
+		return nil, fmt.Errorf("Invalid migrations table name %q", runner.TableName)
+	}
+
+	if err := runner.checkRegisteredNames(runner.migrationsList); err != nil {
+		return nil, err
 	}
 
-	if err := runner.createMigrationsTable(); err != nil {
+	if runner.ReadOnly || runner.SkipCreateTable {
+		if err := runner.withTrackingRetry(runner.verifyMigrationsTable); err != nil {
+			return nil, err
+		}
+
+		if runner.ReadOnly {
+			return runner, nil
+		}
+	} else if err := runner.withTrackingRetry(runner.createMigrationsTable); err != nil {
+		return nil, err
+	}
+
+	if err := runner.withTrackingRetry(runner.upgradeMigrationsTable); err != nil {
 		return nil, err
 	}
 
 	return runner, nil
 }
 
+// DB returns the runner db instance.
+func (r *Runner) DB() *dbx.DB {
+	return r.db
+}
+
 // Run interactively executes the current runner with the provided args.
 //
 // The following commands are supported:
-// - up                        - applies all migrations
-// - down [n]                  - reverts the last n applied migrations
-// - create NEW_MIGRATION_NAME - create NEW_MIGRATION_NAME.go file from a migration template
+// - up [--allow-out-of-order] [--backup PATH] [--dry-run] [--pause-file PATH] [--progress] [--verbose] [--webhook URL] - applies all migrations (optionally creating a db backup first)
+// - down [n] [--count N] [--dry-run] - reverts the last n applied migrations
+// - create NEW_MIGRATION_NAME [DIR] [--author] [--dir DIR] [--lang go|sql] [--stdout] - create NEW_MIGRATION_NAME.go file from a migration template (or prints it)
+// - mark-release TAG          - records a release marker with the specified TAG
+// - rename [SET/]OLD_FILE NEW_FILE - renames an applied migration (both its record and source file)
+// - run-one FILE [up|down] [--isolated] - executes only the specified migration
+// - export-plan               - prints the pending SQL migrations as a single SQL script
+// - list [--since DURATION]   - prints all migrations grouped by their applied status (or only the recently applied ones)
+// - version                   - prints the applied migrations schema version digest
+// - since-last [--seconds]    - prints the elapsed time since the last migration was applied
+// - backup PATH               - creates a snapshot of the SQLite db file at PATH
+// - prune DURATION|DATE       - deletes the reverted history records older than the cutoff
+//
+// All commands also accept the "--set NAME1,NAME2" flag to
+// run only the specified named sets (see RegisterSet), the "--quiet"
+// flag to suppress the success messages and the "--yes" flag to skip
+// the confirmation prompts (the args are parsed with ParseRunArgs).
 func (r *Runner) Run(args ...string) error {
-	cmd := "up"
-	if len(args) > 0 {
-		cmd = args[0]
+	a, err := ParseRunArgs(args...)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Sets) > 0 {
+		defer func(original []string) { r.Sets = original }(r.Sets)
+		r.Sets = a.Sets
+	}
+
+	if a.Has("--quiet") {
+		defer func(original bool) { r.Quiet = original }(r.Quiet)
+		r.Quiet = true
+	}
+
+	if a.Yes {
+		defer func(original func(message string) (bool, error)) { r.Confirm = original }(r.Confirm)
+		r.Confirm = func(message string) (bool, error) {
+			return true, nil
+		}
 	}
 
-	switch cmd {
+	switch a.Command {
 	case "up":
+		if a.DryRun {
+			return r.printDryRun(a)
+		}
+
+		if a.Has("--allow-out-of-order") {
+			defer func(original bool) { r.AllowOutOfOrder = original }(r.AllowOutOfOrder)
+			r.AllowOutOfOrder = true
+		}
+
+		if a.Has("--past-floor") {
+			defer func(original bool) { r.PastFloor = original }(r.PastFloor)
+			r.PastFloor = true
+		}
+
+		if a.Has("--verbose") {
+			defer func(original func(file string)) { r.OnSkip = original }(r.OnSkip)
+			onSkip := r.OnSkip
+			r.OnSkip = func(file string) {
+				fmt.Printf("Skipping already-applied %s\n", file)
+				if onSkip != nil {
+					onSkip(file)
+				}
+			}
+		}
+
+		if timingsPath, ok := a.Value("--timings"); ok {
+			if timingsPath == "" {
+				return fmt.Errorf("Missing --timings file path")
+			}
+
+			f, err := os.Create(timingsPath)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			defer func(original io.Writer) { r.TimingsWriter = original }(r.TimingsWriter)
+			r.TimingsWriter = f
+		}
+
+		if backupPath, ok := a.Value("--backup"); ok {
+			done, err := r.backupWithConfirm(backupPath)
+			if err != nil {
+				color.Red(err.Error())
+				return err
+			}
+			if !done {
+				fmt.Println("The command has been cancelled")
+				return nil
+			}
+			r.printSuccess("Successfully created backup %q", backupPath)
+		}
+
+		if pauseFile, ok := a.Value("--pause-file"); ok {
+			if pauseFile == "" {
+				return fmt.Errorf("Missing --pause-file path")
+			}
+
+			defer func(original string) { r.PauseFile = original }(r.PauseFile)
+			r.PauseFile = pauseFile
+		}
+
+		if webhookURL, ok := a.Value("--webhook"); ok {
+			if webhookURL == "" {
+				return fmt.Errorf("Missing --webhook URL")
+			}
+
+			webhook := NewWebhook(webhookURL)
+			defer func() {
+				if err := webhook.Close(); err != nil {
+					color.Yellow(err.Error())
+				}
+			}()
+
+			defer func(original func(e MigrationEvent)) { r.OnEvent = original }(r.OnEvent)
+			onEvent := r.OnEvent
+			r.OnEvent = func(e MigrationEvent) {
+				webhook.OnEvent(e)
+				if onEvent != nil {
+					onEvent(e)
+				}
+			}
+		}
+
+		if a.Has("--progress") && !r.progressEnabled() {
+			defer func(every int, interval time.Duration) {
+				r.ProgressEvery = every
+				r.ProgressInterval = interval
+			}(r.ProgressEvery, r.ProgressInterval)
+			r.ProgressEvery = DefaultProgressEvery
+			r.ProgressInterval = DefaultProgressInterval
+		}
+
+		if r.progressEnabled() {
+			pending, err := r.PendingMigrations()
+			if err != nil {
+				color.Red(err.Error())
+				return err
+			}
+
+			progress := newProgressPrinter(r, len(pending))
+
+			applied, err := r.up(nil, "", progress.notify)
+			if err != nil && !errors.Is(err, ErrPaused) {
+				color.Red(err.Error())
+				return err
+			}
+
+			progress.summary(applied)
+
+			if err != nil {
+				color.Yellow("%s (run \"up\" again to resume).", err.Error())
+			}
+
+			return nil
+		}
+
 		applied, err := r.Up()
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrPaused) {
 			color.Red(err.Error())
 			return err
 		}
 
+		if err != nil {
+			for _, file := range applied {
+				r.printSuccess("Applied %s (run %s)", file, r.LastRunId())
+			}
+			color.Yellow("%s (run \"up\" again to resume).", err.Error())
+
+			return nil
+		}
+
 		if len(applied) == 0 {
-			color.Green("No new migrations to apply.")
+			r.printSuccess("No new migrations to apply.")
 		} else {
 			for _, file := range applied {
-				color.Green("Applied %s", file)
+				r.printSuccess("Applied %s (run %s)", file, r.LastRunId())
 			}
 		}
 
 		return nil
 	case "down":
-		toRevertCount := 1
-		if len(args) > 1 {
-			toRevertCount = cast.ToInt(args[1])
-			if toRevertCount < 0 {
-				// revert all applied migrations
-				toRevertCount = len(r.migrationsList.Items())
-			}
+		toRevertCount, err := a.revertCount()
+		if err != nil {
+			return err
+		}
+
+		if a.DryRun {
+			return r.printDryRun(a)
 		}
 
-		confirm := false
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("Do you really want to revert the last %d applied migration(s)?", toRevertCount),
+		appliedCount := r.appliedCount()
+		if appliedCount == 0 {
+			r.printSuccess("No migrations to revert.")
+			return nil
+		}
+
+		if toRevertCount < 0 {
+			// revert all applied migrations
+			toRevertCount = appliedCount
+		}
+
+		if toRevertCount > appliedCount {
+			color.Yellow("Requested %d migration(s) to revert, but only %d are applied.", toRevertCount, appliedCount)
+			toRevertCount = appliedCount
+		}
+
+		confirm, err := r.Confirm(fmt.Sprintf("Do you really want to revert the last %d applied migration(s)?", toRevertCount))
+		if err != nil {
+			return err
 		}
-		survey.AskOne(prompt, &confirm)
 		if !confirm {
 			fmt.Println("The command has been cancelled")
 			return nil
@@ -88,184 +811,1388 @@ func (r *Runner) Run(args ...string) error {
 
 		reverted, err := r.Down(toRevertCount)
 		if err != nil {
+			// the committed reverts are not rolled back with the failed one
+			for _, file := range reverted {
+				r.printSuccess("Reverted %s (run %s)", file, r.LastRunId())
+			}
 			color.Red(err.Error())
 			return err
 		}
 
 		if len(reverted) == 0 {
-			color.Green("No migrations to revert.")
+			r.printSuccess("No migrations to revert.")
 		} else {
 			for _, file := range reverted {
-				color.Green("Reverted %s", file)
+				r.printSuccess("Reverted %s (run %s)", file, r.LastRunId())
 			}
 		}
 
 		return nil
 	case "create":
-		if len(args) < 2 {
+		if r.ReadOnly {
+			return ErrReadOnly
+		}
+
+		toStdout := a.Has("--stdout")
+
+		if indexFile, ok := a.Value("--index"); ok {
+			if indexFile == "" {
+				return fmt.Errorf("Missing --index file path")
+			}
+
+			defer func(original string) { r.IndexFile = original }(r.IndexFile)
+			r.IndexFile = indexFile
+		}
+
+		if lang, ok := a.Value("--lang"); ok {
+			if lang == "" {
+				return fmt.Errorf("Missing --lang value (eg. go or sql)")
+			}
+
+			defer func(original string) { r.FileExtension = original }(r.FileExtension)
+			r.FileExtension = "." + strings.TrimPrefix(lang, ".")
+		}
+
+		collection, hasCollection := a.Value("--collection")
+		if hasCollection && collection == "" {
+			return fmt.Errorf("Missing --collection name")
+		}
+
+		var name string
+		switch {
+		case a.Arg(0) != "":
+			name = a.Arg(0)
+		case collection != "":
+			name = "update_" + collection
+		default:
 			return fmt.Errorf("Missing migration file name")
 		}
 
-		name := args[1]
+		if err := r.checkFileExtension(collection); err != nil {
+			return err
+		}
+
+		if err := r.checkTemplateStyle(); err != nil {
+			return err
+		}
 
-		var dir string
-		if len(args) == 3 {
-			dir = args[2]
+		dir := a.Arg(1)
+		if dir == "" {
+			dir = a.Dir
 		}
 		if dir == "" {
-			// If not specified, auto point to the default migrations folder.
-			//
-			// NB!
-			// Since the create command makes sense only during development,
-			// it is expected the user to be in the app working directory
-			// and to be using `go run ...`
-			wd, err := os.Getwd()
-			if err != nil {
+			var err error
+			if dir, err = r.migrationsDir(); err != nil {
 				return err
 			}
-			dir = path.Join(wd, "migrations")
 		}
 
-		resultFilePath := path.Join(
-			dir,
-			fmt.Sprintf("%d_%s.go", time.Now().Unix(), inflector.Snakecase(name)),
-		)
+		resultFile := fmt.Sprintf("%d_%s%s", r.createTimestamp(dir), inflector.Snakecase(name), r.fileExtension())
+		if err := r.checkFileName(resultFile); err != nil {
+			return err
+		}
 
-		confirm := false
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("Do you really want to create migration %q?", resultFilePath),
+		resultFilePath := path.Join(dir, resultFile)
+
+		content := r.templateContent(dir, collection)
+		if r.AnnotateAuthor || a.Has("--author") {
+			content = r.authorHeader(dir) + content
 		}
-		survey.AskOne(prompt, &confirm)
-		if !confirm {
-			fmt.Println("The command has been cancelled")
+		if r.fileExtension() == ".sql" {
+			if err := checkSQLTemplate(content); err != nil {
+				return err
+			}
+		}
+
+		// preview only
+		if toStdout {
+			fmt.Print(r.templateHeader(resultFilePath) + content)
 			return nil
 		}
 
+		if !r.AutoConfirmCreate && !a.Has("--no-confirm") {
+			confirm, err := r.Confirm(fmt.Sprintf("Do you really want to create migration %q?", resultFilePath))
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				fmt.Println("The command has been cancelled")
+				return nil
+			}
+		}
+
 		// ensure that migrations dir exist
 		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 			return err
 		}
 
-		if err := os.WriteFile(resultFilePath, []byte(createTemplateContent), 0644); err != nil {
+		if err := os.WriteFile(resultFilePath, []byte(content), 0644); err != nil {
 			return fmt.Errorf("Failed to save migration file %q\n", resultFilePath)
 		}
 
-		fmt.Printf("Successfully created file %q\n", resultFilePath)
-		return nil
-	default:
-		return fmt.Errorf("Unsupported command: %q\n", cmd)
-	}
-}
-
-// Up executes all unapplied migrations for the provided runner.
-//
-// On success returns list with the applied migrations file names.
-func (r *Runner) Up() ([]string, error) {
-	applied := []string{}
+		r.printSuccess("Successfully created file %q", resultFilePath)
 
-	err := r.db.Transactional(func(tx *dbx.Tx) error {
-		for _, m := range r.migrationsList.Items() {
-			// skip applied
-			if r.isMigrationApplied(tx, m.file) {
-				continue
+		if r.IndexFile != "" {
+			updated, err := r.updateIndex(resultFilePath)
+			if err != nil {
+				color.Red(err.Error())
+				return err
 			}
-
-			if err := m.up(tx); err != nil {
-				return fmt.Errorf("Failed to apply migration %s: %w", m.file, err)
+			if updated {
+				r.printSuccess("Successfully updated index file %q", r.IndexFile)
 			}
+		}
 
-			if err := r.saveAppliedMigration(tx, m.file); err != nil {
-				return fmt.Errorf("Failed to save applied migration info for %s: %w", m.file, err)
-			}
+		return nil
+	case "mark-release":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing release tag")
+		}
 
-			applied = append(applied, m.file)
+		if err := r.MarkRelease(a.Arg(0)); err != nil {
+			color.Red(err.Error())
+			return err
 		}
 
+		r.printSuccess("Successfully marked release %q", a.Arg(0))
 		return nil
-	})
+	case "rename":
+		if len(a.Positional) < 2 {
+			return fmt.Errorf("Missing old or new migration file name")
+		}
 
-	if err != nil {
-		return nil, err
-	}
-	return applied, nil
-}
+		if err := r.Rename(a.Arg(0), a.Arg(1)); err != nil {
+			color.Red(err.Error())
+			return err
+		}
 
-// Down reverts the last `toRevertCount` applied migrations.
-//
-// On success returns list with the reverted migrations file names.
-func (r *Runner) Down(toRevertCount int) ([]string, error) {
-	applied := []string{}
+		r.printSuccess("Successfully renamed %s to %s", a.Arg(0), a.Arg(1))
+		return nil
+	case "run-one":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing migration file name")
+		}
 
-	err := r.db.Transactional(func(tx *dbx.Tx) error {
-		totalReverted := 0
+		direction := DirectionUp
+		if a.Arg(1) != "" {
+			direction = a.Arg(1)
+		}
 
-		for i := len(r.migrationsList.Items()) - 1; i >= 0; i-- {
-			m := r.migrationsList.Item(i)
+		if err := r.RunOne(a.Arg(0), direction, a.Has("--isolated")); err != nil {
+			color.Red(err.Error())
+			return err
+		}
 
-			// skip unapplied
-			if !r.isMigrationApplied(tx, m.file) {
-				continue
+		r.printSuccess("Successfully executed %s %s", a.Arg(0), direction)
+		return nil
+	case "export-plan":
+		return r.ExportPlan(os.Stdout)
+	case "explain":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing migration file name")
+		}
+
+		if err := r.writeExplain(os.Stdout, a.Arg(0)); err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		return nil
+	case "list":
+		if value, ok := a.Value("--since"); ok {
+			d, err := parseSinceDuration(value)
+			if err != nil {
+				return err
+			}
+
+			return r.printAppliedSince(color.Output, d)
+		}
+
+		return r.printTree(color.Output)
+	case "pending":
+		return r.runPendingCommand(a)
+	case "confirm":
+		confirmed, err := r.ConfirmApplied(a.Positional...)
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		r.printSuccess("Successfully confirmed %d migration(s)", confirmed)
+		return nil
+	case "resume":
+		return r.runResumeCommand(a)
+	case "adopt":
+		return r.runAdoptCommand()
+	case "diff":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing the other database dsn")
+		}
+
+		if err := r.writeDiff(os.Stdout, a.Arg(0)); err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		return nil
+	case "unfloor":
+		if err := r.Unfloor(); err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		r.printSuccess("Successfully removed the rollback floor")
+		return nil
+	case "preflight":
+		if err := r.PreflightReversible(); err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		if err := r.preflightSQLRoundTrip(); err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		r.printSuccess("All pending migrations are reversible.")
+		return nil
+	case "backup":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing backup destination path")
+		}
+
+		done, err := r.backupWithConfirm(a.Arg(0))
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+		if !done {
+			fmt.Println("The command has been cancelled")
+			return nil
+		}
+
+		r.printSuccess("Successfully created backup %q", a.Arg(0))
+		return nil
+	case "prune":
+		if len(a.Positional) < 1 {
+			return fmt.Errorf("Missing prune cutoff duration or date")
+		}
+
+		before, err := r.parsePruneCutoff(a.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		total, err := r.prunableCount(before)
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		if total == 0 {
+			r.printSuccess("No history records to prune.")
+			return nil
+		}
+
+		confirm, err := r.Confirm(fmt.Sprintf("Do you really want to prune %d reverted history record(s)?", total))
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			fmt.Println("The command has been cancelled")
+			return nil
+		}
+
+		pruned, err := r.PruneHistory(before)
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		r.printSuccess("Successfully pruned %d history record(s)", pruned)
+		return nil
+	case "version":
+		version, err := r.SchemaVersion()
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		fmt.Println(version)
+		return nil
+	case "since-last":
+		elapsed, err := r.TimeSinceLastApplied()
+		if err != nil {
+			color.Red(err.Error())
+			return err
+		}
+
+		if a.Has("--seconds") {
+			fmt.Println(int64(elapsed.Seconds()))
+		} else {
+			fmt.Println(formatElapsed(elapsed))
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unsupported command: %q\n", a.Command)
+	}
+}
+
+// parseRevertCount parses the optional "down" command revert count
+// (defaults to 1, negative to revert all applied migrations).
+func parseRevertCount(args []string) (int, error) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+
+		count, err := cast.ToIntE(arg)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid revert count %q (expected a number)", arg)
+		}
+		if count == 0 {
+			return 0, errors.New("Nothing to revert - use a positive count or -1 to revert all applied migrations")
+		}
+
+		return count, nil
+	}
+
+	return 1, nil
+}
+
+// Up executes all unapplied migrations for the provided runner.
+//
+// Concurrent Up and Down calls of the same Runner instance are serialized.
+//
+// On success returns list with the applied migrations file names.
+func (r *Runner) Up() ([]string, error) {
+	return r.up(nil, "", nil)
+}
+
+// UpTx executes all unapplied migrations within the provided
+// transaction instead of opening new one(s), allowing the caller to
+// compose the migrations with other changes in a single atomic unit.
+//
+// All migrations (including the NonTransactional ones) are executed
+// with tx and the changes are persisted only after the caller commits it.
+// ContinueOnError is not supported in this mode.
+//
+// On success returns list with the applied migrations file names.
+func (r *Runner) UpTx(tx *dbx.Tx) ([]string, error) {
+	return r.up(tx, "", nil)
+}
+
+// up executes all unapplied migrations (within the optional tx) up to
+// and including the optional target version (defaults to Runner.TargetVersion)
+// and reports the progress of each migration to the optional notify callback.
+func (r *Runner) up(tx *dbx.Tx, target string, notify func(e MigrationEvent)) (result []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if target == "" {
+		target = r.TargetVersion
+	}
+
+	if notify == nil {
+		notify = func(e MigrationEvent) {}
+	}
+
+	if r.closed {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: ErrClosed})
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: ErrReadOnly})
+		return nil, ErrReadOnly
+	}
+
+	r.tx = tx
+	defer func() { r.tx = nil }()
+
+	r.startRun()
+
+	finishSpan := r.startRunSpan(DirectionUp)
+	defer func() { finishSpan(len(result), err) }()
+
+	if r.TimingsWriter != nil {
+		finishTimings := r.startTimings()
+		defer func() {
+			if err := finishTimings(); err != nil {
+				color.Yellow("Failed to write the migrations timings: %v", err)
+			}
+		}()
+	}
+
+	if r.TrackObjects {
+		finishObjects, err := r.startObjectsTracking()
+		if err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+			return nil, err
+		}
+		defer finishObjects()
+	}
+
+	notifyFunc := notify
+	notify = func(e MigrationEvent) {
+		e.RunId = r.runId
+		notifyFunc(e)
+		if r.OnEvent != nil {
+			r.OnEvent(e)
+		}
+	}
+
+	if r.OnEvent != nil {
+		r.OnEvent(MigrationEvent{Type: MigrationEventRunStarted, RunId: r.runId})
+	}
+
+	items, err := r.orderedItems()
+	if err != nil {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if items, err = r.checkpointItems(items); err != nil {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if items, err = r.targetItems(items, target); err != nil {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if r.RequireNonEmpty && len(items) == 0 {
+		err := errors.New("The migrations list is empty - make sure that the migrations are registered")
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if r.ContinueOnError && !r.PerMigrationTx {
+		err := errors.New("ContinueOnError requires PerMigrationTx to be enabled")
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if r.ContinueOnError && tx != nil {
+		err := errors.New("ContinueOnError is not supported with a caller provided transaction")
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if err := r.checkSets(); err != nil {
+		notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+		return nil, err
+	}
+
+	if r.IntegrityCheckBeforeUp {
+		if err := r.checkIntegrity(); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+			return nil, err
+		}
+	}
+
+	if r.StrictOrder && !r.AllowOutOfOrder {
+		if err := r.checkOutOfOrder(); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+			return nil, err
+		}
+	}
+
+	r.checkClock()
+
+	r.checkTimestampCollisions()
+
+	if r.GapsCheck != GapsCheckOff && !r.AllowOutOfOrder {
+		if err := r.checkGaps(); err != nil {
+			if r.GapsCheck != GapsCheckWarn {
+				notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+				return nil, err
+			}
+			color.Yellow(err.Error())
+		}
+	}
+
+	if r.FloorOnDown && !r.PastFloor {
+		var skipped int
+		if items, skipped, err = r.excludeFloored(items); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, Error: err})
+			return nil, err
+		}
+
+		if skipped > 0 {
+			color.Yellow(
+				"Skipped %d migration(s) at or below the rollback floor "+
+					"(use \"up --past-floor\" or \"unfloor\" to apply them).",
+				skipped,
+			)
+		}
+	}
+
+	applied := []string{}
+
+	// the migrations of the current group that are reported
+	// as applied only after the group transaction is committed
+	pending := []string{}
+
+	r.pausable = true
+	defer func() { r.pausable = false }()
+
+	err = r.runGrouped(items, r.ContinueOnError, func(db dbx.Builder, m *Migration) error {
+		// skip applied
+		if r.isMigrationApplied(db, m.set, m.File) {
+			if r.OnSkip != nil {
+				r.OnSkip(migrationName(m))
+			}
+			return nil
+		}
+
+		notify(MigrationEvent{Type: MigrationEventStarted, File: m.File})
+
+		if err := m.validate(db); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, File: m.File, Error: err})
+			return err
+		}
+
+		var log string
+		err := r.traceMigration(m, DirectionUp, func() error {
+			return r.timings.measure(m, func() error {
+				return r.objects.capture(func() (err error) {
+					log, err = captureLog(db, func() error {
+						return r.migrationUp(db, m)
+					})
+					return err
+				})
+			})
+		})
+		if err != nil {
+			err = r.mapError(m.File, DirectionUp, fmt.Errorf("Failed to apply migration %s: %w", m.File, err))
+			notify(MigrationEvent{Type: MigrationEventFailed, File: m.File, Error: err})
+			return err
+		}
+
+		if err := r.saveAppliedMigration(db, m.set, m.File); err != nil {
+			err = fmt.Errorf("Failed to save applied migration info for %s: %w", m.File, err)
+			notify(MigrationEvent{Type: MigrationEventFailed, File: m.File, Error: err})
+			return err
+		}
+
+		if err := r.saveMigrationLog(db, m, log); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, File: m.File, Error: err})
+			return err
+		}
+
+		if err := r.saveCreatedObjects(db, m); err != nil {
+			notify(MigrationEvent{Type: MigrationEventFailed, File: m.File, Error: err})
+			return err
+		}
+
+		pending = append(pending, m.File)
+
+		return nil
+	}, func(committed bool) {
+		if committed {
+			for _, file := range pending {
+				notify(MigrationEvent{Type: MigrationEventApplied, File: file})
+			}
+			applied = append(applied, pending...)
+		}
+
+		pending = pending[:0]
+	})
+
+	if err != nil {
+		var migrationsErr *MigrationsError
+		if errors.As(err, &migrationsErr) {
+			// the successful migrations are still applied
+			return applied, err
+		}
+
+		if errors.Is(err, ErrPaused) {
+			// the migrations of the committed transactions are still applied
+			notify(MigrationEvent{Type: MigrationEventPaused, Error: err})
+			return applied, err
+		}
+
+		return nil, err
+	}
+
+	notify(MigrationEvent{Type: MigrationEventCommitted})
+
+	return applied, nil
+}
+
+// Down reverts the last `toRevertCount` applied migrations.
+//
+// On success returns list with the reverted migrations file names.
+// On failure returns the migrations reverted by the already committed
+// transactions (eg. with PerMigrationTx) together with the error.
+func (r *Runner) Down(toRevertCount int) ([]string, error) {
+	return r.down(nil, toRevertCount)
+}
+
+// DownTx reverts the last `toRevertCount` applied migrations within
+// the provided transaction instead of opening new one(s) (see UpTx).
+//
+// On success returns list with the reverted migrations file names.
+func (r *Runner) DownTx(tx *dbx.Tx, toRevertCount int) ([]string, error) {
+	return r.down(tx, toRevertCount)
+}
+
+// down reverts the last `toRevertCount` applied migrations (within the optional tx).
+func (r *Runner) down(tx *dbx.Tx, toRevertCount int) (result []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	r.tx = tx
+	defer func() { r.tx = nil }()
+
+	r.startRun()
+
+	finishSpan := r.startRunSpan(DirectionDown)
+	defer func() { finishSpan(len(result), err) }()
+
+	applied := []string{}
+
+	items, err := r.orderedItems()
+	if err != nil {
+		return nil, err
+	}
+
+	if items, err = r.checkpointDownItems(items); err != nil {
+		return nil, err
+	}
+
+	reversed := make([]*Migration, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		reversed = append(reversed, items[i])
+	}
+
+	if r.TrackObjects {
+		if err := r.createObjectsTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	totalReverted := 0
+
+	// the first error of saving the rollback floors (see FloorOnDown)
+	var floorErr error
+
+	// the migrations of the current group that are reported
+	// as reverted only after the group transaction is committed
+	pending := []*Migration{}
+
+	err = r.runGrouped(reversed, false, func(db dbx.Builder, m *Migration) error {
+		// revert limit reached
+		if toRevertCount-totalReverted <= 0 {
+			return nil
+		}
+
+		// skip unapplied
+		if !r.isMigrationApplied(db, m.set, m.File) {
+			return nil
+		}
+
+		err := r.traceMigration(m, DirectionDown, func() error {
+			return r.migrationDown(db, m)
+		})
+		if err != nil {
+			return r.mapError(m.File, DirectionDown, fmt.Errorf("Failed to revert migration %s: %w", m.File, err))
+		}
+
+		if err := r.saveRevertedMigration(db, m.set, m.File); err != nil {
+			return fmt.Errorf("Failed to save reverted migration info for %s: %w", m.File, err)
+		}
+
+		if r.TrackObjects {
+			if err := r.deleteCreatedObjects(db, m); err != nil {
+				return err
 			}
+		}
 
-			// revert limit reached
-			if toRevertCount-totalReverted <= 0 {
-				break
+		pending = append(pending, m)
+		totalReverted++
+
+		return nil
+	}, func(committed bool) {
+		if committed {
+			for _, m := range pending {
+				applied = append(applied, m.File)
 			}
 
-			if err := m.down(tx); err != nil {
-				return fmt.Errorf("Failed to revert migration %s: %w", m.file, err)
+			// saved per committed group so that the floors of the
+			// committed reverts remain even if a later group fails
+			if r.FloorOnDown && floorErr == nil {
+				floorErr = r.saveFloors(pending)
 			}
+		}
+
+		pending = pending[:0]
+	})
+
+	if err == nil {
+		err = floorErr
+	} else if floorErr != nil {
+		err = fmt.Errorf("%w (also failed to save the rollback floor: %v)", err, floorErr)
+	}
+
+	if err != nil {
+		// the migrations of the committed transactions are still reverted
+		return applied, err
+	}
+
+	return applied, nil
+}
+
+// mapError passes the migration func error through the MapError hook (if set).
+func (r *Runner) mapError(file string, direction string, err error) error {
+	if r.MapError == nil {
+		return err
+	}
+
+	return r.MapError(file, direction, err)
+}
+
+// runGrouped calls fn for each of the provided migrations.
+//
+// Consecutive transactional migrations are grouped and executed in a
+// single transaction (or in a separate transaction for each migration
+// if PerMigrationTx is set and for each CommitEvery migrations if
+// CommitEvery is set), while the NonTransactional ones are
+// executed directly against the runner db and the DisableForeignKeys
+// ones in their own transaction (see runWithForeignKeysDisabled).
+// The Migration.Transaction preference overrides the PerMigrationTx
+// grouping of the individual migrations (see transactionMode).
+//
+// During UpTx/DownTx all migrations are executed with the caller
+// provided transaction (the DisableForeignKeys ones are not supported).
+//
+// If continueOnError is set, the failed groups are rolled back and
+// the execution continues with the next group, returning at the end
+// a *MigrationsError with all failures.
+//
+// The optional groupDone callback is invoked after each group with
+// whether its changes were committed (in UpTx/DownTx mode the group
+// is considered committed once all of its migrations succeed).
+func (r *Runner) runGrouped(
+	migrations []*Migration,
+	continueOnError bool,
+	fn func(db dbx.Builder, m *Migration) error,
+	groupDone func(committed bool),
+) error {
+	if groupDone == nil {
+		groupDone = func(committed bool) {}
+	}
+
+	if err := r.checkTransactionModes(migrations); err != nil {
+		return err
+	}
 
-			if err := r.saveRevertedMigration(tx, m.file); err != nil {
-				return fmt.Errorf("Failed to save reverted migration info for %s: %w", m.file, err)
+	// execute everything in the caller provided transaction
+	if r.tx != nil {
+		for _, m := range migrations {
+			if m.DisableForeignKeys {
+				return fmt.Errorf("Migration %s with DisableForeignKeys cannot be executed in a caller provided transaction", m.File)
 			}
 
-			applied = append(applied, m.file)
+			if err := fn(r.tx, m); err != nil {
+				groupDone(false)
+				return err
+			}
 		}
 
+		groupDone(true)
+
 		return nil
+	}
+
+	failures := []error{}
+
+	for i := 0; i < len(migrations); {
+		if r.pausable && r.pauseRequested() {
+			return ErrPaused
+		}
+
+		j := i + 1
+		var err error
+
+		mode := r.transactionMode(migrations[i])
+
+		if migrations[i].DisableForeignKeys {
+			m := migrations[i]
+			err = r.runWithForeignKeysDisabled(m, func(tx *dbx.Tx) error {
+				return fn(tx, m)
+			})
+		} else if mode == TransactionNone {
+			err = fn(r.db, migrations[i])
+		} else {
+			for mode == TransactionBatch &&
+				j < len(migrations) &&
+				r.transactionMode(migrations[j]) == TransactionBatch &&
+				(r.CommitEvery <= 0 || j-i < r.CommitEvery) {
+				j++
+			}
+			batch := migrations[i:j]
+
+			err = r.db.Transactional(func(tx *dbx.Tx) error {
+				for _, m := range batch {
+					if err := fn(tx, m); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		}
+
+		groupDone(err == nil)
+
+		if err != nil {
+			if !continueOnError {
+				return err
+			}
+			failures = append(failures, err)
+		}
+
+		i = j
+	}
+
+	if len(failures) > 0 {
+		return &MigrationsError{Errors: failures}
+	}
+
+	return nil
+}
+
+// MigrationsError defines an aggregated error of multiple failed migrations.
+type MigrationsError struct {
+	Errors []error
+}
+
+// Error implements the [error] interface.
+func (e *MigrationsError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d migration(s) failed:", len(e.Errors))
+	for _, err := range e.Errors {
+		b.WriteString("\n- ")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// printSuccess prints a formatted Run success message (if not Quiet).
+func (r *Runner) printSuccess(format string, a ...any) {
+	if r.Quiet {
+		return
+	}
+
+	color.Green(format, a...)
+}
+
+// builder returns the db builder for the current run queries
+// (the caller provided transaction or the runner db).
+func (r *Runner) builder() dbx.Builder {
+	if r.tx != nil {
+		return r.tx
+	}
+
+	return r.db
+}
+
+// migrationsDir returns the runner migrations source directory.
+func (r *Runner) migrationsDir() (string, error) {
+	if r.Dir != "" {
+		return r.Dir, nil
+	}
+
+	// If not specified, auto point to the default migrations folder.
+	//
+	// NB!
+	// Since the create command makes sense only during development,
+	// it is expected the user to be in the app working directory
+	// and to be using `go run ...`
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(wd, "migrations"), nil
+}
+
+// surveyConfirm asks for confirmation using an interactive terminal prompt.
+func surveyConfirm(message string) (bool, error) {
+	confirm := false
+
+	err := survey.AskOne(&survey.Confirm{Message: message}, &confirm)
+
+	return confirm, err
+}
+
+// appliedCount returns the number of registered migrations that are currently applied.
+func (r *Runner) appliedCount() int {
+	total := 0
+
+	for _, m := range r.items() {
+		if r.isMigrationApplied(r.db, m.set, m.File) {
+			total++
+		}
+	}
+
+	return total
+}
+
+// createMigrationsTableQuery returns the default CREATE TABLE query
+// of a migrations table with the provided name and primary key columns
+// (defaults to the "file" and "set" columns).
+func (r *Runner) createMigrationsTableQuery(table string, primaryKey ...string) string {
+	if len(primaryKey) == 0 {
+		primaryKey = []string{"file", "set"}
+	}
+
+	columns := []string{
+		"file " + r.Dialect.KeyType() + " NOT NULL",
+		"applied " + r.Dialect.IntegerType() + " NOT NULL",
+	}
+	for _, c := range optionalMigrationsTableColumns {
+		columns = append(columns, r.db.QuoteColumnName(c.name)+" "+c.definition(r.Dialect))
+	}
+	keyColumns := make([]string, len(primaryKey))
+	for i, name := range primaryKey {
+		keyColumns[i] = r.db.QuoteColumnName(name)
+	}
+	columns = append(columns, "PRIMARY KEY ("+strings.Join(keyColumns, ", ")+")")
+
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %v (%s)",
+		r.db.QuoteTableName(table),
+		strings.Join(columns, ", "),
+	)
+}
+
+func (r *Runner) createMigrationsTable() error {
+	rawQuery := r.createMigrationsTableQuery(r.TableName)
+
+	if r.CreateTableSQL == nil {
+		_, err := r.db.NewQuery(rawQuery).Execute()
+
+		return err
+	}
+
+	statements := r.CreateTableSQL(rawQuery)
+	if len(statements) == 0 {
+		return fmt.Errorf("%w - the CreateTableSQL builder returned no statements", ErrIncompatibleMigrationsTable)
+	}
+
+	return r.db.Transactional(func(tx *dbx.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.NewQuery(statement).Execute(); err != nil {
+				return fmt.Errorf("Failed to execute the custom migrations table statement %q: %w", statement, err)
+			}
+		}
+
+		// validate the custom schema before committing it
+		columns, err := r.migrationsTableColumns(tx)
+		if err != nil {
+			return err
+		}
+
+		return checkRequiredColumns(columns)
 	})
+}
+
+// verifyMigrationsTable checks whether the migrations table exists
+// and has all tracking columns (used in ReadOnly and SkipCreateTable mode).
+func (r *Runner) verifyMigrationsTable() error {
+	columns, err := r.migrationsTableColumns(r.db)
+	if err != nil {
+		return err
+	}
+
+	if err := checkRequiredColumns(columns); err != nil {
+		return err
+	}
+
+	for _, c := range optionalMigrationsTableColumns {
+		if !list.ExistInSlice(c.name, columns) {
+			return fmt.Errorf(
+				"%w - the table is outdated (missing column %q), run the migrations once with a runner allowed to upgrade it",
+				ErrIncompatibleMigrationsTable,
+				c.name,
+			)
+		}
+	}
+
+	// the read-only runners don't record migrations
+	// and are not affected by the sets collisions
+	if r.ReadOnly || r.CreateTableSQL != nil {
+		return nil
+	}
+
+	outdated, err := r.hasOutdatedPrimaryKey()
+	if err != nil {
+		return err
+	}
+	if outdated {
+		return fmt.Errorf(
+			"%w - the table is outdated (missing \"set\" primary key column), run the migrations once with a runner allowed to upgrade it",
+			ErrIncompatibleMigrationsTable,
+		)
+	}
+
+	return nil
+}
+
+// checkRequiredColumns returns ErrIncompatibleMigrationsTable if any of
+// the requiredMigrationsTableColumns is missing from the provided columns.
+func checkRequiredColumns(columns []string) error {
+	for _, name := range requiredMigrationsTableColumns {
+		if !list.ExistInSlice(name, columns) {
+			return fmt.Errorf(
+				"%w - missing column %q (the table was probably created by another tool, rename or migrate it manually)",
+				ErrIncompatibleMigrationsTable,
+				name,
+			)
+		}
+	}
 
+	return nil
+}
+
+// migrationsTableColumns returns the existing migrations table column names.
+func (r *Runner) migrationsTableColumns(db dbx.Builder) ([]string, error) {
+	rows, err := db.Select("*").From(r.TableName).Limit(1).Rows()
 	if err != nil {
 		return nil, err
 	}
-	return applied, nil
+	defer rows.Close()
+
+	return rows.Columns()
 }
 
-func (r *Runner) createMigrationsTable() error {
-	rawQuery := fmt.Sprintf(
-		"CREATE TABLE IF NOT EXISTS %v (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)",
-		r.db.QuoteTableName(r.tableName),
-	)
+// upgradeMigrationsTable adds the tracking columns that may be missing
+// in migrations tables created by older versions of the runner.
+//
+// The legacy tables with a primary key without the "set" column
+// (eg. only "file") are also rebuilt with a (file, set) primary key, so
+// that the same file name in different sets doesn't collide. The tables
+// created with a custom Runner.CreateTableSQL or verified with
+// Runner.SkipCreateTable are never rebuilt.
+func (r *Runner) upgradeMigrationsTable() error {
+	columns, err := r.migrationsTableColumns(r.db)
+	if err != nil {
+		return err
+	}
+
+	if err := checkRequiredColumns(columns); err != nil {
+		return err
+	}
+
+	for _, c := range optionalMigrationsTableColumns {
+		if list.ExistInSlice(c.name, columns) {
+			continue
+		}
+
+		if _, err := r.db.AddColumn(r.TableName, c.name, c.definition(r.Dialect)).Execute(); err != nil {
+			return fmt.Errorf(
+				"%w - failed to add the missing column %q (%v), upgrade the table manually or with a user allowed to alter it",
+				ErrIncompatibleMigrationsTable,
+				c.name,
+				err,
+			)
+		}
+	}
+
+	if r.CreateTableSQL == nil && !r.SkipCreateTable {
+		outdated, err := r.hasOutdatedPrimaryKey()
+		if err != nil {
+			return err
+		}
+
+		if outdated {
+			if err := r.rebuildMigrationsTable(); err != nil {
+				return fmt.Errorf(
+					"%w - failed to rebuild the table with a (file, set) primary key (%v), upgrade the table manually or with a user allowed to alter it",
+					ErrIncompatibleMigrationsTable,
+					err,
+				)
+			}
+		}
+	}
+
+	// convert the legacy second precision timestamps to nanoseconds
+	_, err = r.db.Update(
+		r.TableName,
+		dbx.Params{"applied": dbx.NewExp("[[applied]] * 1000000000")},
+		dbx.NewExp("[[applied]] > 0 AND [[applied]] < {:threshold}", dbx.Params{"threshold": legacyTimestampThreshold}),
+	).Execute()
+	if err != nil {
+		return fmt.Errorf("Failed to convert the legacy migrations timestamps: %w", err)
+	}
+
+	return nil
+}
+
+// primaryKeyColumns returns the primary key column names of the
+// migrations table.
+//
+// Returns nil if the table has no primary key or if the primary key
+// cannot be inspected for the runner db driver.
+func (r *Runner) primaryKeyColumns() ([]string, error) {
+	schema, table := "", r.TableName
+	if i := strings.Index(r.TableName, "."); i >= 0 {
+		schema, table = r.TableName[:i], r.TableName[i+1:]
+	}
+
+	var query *dbx.Query
+
+	switch r.db.DriverName() {
+	case "sqlite", "sqlite3":
+		if schema == "" {
+			schema = "main"
+		}
+		query = r.db.NewQuery("SELECT [[name]] FROM pragma_table_info({:table}, {:schema}) WHERE [[pk]] > 0 ORDER BY [[pk]]")
+	case "postgres", "pgx":
+		query = r.db.NewQuery(
+			"SELECT a.attname FROM pg_index i " +
+				"JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) " +
+				"WHERE i.indrelid = {:name}::regclass AND i.indisprimary",
+		)
+	case "mysql":
+		query = r.db.NewQuery(
+			"SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE " +
+				"WHERE CONSTRAINT_NAME = 'PRIMARY' AND TABLE_NAME = {:table} " +
+				"AND TABLE_SCHEMA = COALESCE(NULLIF({:schema}, ''), DATABASE())",
+		)
+	default:
+		return nil, nil
+	}
+
+	columns := []string{}
+
+	err := query.Bind(dbx.Params{"name": r.TableName, "table": table, "schema": schema}).Column(&columns)
+
+	return columns, err
+}
+
+// hasOutdatedPrimaryKey checks whether the migrations table has
+// a primary key that doesn't include the "set" column.
+func (r *Runner) hasOutdatedPrimaryKey() (bool, error) {
+	columns, err := r.primaryKeyColumns()
+	if err != nil {
+		return false, fmt.Errorf("Failed to inspect the migrations table primary key: %w", err)
+	}
+
+	return len(columns) > 0 && !list.ExistInSlice("set", columns), nil
+}
+
+// migrationsTableColumnsList returns the quoted and comma separated
+// names of all migrations table columns (eg. for INSERT ... SELECT).
+func (r *Runner) migrationsTableColumnsList() string {
+	columns := []string{r.db.QuoteColumnName("file"), r.db.QuoteColumnName("applied")}
+	for _, c := range optionalMigrationsTableColumns {
+		columns = append(columns, r.db.QuoteColumnName(c.name))
+	}
+
+	return strings.Join(columns, ", ")
+}
 
-	_, err := r.db.NewQuery(rawQuery).Execute()
+// rebuildMigrationsTable recreates the migrations table with the
+// default schema and (file, set) primary key, preserving its records.
+//
+// The table must already have all tracking columns.
+func (r *Runner) rebuildMigrationsTable() error {
+	tmpTable := r.TableName + "_rebuild"
+
+	columnsList := r.migrationsTableColumnsList()
+
+	// the sqlite and postgres tables are renamed within their schema
+	renameTo := r.TableName
+	if driver := r.db.DriverName(); driver != "mysql" {
+		if i := strings.Index(renameTo, "."); i >= 0 {
+			renameTo = renameTo[i+1:]
+		}
+	}
+
+	return r.db.Transactional(func(tx *dbx.Tx) error {
+		if _, err := tx.NewQuery(r.createMigrationsTableQuery(tmpTable)).Execute(); err != nil {
+			return err
+		}
+
+		_, err := tx.NewQuery(fmt.Sprintf(
+			"INSERT INTO %v (%s) SELECT %s FROM %v",
+			r.db.QuoteTableName(tmpTable),
+			columnsList,
+			columnsList,
+			r.db.QuoteTableName(r.TableName),
+		)).Execute()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.DropTable(r.TableName).Execute(); err != nil {
+			return err
+		}
+
+		_, err = tx.RenameTable(tmpTable, renameTo).Execute()
+
+		return err
+	})
+}
+
+// runIdLength is the length of the generated run ids.
+const runIdLength = 15
+
+// startRun generates a new unique id for the current migrations run.
+func (r *Runner) startRun() {
+	r.runId = security.RandomString(runIdLength)
+}
+
+// LastRunId returns the unique id of the last migrations run
+// (eg. Up, Down, RunOne) that is also stored in the "run_id"
+// column of its applied migrations records.
+//
+// Returns empty string if there were no runs yet.
+func (r *Runner) LastRunId() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.runId
+}
+
+// nextTimestamp returns the current unix timestamp in nanoseconds,
+// guaranteeing that it is always greater than the previously
+// returned one so that the records apply order could be
+// reconstructed even for migrations applied in the same instant.
+func (r *Runner) nextTimestamp() int64 {
+	ts := r.now().UnixNano()
+
+	if ts <= r.lastTimestamp {
+		ts = r.lastTimestamp + 1
+	}
+
+	r.lastTimestamp = ts
+
+	return ts
+}
 
-	return err
+// now returns the current time based on the runner Now clock (if set).
+func (r *Runner) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+
+	return time.Now()
 }
 
-func (r *Runner) isMigrationApplied(tx dbx.Builder, file string) bool {
-	var exists bool
+// IsApplied checks whether the specified migration file
+// of the default set is currently applied.
+func (r *Runner) IsApplied(file string) (bool, error) {
+	return r.checkMigrationApplied(r.db, "", file)
+}
 
-	err := tx.Select("count(*)").
-		From(r.tableName).
-		Where(dbx.HashExp{"file": file}).
-		Limit(1).
-		Row(&exists)
+func (r *Runner) isMigrationApplied(tx dbx.Builder, set string, file string) bool {
+	exists, err := r.checkMigrationApplied(tx, set, file)
 
 	return err == nil && exists
 }
 
-func (r *Runner) saveAppliedMigration(tx dbx.Builder, file string) error {
-	_, err := tx.Insert(r.tableName, dbx.Params{
-		"file":    file,
-		"applied": time.Now().Unix(),
-	}).Execute()
+func (r *Runner) checkMigrationApplied(tx dbx.Builder, set string, file string) (bool, error) {
+	var total int
+
+	err := r.withTrackingRetry(func() error {
+		return tx.Select(r.Dialect.CountExp()).
+			From(r.TableName).
+			Where(dbx.HashExp{"file": file, "set": set}).
+			AndWhere(appliedRecordExp()).
+			Limit(1).
+			Row(&total)
+	})
+
+	return total > 0, err
+}
+
+// appliedRecordExp returns the expression matching the migrations table
+// records of the currently applied migrations - the not reverted ones
+// with a positive applied timestamp (some legacy tables keep the
+// reverted records with applied=0 instead of deleting them).
+func appliedRecordExp() dbx.Expression {
+	return dbx.And(dbx.HashExp{"reverted": 0}, dbx.NewExp("[[applied]] > 0"))
+}
+
+func (r *Runner) saveAppliedMigration(tx dbx.Builder, set string, file string) error {
+	return r.saveAppliedMigrationAt(tx, set, file, r.nextTimestamp())
+}
+
+func (r *Runner) saveAppliedMigrationAt(tx dbx.Builder, set string, file string, applied int64) error {
+	return r.withTrackingRetry(func() error {
+		if err := r.cleanupInactiveRecords(tx, set, file); err != nil {
+			return err
+		}
+
+		// skip the insert if the migration is already recorded
+		// (eg. by a concurrent run) to avoid duplicated records
+		// and fail instead of silently dropping the new record
+		verb, suffix := r.Dialect.InsertIgnore()
+
+		confirmed := 1
+		if r.Tentative {
+			confirmed = 0
+		}
+
+		result, err := tx.NewQuery(strings.TrimSpace(fmt.Sprintf(
+			"%s INTO {{%s}} ([[file]], [[applied]], [[applied_by]], [[set]], [[run_id]], [[confirmed]]) "+
+				"VALUES ({:file}, {:applied}, {:applied_by}, {:set}, {:run_id}, {:confirmed}) %s",
+			verb,
+			r.TableName,
+			suffix,
+		))).Bind(dbx.Params{
+			"file":       file,
+			"applied":    applied,
+			"applied_by": r.Applier,
+			"set":        set,
+			"run_id":     r.runId,
+			"confirmed":  confirmed,
+		}).Execute()
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return fmt.Errorf("Migration %s is already recorded as applied (eg. by a concurrent run)", AppliedMigration{Set: set, File: file}.name())
+		}
 
-	return err
+		return nil
+	})
 }
 
-func (r *Runner) saveRevertedMigration(tx dbx.Builder, file string) error {
-	_, err := tx.Delete(r.tableName, dbx.HashExp{"file": file}).Execute()
+func (r *Runner) saveRevertedMigration(tx dbx.Builder, set string, file string) error {
+	return r.withTrackingRetry(func() error {
+		if r.KeepRevertedHistory {
+			_, err := tx.Update(
+				r.TableName,
+				dbx.Params{"reverted": r.nextTimestamp()},
+				dbx.HashExp{"file": file, "set": set},
+			).Execute()
+			if err != nil {
+				return err
+			}
+
+			return r.archiveRevertedRecords(tx, set, file)
+		}
+
+		_, err := tx.Delete(r.TableName, dbx.HashExp{"file": file, "set": set}).Execute()
 
-	return err
+		return err
+	})
 }
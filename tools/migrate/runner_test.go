@@ -3,9 +3,18 @@ package migrate
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/tools/list"
 	_ "modernc.org/sqlite"
@@ -33,7 +42,10 @@ func TestNewRunner(t *testing.T) {
 	}
 
 	expectedQueries := []string{
-		"CREATE TABLE IF NOT EXISTS `_migrations` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS `_migrations` (file VARCHAR(255) NOT NULL, applied INTEGER NOT NULL, `reverted` INTEGER DEFAULT 0 NOT NULL, `applied_by` TEXT DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` TEXT DEFAULT '' NOT NULL, `confirmed` INTEGER DEFAULT 1 NOT NULL, `log` TEXT DEFAULT '' NOT NULL, PRIMARY KEY (`file`, `set`))",
+		"SELECT * FROM `_migrations` LIMIT 1",
+		"SELECT [[name]] FROM pragma_table_info('_migrations', 'main') WHERE [[pk]] > 0 ORDER BY [[pk]]",
+		"UPDATE `_migrations` SET `applied`=[[applied]] * 1000000000 WHERE [[applied]] > 0 AND [[applied]] < 1000000000000",
 	}
 	if len(expectedQueries) != len(testDB.CalledQueries) {
 		t.Fatalf("Expected %d queries, got %d: \n%v", len(expectedQueries), len(testDB.CalledQueries), testDB.CalledQueries)
@@ -79,7 +91,7 @@ func TestRunnerUpAndDown(t *testing.T) {
 	}
 
 	// simulate partially run migration
-	r.saveAppliedMigration(testDB, r.migrationsList.Item(0).file)
+	r.saveAppliedMigration(testDB, "", r.migrationsList.Item(0).File)
 
 	// Up()
 	// ---
@@ -97,7 +109,7 @@ func TestRunnerUpAndDown(t *testing.T) {
 
 	// simulate unrun migration
 	var test3DownCalled bool
-	r.migrationsList.Register(nil, func(db dbx.Builder) error {
+	r.migrationsList.(*MigrationsList).Register(nil, func(db dbx.Builder) error {
 		test3DownCalled = true
 		return nil
 	}, "3_test")
@@ -117,6 +129,1609 @@ func TestRunnerUpAndDown(t *testing.T) {
 	}
 }
 
+func TestRunnerDownLimit(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := r.appliedCount(); count != 3 {
+		t.Fatalf("Expected 3 applied migrations, got %d", count)
+	}
+
+	reverted, err := r.Down(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0] != "3_test" {
+		t.Fatalf("Expected only 3_test to be reverted, got %v", reverted)
+	}
+
+	// request more than the available applied migrations
+	reverted, err = r.Down(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 2 {
+		t.Fatalf("Expected 2 reverted migrations, got %v", reverted)
+	}
+
+	if count := r.appliedCount(); count != 0 {
+		t.Fatalf("Expected 0 applied migrations, got %d", count)
+	}
+}
+
+func TestRunnerDownPartialFailure(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+	fail := func(db dbx.Builder) error { return errors.New("down failure") }
+
+	l := MigrationsList{}
+	l.Register(noop, fail, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	scenarios := []struct {
+		perMigrationTx   bool
+		expectedReverted []string
+		expectedApplied  int
+	}{
+		// the whole group is rolled back
+		{false, nil, 3},
+		// the reverts of the committed transactions remain
+		{true, []string{"3_test", "2_test"}, 1},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.PerMigrationTx = s.perMigrationTx
+
+		if _, err := r.Up(); err != nil {
+			t.Fatal(err)
+		}
+
+		reverted, err := r.Down(3)
+
+		count := r.appliedCount()
+
+		testDB.Close()
+
+		if err == nil {
+			t.Errorf("(%d) Expected down failure, got nil", i)
+			continue
+		}
+
+		if strings.Join(reverted, ",") != strings.Join(s.expectedReverted, ",") {
+			t.Errorf("(%d) Expected reverted %v, got %v", i, s.expectedReverted, reverted)
+		}
+
+		if count != s.expectedApplied {
+			t.Errorf("(%d) Expected %d applied migrations, got %d", i, s.expectedApplied, count)
+		}
+	}
+}
+
+func TestNewRunnerUpgradeLegacyTable(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// simulate a migrations table created by an older version
+	_, err = testDB.NewQuery("CREATE TABLE `_migrations` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Insert("_migrations", dbx.Params{"file": "1_test", "applied": 1640988000}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedQuery := "ALTER TABLE `_migrations` ADD `reverted` INTEGER DEFAULT 0 NOT NULL"
+	if !list.ExistInSlice(expectedQuery, testDB.CalledQueries) {
+		t.Fatalf("Query %s was not found in \n%v", expectedQuery, testDB.CalledQueries)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0].Applied != 1640988000*1e9 {
+		t.Fatalf("Expected the legacy timestamp to be converted to nanoseconds, got %v", applied)
+	}
+
+	pk, err := r.primaryKeyColumns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pk, ",") != "file,set" {
+		t.Fatalf("Expected the legacy table to be rebuilt with (file, set) primary key, got %v", pk)
+	}
+
+	// the same file name in different sets doesn't collide
+	if err := r.saveAppliedMigration(testDB, "staging", "1_test"); err != nil {
+		t.Fatalf("Expected the staging/1_test record to be saved, got %v", err)
+	}
+	if !r.isMigrationApplied(testDB, "", "1_test") || !r.isMigrationApplied(testDB, "staging", "1_test") {
+		t.Fatal("Expected both 1_test and staging/1_test to be applied")
+	}
+
+	// already upgraded tables are not rebuilt again
+	testDB.CalledQueries = nil
+	if _, err := NewRunner(testDB.DB, MigrationsList{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range testDB.CalledQueries {
+		if strings.HasPrefix(q, "DROP") {
+			t.Fatalf("Expected no table rebuild, got %q", q)
+		}
+	}
+}
+
+func TestNewRunnerRebuildOutdatedPrimaryKey(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// simulate a table with all columns but with the old primary key
+	_, err = testDB.NewQuery("CREATE TABLE `_migrations` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL, `reverted` INTEGER DEFAULT 0 NOT NULL, `applied_by` TEXT DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` TEXT DEFAULT '' NOT NULL, `confirmed` INTEGER DEFAULT 1 NOT NULL, `log` TEXT DEFAULT '' NOT NULL)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Insert("_migrations", dbx.Params{"file": "1_test", "applied": 100, "set": "staging", "applied_by": "a", "log": "test"}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the verify only runners don't rebuild the table
+	skipCreate := func(r *Runner) { r.SkipCreateTable = true }
+	if _, err := NewRunner(testDB.DB, MigrationsList{}, skipCreate); !errors.Is(err, ErrIncompatibleMigrationsTable) {
+		t.Fatalf("Expected ErrIncompatibleMigrationsTable, got %v", err)
+	}
+
+	readOnly := func(r *Runner) { r.ReadOnly = true }
+	if _, err := NewRunner(testDB.DB, MigrationsList{}, readOnly); err != nil {
+		t.Fatalf("Expected the read-only runner to accept the table, got %v", err)
+	}
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0].Set != "staging" || applied[0].AppliedBy != "a" || applied[0].Log != "test" {
+		t.Fatalf("Expected the staging/1_test record to be preserved, got %v", applied)
+	}
+
+	if err := r.saveAppliedMigration(testDB, "", "1_test"); err != nil {
+		t.Fatalf("Expected the 1_test record to be saved, got %v", err)
+	}
+
+	if _, err := NewRunner(testDB.DB, MigrationsList{}, skipCreate); err != nil {
+		t.Fatalf("Expected the rebuilt table to be accepted, got %v", err)
+	}
+}
+
+func TestRunnerKeepRevertedHistory(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.KeepRevertedHistory = true
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected 1_test to be marked as reverted")
+	}
+
+	var total int
+	testDB.Select("count(*)").From(r.historyTable()).Where(dbx.NewExp("[[reverted]] > 0")).Row(&total)
+	if total != 1 {
+		t.Fatalf("Expected the reverted record to be kept, got %d records", total)
+	}
+
+	// reapply
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("Expected 1_test to be reapplied, got %v", applied)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected 1_test to be applied")
+	}
+
+	// revert again
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	history := []struct {
+		Applied  int64 `db:"applied"`
+		Reverted int64 `db:"reverted"`
+	}{}
+	err = testDB.Select("applied", "reverted").From(r.historyTable()).OrderBy("applied ASC").All(&history)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("Expected both reverted records to be kept, got %v", history)
+	}
+	if history[0].Reverted >= history[1].Applied || history[1].Applied >= history[1].Reverted {
+		t.Fatalf("Expected the apply/revert timeline to be preserved, got %v", history)
+	}
+
+	var active int
+	testDB.Select("count(*)").From(r.TableName).Row(&active)
+	if active != 0 {
+		t.Fatalf("Expected no records in the migrations table, got %d", active)
+	}
+}
+
+func TestRunnerKeepRevertedHistoryLegacyRecords(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.KeepRevertedHistory = true
+
+	// reverted record kept in the migrations table by an older version
+	_, err = testDB.Insert(r.TableName, dbx.Params{"file": "1_test", "applied": 100, "reverted": 200}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	testDB.Select("file").From(r.historyTable()).Where(dbx.HashExp{"applied": 100, "reverted": 200}).Column(&files)
+	if len(files) != 1 {
+		t.Fatalf("Expected the legacy reverted record to be moved to the history table, got %v", files)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected 1_test to be applied")
+	}
+}
+
+func TestRunnerRunConfirm(t *testing.T) {
+	scenarios := []struct {
+		confirm       bool
+		expectCreated bool
+	}{
+		{false, false},
+		{true, true},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewRunner(testDB.DB, MigrationsList{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var confirmMessage string
+		r.Confirm = func(message string) (bool, error) {
+			confirmMessage = message
+			return s.confirm, nil
+		}
+
+		dir := t.TempDir()
+
+		if err := r.Run("create", "test_name", dir); err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		testDB.Close()
+
+		if confirmMessage == "" {
+			t.Fatalf("(%d) Expected Confirm to be called", i)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*_test_name.go"))
+		if created := len(files) > 0; created != s.expectCreated {
+			t.Fatalf("(%d) Expected created %v, got %v", i, s.expectCreated, created)
+		}
+	}
+}
+
+func TestRunnerRunCreateAutoConfirm(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, func(db dbx.Builder) error { return nil }, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var confirmCalls int
+	r.Confirm = func(message string) (bool, error) {
+		confirmCalls++
+		return false, nil
+	}
+
+	scenarios := []struct {
+		autoConfirm bool
+		args        []string
+	}{
+		{false, []string{"--no-confirm"}},
+		{true, nil},
+	}
+
+	for i, s := range scenarios {
+		r.AutoConfirmCreate = s.autoConfirm
+
+		dir := t.TempDir()
+
+		args := append([]string{"create", "test_name", dir}, s.args...)
+		if err := r.Run(args...); err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		if files, _ := filepath.Glob(filepath.Join(dir, "*_test_name.go")); len(files) != 1 {
+			t.Fatalf("(%d) Expected the migration file to be created, got %v", i, files)
+		}
+	}
+
+	if confirmCalls != 0 {
+		t.Fatalf("Expected Confirm to not be called, got %d calls", confirmCalls)
+	}
+
+	// the down command should still ask for confirmation
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Run("down", "1", "--no-confirm"); err != nil {
+		t.Fatal(err)
+	}
+	if confirmCalls != 1 {
+		t.Fatalf("Expected the down Confirm to be called, got %d calls", confirmCalls)
+	}
+}
+
+func TestRunnerRunDownCount(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+	l.Register(noop, noop, "4_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var confirmCalls int
+	r.Confirm = func(message string) (bool, error) {
+		confirmCalls++
+		return true, nil
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		args            []string
+		expectError     bool
+		expectedApplied int
+	}{
+		{[]string{"down", "0"}, true, 4},
+		{[]string{"down", "abc"}, true, 4},
+		{[]string{"down", "--quiet"}, false, 3},
+		{[]string{"down", "--quiet", "2"}, false, 1},
+		{[]string{"down", "-1"}, false, 0},
+	}
+
+	for i, s := range scenarios {
+		confirmCalls = 0
+
+		err := r.Run(s.args...)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if hasErr && confirmCalls != 0 {
+			t.Errorf("(%d) Expected Confirm to not be called on error", i)
+		}
+
+		if applied := r.appliedCount(); applied != s.expectedApplied {
+			t.Errorf("(%d) Expected %d applied migrations, got %d", i, s.expectedApplied, applied)
+		}
+	}
+}
+
+func TestRunnerRunCreateStdout(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Confirm = func(message string) (bool, error) {
+		t.Fatal("Expected Confirm to not be called")
+		return false, nil
+	}
+
+	dir := t.TempDir()
+
+	originalStdout := os.Stdout
+	defer func() { os.Stdout = originalStdout }()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+
+	runErr := r.Run("create", "test_name", dir, "--stdout")
+
+	writer.Close()
+	os.Stdout = originalStdout
+
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	output, _ := io.ReadAll(reader)
+
+	if !strings.HasPrefix(string(output), "// "+dir+"/") || !strings.HasSuffix(string(output), createTemplateContent) {
+		t.Fatalf("Expected the path comment and the template content, got \n%s", output)
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) > 0 {
+		t.Fatalf("Expected no files to be created, got %v", files)
+	}
+}
+
+func TestRunnerRunQuiet(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalOutput := color.Output
+	defer func() { color.Output = originalOutput }()
+
+	var b strings.Builder
+	color.Output = &b
+
+	r.Quiet = true
+	r.RequireNonEmpty = true
+
+	if err := r.Run("up"); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len() > 0 {
+		t.Fatalf("Expected no output in quiet mode, got %q", b.String())
+	}
+
+	r.Quiet = false
+
+	if err := r.Run("up", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len() > 0 {
+		t.Fatalf("Expected no output with the --quiet flag, got %q", b.String())
+	}
+
+	if err := r.Run("up"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), "No new migrations to apply.") {
+		t.Fatalf("Expected the success message to be printed, got %q", b.String())
+	}
+
+	// errors are still printed
+	b.Reset()
+	r.migrationsList = MigrationsList{}
+	if err := r.Run("up", "--quiet"); err == nil {
+		t.Fatal("Expected empty migrations list error")
+	}
+
+	if !strings.Contains(b.String(), "migrations list is empty") {
+		t.Fatalf("Expected the error to be printed, got %q", b.String())
+	}
+}
+
+func TestRunnerRequireNonEmpty(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	r.RequireNonEmpty = true
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected error for empty migrations list")
+	}
+}
+
+func TestRunnerAppliedMigrationsOrder(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+	l.Register(noop, noop, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// apply 3_test before the others
+	r.saveAppliedMigration(testDB, "", "3_test")
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"3_test", "1_test", "2_test"}
+	if len(applied) != len(expected) {
+		t.Fatalf("Expected %d applied migrations, got %v", len(expected), applied)
+	}
+	for i, file := range expected {
+		if applied[i].File != file {
+			t.Fatalf("Expected %v order, got %v", expected, applied)
+		}
+		if i > 0 && applied[i].Applied <= applied[i-1].Applied {
+			t.Fatalf("Expected strictly increasing applied timestamps, got %v", applied)
+		}
+	}
+}
+
+func TestRunnerUpTxAndDownTx(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	createTable := func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE demo (id TEXT)").Execute()
+		return err
+	}
+	dropTable := func(db dbx.Builder) error {
+		_, err := db.NewQuery("DROP TABLE demo").Execute()
+		return err
+	}
+
+	l := MigrationsList{}
+	l.Register(createTable, dropTable, "1_test")
+	l.Add(&Migration{File: "2_test", Up: createTable, NonTransactional: true})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the non transactional migration must fail because
+	// it is executed in the same transaction and the table exists
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UpTx(tx); err == nil {
+		t.Fatal("Expected 2_test to fail")
+	}
+	tx.Rollback()
+
+	l.Item(1).Up = func(db dbx.Builder) error { return nil }
+
+	// rollback the caller transaction
+	tx, err = testDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := r.UpTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %v", applied)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected the caller transaction rollback to revert the applied migrations")
+	}
+
+	// commit the caller transaction
+	tx, err = testDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UpTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Insert("demo", dbx.Params{"id": "test"}).Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_test") || !r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected 1_test and 2_test to be applied")
+	}
+
+	tx, err = testDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := r.DownTx(tx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 2 {
+		t.Fatalf("Expected 2 reverted migrations, got %v", reverted)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.appliedCount() != 0 {
+		t.Fatal("Expected no applied migrations")
+	}
+
+	r.ContinueOnError = true
+	r.PerMigrationTx = true
+	tx, err = testDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := r.UpTx(tx); err == nil {
+		t.Fatal("Expected ContinueOnError error")
+	}
+}
+
+func TestRunnerRunId(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id := r.LastRunId(); id != "" {
+		t.Fatalf("Expected empty run id before the first run, got %q", id)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	firstRunId := r.LastRunId()
+	if len(firstRunId) != runIdLength {
+		t.Fatalf("Expected %d characters run id, got %q", runIdLength, firstRunId)
+	}
+
+	r.migrationsList.(*MigrationsList).Register(noop, noop, "3_test")
+
+	events, err := r.UpStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e := range events {
+		if e.RunId == "" || e.RunId == firstRunId {
+			t.Fatalf("Expected new run id in the %s event, got %q", e.Type, e.RunId)
+		}
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{firstRunId, firstRunId, r.LastRunId()}
+	if len(applied) != len(expected) {
+		t.Fatalf("Expected %d applied migrations, got %v", len(expected), applied)
+	}
+	for i, id := range expected {
+		if applied[i].RunId != id {
+			t.Fatalf("Expected %s run id %q, got %q", applied[i].File, id, applied[i].RunId)
+		}
+	}
+}
+
+func TestRunnerSkipCreateTable(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	skipCreate := func(r *Runner) { r.SkipCreateTable = true }
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+
+	// missing migrations table
+	if _, err := NewRunner(testDB.DB, l, skipCreate); err == nil {
+		t.Fatal("Expected missing migrations table error")
+	}
+
+	// outdated migrations table
+	_, err = testDB.NewQuery("CREATE TABLE `legacy` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRunner(testDB.DB, l, skipCreate, func(r *Runner) { r.TableName = "legacy" }); !errors.Is(err, ErrIncompatibleMigrationsTable) {
+		t.Fatalf("Expected ErrIncompatibleMigrationsTable, got %v", err)
+	}
+
+	// pre-provisioned table
+	if _, err := NewRunner(testDB.DB, l); err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.CalledQueries = nil
+
+	r, err := NewRunner(testDB.DB, l, skipCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range testDB.CalledQueries {
+		if strings.HasPrefix(q, "CREATE") || strings.HasPrefix(q, "ALTER") {
+			t.Fatalf("Expected no CREATE or ALTER queries, got %q", q)
+		}
+	}
+
+	if applied, err := r.Up(); err != nil || len(applied) != 1 {
+		t.Fatalf("Expected 1 applied migration, got %v (%v)", applied, err)
+	}
+}
+
+func TestRunnerReadOnly(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	readOnly := func(r *Runner) { r.ReadOnly = true }
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	// missing migrations table
+	if _, err := NewRunner(testDB.DB, l, readOnly); err == nil {
+		t.Fatal("Expected missing migrations table error")
+	}
+
+	// outdated migrations table
+	_, err = testDB.NewQuery("CREATE TABLE `legacy` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRunner(testDB.DB, l, readOnly, func(r *Runner) { r.TableName = "legacy" }); !errors.Is(err, ErrIncompatibleMigrationsTable) {
+		t.Fatalf("Expected ErrIncompatibleMigrationsTable, got %v", err)
+	}
+
+	rw, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw.saveAppliedMigration(testDB, "", "1_test")
+
+	testDB.CalledQueries = nil
+
+	r, err := NewRunner(testDB.DB, l, readOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range testDB.CalledQueries {
+		if !strings.HasPrefix(q, "SELECT") {
+			t.Fatalf("Expected only SELECT queries, got %q", q)
+		}
+	}
+
+	if applied, err := r.AppliedMigrations(); err != nil || len(applied) != 1 {
+		t.Fatalf("Expected 1 applied migration, got %v (%v)", applied, err)
+	}
+
+	if _, err := r.Up(); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected Up ErrReadOnly, got %v", err)
+	}
+
+	if _, err := r.Down(1); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected Down ErrReadOnly, got %v", err)
+	}
+
+	if err := r.RunOne("2_test", DirectionUp, true); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected RunOne ErrReadOnly, got %v", err)
+	}
+
+	if err := r.Run("create", "test", t.TempDir()); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected create ErrReadOnly, got %v", err)
+	}
+
+	if err := r.MarkRelease("v1"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected MarkRelease ErrReadOnly, got %v", err)
+	}
+
+	if r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected 2_test to remain unapplied")
+	}
+}
+
+func TestRunnerMarkApplied(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	upCalled := false
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		upCalled = true
+		return nil
+	}, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2022, 6, 30, 10, 0, 0, 0, time.UTC)
+
+	scenarios := []struct {
+		file        string
+		at          time.Time
+		expectError bool
+	}{
+		{"", at, true},
+		{"1_test", time.Time{}, true},
+		{"1_test", at, false},
+		{"1_test", at, true}, // already applied
+		{"2_unregistered", at.Add(time.Hour), false},
+	}
+
+	for i, s := range scenarios {
+		err := r.MarkApplied(s.file, s.at)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+
+	if upCalled {
+		t.Fatal("Expected the migration Up func to not be called")
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || !applied[0].AppliedAt().Equal(at) || applied[1].File != "2_unregistered" {
+		t.Fatalf("Expected the original apply times to be preserved, got %v", applied)
+	}
+
+	if pending, _ := r.Up(); len(pending) != 0 {
+		t.Fatalf("Expected no migrations to apply, got %v", pending)
+	}
+}
+
+func TestRunnerLastApplied(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := r.LastApplied(); ok || err != nil {
+		t.Fatalf("Expected no last applied migration, got %v (%v)", ok, err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "3_test")
+	r.saveAppliedMigration(testDB, "", "1_test")
+	r.saveAppliedMigration(testDB, "", "2_test")
+	r.KeepRevertedHistory = true
+	r.saveRevertedMigration(testDB, "", "2_test")
+
+	last, ok, err := r.LastApplied()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || last.File != "1_test" || last.Applied == 0 {
+		t.Fatalf("Expected 1_test to be the last applied migration, got %v (%v)", last, ok)
+	}
+}
+func TestRunnerIsApplied(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+
+	scenarios := []struct {
+		file     string
+		expected bool
+	}{
+		{"missing", false},
+		{"1_test", true},
+	}
+
+	for i, s := range scenarios {
+		result, err := r.IsApplied(s.file)
+		if err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+		if result != s.expected {
+			t.Fatalf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+
+	// drop the migrations table to simulate db error
+	testDB.DropTable(r.TableName).Execute()
+
+	if _, err := r.IsApplied("1_test"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+func TestRunnerNonTransactional(t *testing.T) {
+	for _, nonTransactional := range []bool{false, true} {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		noop := func(db dbx.Builder) error { return nil }
+
+		l := MigrationsList{}
+		l.Register(noop, noop, "1_test")
+		l.Add(&Migration{
+			File: "2_vacuum",
+			Up: func(db dbx.Builder) error {
+				_, err := db.NewQuery("VACUUM").Execute()
+				return err
+			},
+			Down:             noop,
+			NonTransactional: nonTransactional,
+		})
+		l.Register(noop, noop, "3_test")
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err := r.Up()
+
+		if !nonTransactional {
+			if err == nil {
+				t.Fatal("Expected VACUUM to fail inside a transaction")
+			}
+			testDB.Close()
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(applied) != 3 {
+			t.Fatalf("Expected 3 applied migrations, got %v", applied)
+		}
+
+		reverted, err := r.Down(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(reverted) != 3 {
+			t.Fatalf("Expected 3 reverted migrations, got %v", reverted)
+		}
+
+		testDB.Close()
+	}
+}
+
+func TestRunnerMapError(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	funcErr := errors.New("func error")
+	mappedErr := errors.New("mapped error")
+
+	failing := func(db dbx.Builder) error { return funcErr }
+
+	l := MigrationsList{}
+	l.Register(failing, failing, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// default
+	if _, err := r.Up(); !errors.Is(err, funcErr) {
+		t.Fatalf("Expected the func error to be wrapped, got %v", err)
+	}
+
+	directions := []string{}
+	r.MapError = func(file string, direction string, err error) error {
+		if file != "1_test" || !errors.Is(err, funcErr) {
+			t.Fatalf("Unexpected MapError args %s, %v", file, err)
+		}
+		directions = append(directions, direction)
+		return mappedErr
+	}
+
+	if _, err := r.Up(); err != mappedErr {
+		t.Fatalf("Expected the mapped up error, got %v", err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+
+	if _, err := r.Down(1); err != mappedErr {
+		t.Fatalf("Expected the mapped down error, got %v", err)
+	}
+
+	if len(directions) != 2 || directions[0] != DirectionUp || directions[1] != DirectionDown {
+		t.Fatalf("Expected up and down directions, got %v", directions)
+	}
+}
+
+func TestNewRunnerTableName(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// ensure that the attached db is available for all queries
+	testDB.DB.DB().SetMaxOpenConns(1)
+
+	if _, err := testDB.NewQuery("ATTACH DATABASE ':memory:' AS meta").Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalidNames := []string{"", "a b", "a.b.c", "1abc", "_migrations;DROP"}
+	for _, name := range invalidNames {
+		_, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+			r.TableName = name
+		})
+		if err == nil {
+			t.Fatalf("Expected error for table name %q", name)
+		}
+	}
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+
+	r, err := NewRunner(testDB.DB, l, func(r *Runner) {
+		r.TableName = "meta._migrations"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedQuery := "CREATE TABLE IF NOT EXISTS `meta`.`_migrations` (file VARCHAR(255) NOT NULL, applied INTEGER NOT NULL, `reverted` INTEGER DEFAULT 0 NOT NULL, `applied_by` TEXT DEFAULT '' NOT NULL, `set` VARCHAR(255) DEFAULT '' NOT NULL, `run_id` TEXT DEFAULT '' NOT NULL, `confirmed` INTEGER DEFAULT 1 NOT NULL, `log` TEXT DEFAULT '' NOT NULL, PRIMARY KEY (`file`, `set`))"
+	if !list.ExistInSlice(expectedQuery, testDB.CalledQueries) {
+		t.Fatalf("Query %s was not found in \n%v", expectedQuery, testDB.CalledQueries)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, err := r.IsApplied("1_test"); err != nil || !applied {
+		t.Fatalf("Expected 1_test to be applied, got %v (%v)", applied, err)
+	}
+
+	var total int
+	testDB.Select("count(*)").From("meta._migrations").Row(&total)
+	if total != 1 {
+		t.Fatalf("Expected 1 record in meta._migrations, got %d", total)
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, err := r.IsApplied("1_test"); err != nil || applied {
+		t.Fatalf("Expected 1_test to be reverted, got %v (%v)", applied, err)
+	}
+}
+
+func TestRunnerConcurrentUp(t *testing.T) {
+	// use a file db so that each connection shares the same data
+	sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := dbx.NewFromDB(sqlDB, "sqlite")
+	defer db.Close()
+
+	var calls int32
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, err := db.NewQuery("CREATE TABLE demo (id TEXT)").Execute()
+		return err
+	}, nil, "1_test")
+
+	r, err := NewRunner(db, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Up()
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected all concurrent Up calls to succeed, got %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected the migration to be applied once, got %d", calls)
+	}
+}
+
+func TestRunnerApplier(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+
+	r.Applier = "web-01@abc123"
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 2 || applied[0].AppliedBy != "" || applied[1].AppliedBy != "web-01@abc123" {
+		t.Fatalf("Expected only 2_test to have applied_by, got %v", applied)
+	}
+}
+
+func TestRunnerContinueOnError(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	createTable := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			_, err := db.NewQuery("CREATE TABLE " + name + " (id TEXT)").Execute()
+			return err
+		}
+	}
+
+	failing := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			if err := createTable(name)(db); err != nil {
+				return err
+			}
+			return errors.New("test error")
+		}
+	}
+
+	l := MigrationsList{}
+	l.Register(createTable("t1"), nil, "1_test")
+	l.Register(failing("t2"), nil, "2_test")
+	l.Register(createTable("t3"), nil, "3_test")
+	l.Register(failing("t4"), nil, "4_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.ContinueOnError = true
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected error for ContinueOnError without PerMigrationTx")
+	}
+
+	r.PerMigrationTx = true
+
+	applied, err := r.Up()
+
+	var migrationsErr *MigrationsError
+	if !errors.As(err, &migrationsErr) || len(migrationsErr.Errors) != 2 {
+		t.Fatalf("Expected MigrationsError with 2 errors, got %v", err)
+	}
+
+	if len(applied) != 2 || applied[0] != "1_test" || applied[1] != "3_test" {
+		t.Fatalf("Expected 1_test and 3_test to be applied, got %v", applied)
+	}
+
+	// the failed migrations changes should be rolled back
+	for table, exists := range map[string]bool{"t1": true, "t2": false, "t3": true, "t4": false} {
+		var total int
+		testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "table", "name": table}).Row(&total)
+		if (total > 0) != exists {
+			t.Fatalf("Expected table %s exists to be %v", table, exists)
+		}
+	}
+}
+
+func TestRunnerValidate(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	maxRows := 0
+	upCalled := false
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		if _, err := db.NewQuery("CREATE TABLE demo (id TEXT)").Execute(); err != nil {
+			return err
+		}
+		_, err := db.Insert("demo", dbx.Params{"id": "test"}).Execute()
+		return err
+	}, nil, "1_test")
+	l.Add(&Migration{
+		File: "2_test",
+		Validate: func(db dbx.Builder) error {
+			// should see the changes of the previous migration
+			var total int
+			if err := db.Select("count(*)").From("demo").Row(&total); err != nil {
+				return err
+			}
+			if total > maxRows {
+				return fmt.Errorf("expected at most %d demo rows, got %d", maxRows, total)
+			}
+			return nil
+		},
+		Up: func(db dbx.Builder) error {
+			upCalled = true
+			return nil
+		},
+	})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Up()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.File != "2_test" {
+		t.Fatalf("Expected 2_test ValidationError, got %v", err)
+	}
+
+	if expected := "Cannot apply migration 2_test: expected at most 0 demo rows, got 1"; err.Error() != expected {
+		t.Fatalf("Expected error %q, got %q", expected, err.Error())
+	}
+
+	if upCalled {
+		t.Fatal("Expected the 2_test up func to not be called")
+	}
+
+	maxRows = 1
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !upCalled {
+		t.Fatal("Expected the 2_test up func to be called")
+	}
+}
+
+func TestRunnerClose(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return errors.New("test error") }, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected Up error, got nil")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Close(); err != nil {
+			t.Fatalf("(%d) Expected nil Close error, got %v", i, err)
+		}
+	}
+
+	if _, err := r.Up(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected Up ErrClosed, got %v", err)
+	}
+
+	if _, err := r.Down(1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected Down ErrClosed, got %v", err)
+	}
+
+	if err := r.RunOne("1_test", DirectionUp, true); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected RunOne ErrClosed, got %v", err)
+	}
+
+	// the db should remain usable
+	if err := testDB.DB.DB().Ping(); err != nil {
+		t.Fatalf("Expected the db to not be closed, got %v", err)
+	}
+}
+
+func TestRunnerCommitEvery(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	var fail bool
+
+	createTable := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			if _, err := db.NewQuery("CREATE TABLE " + name + " (id TEXT)").Execute(); err != nil {
+				return err
+			}
+			if fail && name == "t4" {
+				return errors.New("test error")
+			}
+			return nil
+		}
+	}
+
+	l := MigrationsList{}
+	for i := 1; i <= 5; i++ {
+		l.Register(createTable(fmt.Sprintf("t%d", i)), nil, fmt.Sprintf("%d_test", i))
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CommitEvery = 2
+
+	fail = true
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// only the first batch should be committed
+	for table, exists := range map[string]bool{"t1": true, "t2": true, "t3": false, "t4": false, "t5": false} {
+		var total int
+		testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "table", "name": table}).Row(&total)
+		if (total > 0) != exists {
+			t.Fatalf("Expected table %s exists to be %v", table, exists)
+		}
+	}
+
+	for file, expected := range map[string]bool{"1_test": true, "2_test": true, "3_test": false, "4_test": false} {
+		if applied := r.isMigrationApplied(testDB, "", file); applied != expected {
+			t.Fatalf("Expected %s applied to be %v, got %v", file, expected, applied)
+		}
+	}
+
+	// resume from the last committed batch
+	fail = false
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "3_test,4_test,5_test"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+}
+
 // -------------------------------------------------------------------
 // Helpers
 // -------------------------------------------------------------------
@@ -143,3 +1758,341 @@ func createTestDB() (*testDB, error) {
 
 	return &db, nil
 }
+
+// filteredMigrations is a custom Migrations implementation
+// that exposes only the migrations with the specified suffix.
+type filteredMigrations struct {
+	list   MigrationsList
+	suffix string
+}
+
+func (f filteredMigrations) Items() []*Migration {
+	result := []*Migration{}
+	for _, m := range f.list.Items() {
+		if strings.HasSuffix(m.File, f.suffix) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (f filteredMigrations) Len() int {
+	return len(f.Items())
+}
+
+func (f filteredMigrations) Item(index int) *Migration {
+	items := f.Items()
+	if index < 0 || index >= len(items) {
+		return nil
+	}
+	return items[index]
+}
+
+func TestNewRunnerCustomMigrations(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test.go")
+	l.Register(noop, noop, "2_test.sql")
+	l.Register(noop, noop, "3_test.go")
+
+	r, err := NewRunner(testDB.DB, filteredMigrations{list: l, suffix: ".go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "1_test.go,3_test.go"; strings.Join(applied, ",") != expected {
+		t.Fatalf("Expected applied %s, got %v", expected, applied)
+	}
+
+	// nil migrations
+	r, err = NewRunner(testDB.DB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.migrationsList.Len() != 0 {
+		t.Fatalf("Expected empty migrations, got %d", r.migrationsList.Len())
+	}
+}
+
+func TestRunnerOnSkip(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+
+	skipped := []string{}
+	r.OnSkip = func(file string) {
+		skipped = append(skipped, file)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "1_test"; strings.Join(skipped, ",") != expected {
+		t.Fatalf("Expected skipped %s, got %v", expected, skipped)
+	}
+
+	// verbose
+	skipped = []string{}
+
+	originalStdout := os.Stdout
+	defer func() { os.Stdout = originalStdout }()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+
+	runErr := r.Run("up", "--verbose")
+
+	writer.Close()
+	os.Stdout = originalStdout
+
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	output, _ := io.ReadAll(reader)
+
+	expectedOutput := "Skipping already-applied 1_test\nSkipping already-applied 2_test\n"
+	if string(output) != expectedOutput {
+		t.Fatalf("Expected output \n%s, \ngot \n%s", expectedOutput, output)
+	}
+
+	// the original callback is still invoked
+	if expected := "1_test,2_test"; strings.Join(skipped, ",") != expected {
+		t.Fatalf("Expected skipped %s, got %v", expected, skipped)
+	}
+}
+
+func TestNewRunnerIncompatibleTable(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	// table created by another tool (missing the required "applied" column)
+	_, err = testDB.NewQuery("CREATE TABLE `other` (file VARCHAR(255) PRIMARY KEY NOT NULL, executed_at TEXT)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		readOnly bool
+	}{
+		{false},
+		{true},
+	}
+
+	for i, s := range scenarios {
+		_, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+			r.TableName = "other"
+			r.ReadOnly = s.readOnly
+		})
+
+		if !errors.Is(err, ErrIncompatibleMigrationsTable) {
+			t.Errorf("(%d) Expected ErrIncompatibleMigrationsTable, got %v", i, err)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), `missing column "applied"`) {
+			t.Errorf("(%d) Expected the missing column in the error, got %v", i, err)
+		}
+	}
+
+	// the optional columns of a legacy table are still upgraded
+	_, err = testDB.NewQuery("CREATE TABLE `legacy` (file VARCHAR(255) PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)").Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) { r.TableName = "legacy" }); err != nil {
+		t.Fatalf("Expected the legacy table to be upgraded, got %v", err)
+	}
+}
+
+func TestNewRunnerCreateTableSQL(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	scenarios := []struct {
+		table       string
+		statements  func(defaultSQL string) []string
+		expectError bool
+	}{
+		{
+			"custom_index",
+			func(defaultSQL string) []string {
+				return []string{defaultSQL, "CREATE INDEX IF NOT EXISTS idx_custom_index_applied ON `custom_index` (applied)"}
+			},
+			false,
+		},
+		{
+			"custom_minimal",
+			func(defaultSQL string) []string {
+				return []string{"CREATE TABLE IF NOT EXISTS `custom_minimal` (file TEXT PRIMARY KEY NOT NULL, applied INTEGER NOT NULL)"}
+			},
+			false,
+		},
+		{
+			"custom_missing",
+			func(defaultSQL string) []string {
+				return []string{"CREATE TABLE IF NOT EXISTS `custom_missing` (file TEXT PRIMARY KEY NOT NULL)"}
+			},
+			true,
+		},
+		{
+			"custom_empty",
+			func(defaultSQL string) []string {
+				return nil
+			},
+			true,
+		},
+	}
+
+	for i, s := range scenarios {
+		r, err := NewRunner(testDB.DB, MigrationsList{}, func(r *Runner) {
+			r.TableName = s.table
+			r.CreateTableSQL = s.statements
+		})
+
+		if s.expectError {
+			if !errors.Is(err, ErrIncompatibleMigrationsTable) {
+				t.Errorf("(%d) Expected ErrIncompatibleMigrationsTable, got %v", i, err)
+			}
+
+			var total int
+			testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "table", "name": s.table}).Row(&total)
+			if total != 0 {
+				t.Errorf("(%d) Expected the invalid table creation to be rolled back", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("(%d) Expected nil error, got %v", i, err)
+			continue
+		}
+
+		// the missing tracking columns are added
+		columns, err := r.migrationsTableColumns(testDB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range optionalMigrationsTableColumns {
+			if !list.ExistInSlice(c.name, columns) {
+				t.Errorf("(%d) Expected column %q to exist, got %v", i, c.name, columns)
+			}
+		}
+	}
+
+	var indexes int
+	testDB.Select("count(*)").From("sqlite_master").Where(dbx.HashExp{"type": "index", "name": "idx_custom_index_applied"}).Row(&indexes)
+	if indexes != 1 {
+		t.Fatal("Expected the custom index to be created")
+	}
+}
+
+func TestRunnerZeroAppliedRecords(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := []string{}
+
+	l := MigrationsList{}
+	for _, file := range []string{"1_test", "2_test"} {
+		file := file
+		l.Register(func(db dbx.Builder) error {
+			calls = append(calls, file)
+			return nil
+		}, nil, file)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.saveAppliedMigration(testDB, "", "1_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// legacy reverted record kept with applied=0
+	_, err = testDB.Insert(r.TableName, dbx.Params{"file": "2_test", "applied": 0}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "1_test") {
+		t.Fatal("Expected 1_test to be applied")
+	}
+
+	if r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected the applied=0 record of 2_test to not be treated as applied")
+	}
+
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0].File != "1_test" {
+		t.Fatalf("Expected only 1_test to be in the applied migrations, got %v", applied)
+	}
+
+	pending, err := r.PendingMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pending, ",") != "2_test" {
+		t.Fatalf("Expected 2_test to be pending, got %v", pending)
+	}
+
+	// the applied=0 record is replaced on apply
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(calls, ",") != "2_test" {
+		t.Fatalf("Expected only 2_test to be executed, got %v", calls)
+	}
+
+	if !r.isMigrationApplied(testDB, "", "2_test") {
+		t.Fatal("Expected 2_test to be applied")
+	}
+}
@@ -0,0 +1,123 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+// setNameRegex defines the allowed migrations set name format.
+var setNameRegex = regexp.MustCompile(`^\w+$`)
+
+type migrationsSet struct {
+	name   string
+	list   Migrations // the snapshot of source (see Runner.Refresh)
+	source Migrations
+}
+
+// RegisterSet registers an additional named migrations set
+// (eg. environment specific seed migrations).
+//
+// The sets migrations are tracked in the same migrations table,
+// distinguished by their set name, so the same file name could be
+// used in different sets. The migrations of the list passed to
+// NewRunner belong to the default (unnamed) set.
+//
+// Note that the list migrations are assigned to the set and
+// the same list shouldn't be registered more than once.
+//
+// Same as the NewRunner list, the set migrations are snapshotted
+// on registration (see Refresh).
+func (r *Runner) RegisterSet(name string, migrationsList Migrations) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !setNameRegex.MatchString(name) {
+		return fmt.Errorf("Invalid migrations set name %q", name)
+	}
+
+	for _, s := range r.sets {
+		if s.name == name {
+			return fmt.Errorf("Migrations set %q is already registered", name)
+		}
+	}
+
+	snapshot := snapshotMigrations(migrationsList)
+
+	if err := r.checkRegisteredNames(snapshot); err != nil {
+		return err
+	}
+
+	for _, m := range snapshot.Items() {
+		m.set = name
+	}
+
+	r.sets = append(r.sets, migrationsSet{name: name, list: snapshot, source: migrationsList})
+
+	return nil
+}
+
+// items returns the default set migrations and the migrations of the
+// selected named sets (see Runner.Sets) in their apply order.
+func (r *Runner) items() []*Migration {
+	if len(r.sets) == 0 {
+		return r.migrationsList.Items()
+	}
+
+	result := append([]*Migration{}, r.migrationsList.Items()...)
+
+	for _, s := range r.sets {
+		if len(r.Sets) > 0 && !list.ExistInSlice(s.name, r.Sets) {
+			continue
+		}
+		result = append(result, s.list.Items()...)
+	}
+
+	sort.SliceStable(result, func(i int, j int) bool {
+		return migrationLess(result[i], result[j])
+	})
+
+	return result
+}
+
+// checkSets returns an error if any of the selected sets is not
+// registered or if the migrations table has applied migrations of
+// a set that is not registered with the runner (eg. due to a renamed set).
+func (r *Runner) checkSets() error {
+	registered := make([]string, 0, len(r.sets)+1)
+	registered = append(registered, "")
+	for _, s := range r.sets {
+		registered = append(registered, s.name)
+	}
+
+	for _, name := range r.Sets {
+		if !list.ExistInSlice(name, registered) {
+			return fmt.Errorf("Unknown migrations set %q", name)
+		}
+	}
+
+	var stored []string
+	err := r.withTrackingRetry(func() error {
+		return r.builder().Select("set").
+			Distinct(true).
+			From(r.TableName).
+			Column(&stored)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stored {
+		if !list.ExistInSlice(name, registered) {
+			return fmt.Errorf(
+				"The migrations table has applied migrations of the unregistered set %q "+
+					"(make sure that the same set names are used across runs)",
+				name,
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerRegisterSet(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name        string
+		expectError bool
+	}{
+		{"", true},
+		{"invalid name", true},
+		{"staging", false},
+		{"staging", true}, // duplicated
+		{"demo_data", false},
+	}
+
+	for i, s := range scenarios {
+		err := r.RegisterSet(s.name, MigrationsList{})
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+}
+
+func TestRunnerSets(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := []string{}
+	callsFunc := func(name string) func(db dbx.Builder) error {
+		return func(db dbx.Builder) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	core := MigrationsList{}
+	core.Register(callsFunc("core_1"), callsFunc("core_1_down"), "1_test")
+	core.Register(callsFunc("core_3"), callsFunc("core_3_down"), "3_test")
+
+	staging := MigrationsList{}
+	staging.Register(callsFunc("staging_1"), callsFunc("staging_1_down"), "1_test")
+	staging.Register(callsFunc("staging_2"), callsFunc("staging_2_down"), "2_test")
+
+	demo := MigrationsList{}
+	demo.Register(callsFunc("demo_4"), callsFunc("demo_4_down"), "4_test")
+
+	r, err := NewRunner(testDB.DB, core)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Confirm = func(message string) (bool, error) { return true, nil }
+
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterSet("demo", demo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Run("up", "--set", "unknown"); err == nil {
+		t.Fatal("Expected unknown set error")
+	}
+
+	if err := r.Run("up", "--set", "staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Sets) != 0 {
+		t.Fatalf("Expected the Sets to be restored after the run, got %v", r.Sets)
+	}
+
+	expectedCalls := "core_1,staging_1,staging_2,core_3"
+	if result := strings.Join(calls, ","); result != expectedCalls {
+		t.Fatalf("Expected calls %s, got %s", expectedCalls, result)
+	}
+
+	var total int
+	testDB.Select("count(*)").From(r.TableName).Where(dbx.HashExp{"file": "1_test"}).Row(&total)
+	if total != 2 {
+		t.Fatalf("Expected 2 separate 1_test records, got %d", total)
+	}
+
+	// revert the last applied staging migration
+	calls = []string{}
+	if err := r.Run("down", "1", "--set=demo,staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCalls = "core_3_down"
+	if result := strings.Join(calls, ","); result != expectedCalls {
+		t.Fatalf("Expected calls %s, got %s", expectedCalls, result)
+	}
+
+	// all sets
+	calls = []string{}
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCalls = "core_3,demo_4"
+	if result := strings.Join(calls, ","); result != expectedCalls {
+		t.Fatalf("Expected calls %s, got %s", expectedCalls, result)
+	}
+
+	// unregistered set records
+	r2, err := NewRunner(testDB.DB, core)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2.RegisterSet("staging", MigrationsList{})
+	if _, err := r2.Up(); err == nil || !strings.Contains(err.Error(), `"demo"`) {
+		t.Fatalf("Expected unregistered set error, got %v", err)
+	}
+}
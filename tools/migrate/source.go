@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SourcePath resolves the source file path of the migration with the
+// specified recorded file name (eg. to open the failing migration in
+// an editor) and reports whether the source file exists.
+//
+// The source of the migrations registered with RegisterFS is looked up
+// in their fs.FS and the returned path is relative to the fs root.
+// For all other migrations the path is resolved based on the runner
+// migrations dir (see Runner.Dir).
+func (r *Runner) SourcePath(file string) (string, bool) {
+	for _, m := range r.items() {
+		if m.File != file || m.fsys == nil {
+			continue
+		}
+
+		_, base := splitNamespace(m.File)
+
+		_, err := fs.Stat(m.fsys, base)
+
+		return base, err == nil
+	}
+
+	dir, err := r.migrationsDir()
+	if err != nil {
+		return "", false
+	}
+
+	path := filepath.Join(dir, file)
+
+	info, err := os.Stat(path)
+
+	return path, err == nil && !info.IsDir()
+}
@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRunnerSourcePath(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "1_test.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test.go")
+	l.Register(nil, nil, "2_missing.go")
+	l.RegisterFS(fstest.MapFS{"3_fs.sql": {Data: []byte("-- +up\nSELECT 1;")}})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Dir = dir
+
+	scenarios := []struct {
+		file         string
+		expectedPath string
+		expectExists bool
+	}{
+		{"1_test.go", filepath.Join(dir, "1_test.go"), true},
+		{"2_missing.go", filepath.Join(dir, "2_missing.go"), false},
+		{"3_fs.sql", "3_fs.sql", true},
+		{"4_unknown.go", filepath.Join(dir, "4_unknown.go"), false},
+	}
+
+	for i, s := range scenarios {
+		path, exists := r.SourcePath(s.file)
+
+		if path != s.expectedPath {
+			t.Errorf("(%d) Expected path %q, got %q", i, s.expectedPath, path)
+		}
+
+		if exists != s.expectExists {
+			t.Errorf("(%d) Expected exists %v, got %v", i, s.expectExists, exists)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/dbx"
+)
+
+// List with the supported SQL migration annotations.
+//
+// The annotations must be placed on their own line, eg.:
+//
+//	-- +up
+//	CREATE TABLE demo (id TEXT PRIMARY KEY);
+//
+//	-- +statement-begin
+//	CREATE TRIGGER demo_trigger AFTER INSERT ON demo BEGIN
+//		UPDATE demo SET id = id;
+//	END;
+//	-- +statement-end
+//
+//	-- +down
+//	DROP TABLE demo;
+const (
+	SQLAnnotationUp             = "-- +up"
+	SQLAnnotationDown           = "-- +down"
+	SQLAnnotationStatementBegin = "-- +statement-begin"
+	SQLAnnotationStatementEnd   = "-- +statement-end"
+)
+
+// DefaultSQLDelimiter is the default SQL statements terminator.
+const DefaultSQLDelimiter = ";"
+
+// SQLParser splits raw SQL migrations content into separate statements.
+//
+// Statements are split by the Delimiter, ignoring delimiters inside
+// string literals, quoted identifiers and comments. Multi-statement
+// bodies (eg. triggers) could be wrapped with the
+// "-- +statement-begin" and "-- +statement-end" annotations
+// to be treated as a single statement.
+type SQLParser struct {
+	// Delimiter is the statements terminator (default to ";").
+	Delimiter string
+}
+
+// ParseMigration parses an annotated SQL migration content
+// and returns its "-- +up" and "-- +down" sections statements.
+//
+// The "-- +down" section is optional and hasDown reports whether it was found.
+func (p *SQLParser) ParseMigration(content string) (up []string, down []string, hasDown bool, err error) {
+	sections := map[string]*strings.Builder{}
+	var current *strings.Builder
+
+	for _, line := range strings.Split(content, "\n") {
+		switch annotation := normalizeSQLAnnotation(line); annotation {
+		case SQLAnnotationUp, SQLAnnotationDown:
+			if sections[annotation] != nil {
+				return nil, nil, false, fmt.Errorf("Duplicated %q section", annotation)
+			}
+			current = &strings.Builder{}
+			sections[annotation] = current
+			continue
+		}
+
+		if current == nil {
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "--") {
+				continue // allow header comments
+			}
+			return nil, nil, false, fmt.Errorf("Statement outside of an %q or %q section: %q", SQLAnnotationUp, SQLAnnotationDown, line)
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+
+	if sections[SQLAnnotationUp] == nil {
+		return nil, nil, false, fmt.Errorf("Missing %q section", SQLAnnotationUp)
+	}
+
+	up, err = p.ParseStatements(sections[SQLAnnotationUp].String())
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("Invalid %q section: %w", SQLAnnotationUp, err)
+	}
+
+	if sections[SQLAnnotationDown] != nil {
+		hasDown = true
+		down, err = p.ParseStatements(sections[SQLAnnotationDown].String())
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("Invalid %q section: %w", SQLAnnotationDown, err)
+		}
+	}
+
+	return up, down, hasDown, nil
+}
+
+// ParseStatements splits the provided raw SQL into separate statements.
+func (p *SQLParser) ParseStatements(sql string) ([]string, error) {
+	delimiter := p.Delimiter
+	if delimiter == "" {
+		delimiter = DefaultSQLDelimiter
+	}
+
+	result := []string{}
+
+	var current strings.Builder
+	var hasContent bool // whether current contains anything other than whitespaces and comments
+	var inBlock bool    // inside a "-- +statement-begin" block
+	var quote byte      // the current string literal/identifier quote character (if any)
+	var inComment bool  // inside a multi-line /* */ comment
+	var blockLine int   // the line where the last block started (for error reporting)
+
+	flush := func() {
+		if hasContent {
+			result = append(result, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasContent = false
+	}
+
+	for i, line := range strings.Split(sql, "\n") {
+		if quote == 0 && !inComment {
+			switch normalizeSQLAnnotation(line) {
+			case SQLAnnotationStatementBegin:
+				if inBlock {
+					return nil, fmt.Errorf("Nested %q at line %d", SQLAnnotationStatementBegin, i+1)
+				}
+				if hasContent {
+					return nil, fmt.Errorf("Unterminated statement before %q at line %d", SQLAnnotationStatementBegin, i+1)
+				}
+				current.Reset()
+				inBlock = true
+				blockLine = i + 1
+				continue
+			case SQLAnnotationStatementEnd:
+				if !inBlock {
+					return nil, fmt.Errorf("Unexpected %q at line %d", SQLAnnotationStatementEnd, i+1)
+				}
+				flush()
+				inBlock = false
+				continue
+			}
+		}
+
+		// scan the line characters to find the unquoted delimiters
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+
+			switch {
+			case inComment:
+				if strings.HasPrefix(line[j:], "*/") {
+					inComment = false
+					current.WriteString("*/")
+					j++
+					continue
+				}
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case strings.HasPrefix(line[j:], "--"):
+				// the rest of the line is a comment
+				current.WriteString(line[j:])
+				j = len(line)
+				continue
+			case strings.HasPrefix(line[j:], "/*"):
+				inComment = true
+				current.WriteString("/*")
+				j++
+				continue
+			case c == '\'' || c == '"' || c == '`':
+				quote = c
+				hasContent = true
+			case !inBlock && strings.HasPrefix(line[j:], delimiter):
+				flush()
+				j += len(delimiter) - 1
+				continue
+			default:
+				if c != ' ' && c != '\t' && c != '\r' {
+					hasContent = true
+				}
+			}
+
+			current.WriteByte(c)
+		}
+
+		current.WriteByte('\n')
+	}
+
+	if inBlock {
+		return nil, fmt.Errorf("Missing %q for the block started at line %d", SQLAnnotationStatementEnd, blockLine)
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated quoted string (%c)", quote)
+	}
+
+	if inComment {
+		return nil, errors.New("Unterminated /* comment")
+	}
+
+	// the last statement may not have a trailing delimiter
+	flush()
+
+	return result, nil
+}
+
+// RegisterSQL adds new annotated SQL migration definition to the list.
+//
+// The content is parsed (and validated) with the optional parser,
+// otherwise the default ";" delimited parser is used.
+func (l *MigrationsList) RegisterSQL(file string, content string, optParser ...*SQLParser) error {
+	parser := &SQLParser{}
+	if len(optParser) > 0 && optParser[0] != nil {
+		parser = optParser[0]
+	}
+
+	up, down, hasDown, err := parser.ParseMigration(content)
+	if err != nil {
+		return fmt.Errorf("Failed to parse SQL migration %s: %w", file, err)
+	}
+
+	m := &Migration{
+		File:       file,
+		Up:         sqlStatementsFunc(up),
+		isSQL:      true,
+		upSQL:      up,
+		downSQL:    down,
+		hasDownSQL: hasDown,
+	}
+	if hasDown {
+		m.Down = sqlStatementsFunc(down)
+	}
+
+	l.Add(m)
+
+	return nil
+}
+
+// RegisterFS lazily registers all annotated ".sql" migration files
+// from the root of the provided fsys.
+//
+// Only the file names are read on registration. The content of each
+// migration file is read and parsed only when its Up or Down func is
+// executed, which keeps the startup cheap even for very large sets.
+//
+// As with RegisterSQL, reverting a migration without "-- +down"
+// section is a no-op.
+func (l *MigrationsList) RegisterFS(fsys fs.FS, optParser ...*SQLParser) error {
+	parser := &SQLParser{}
+	if len(optParser) > 0 && optParser[0] != nil {
+		parser = optParser[0]
+	}
+
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		file := file // the migration File could be later namespaced
+		m := &Migration{File: file, isSQL: true, fsys: fsys}
+
+		var loadErr error
+		var loadOnce sync.Once
+		m.load = func() error {
+			loadOnce.Do(func() {
+				content, err := fs.ReadFile(fsys, file)
+				if err != nil {
+					loadErr = err
+					return
+				}
+
+				m.upSQL, m.downSQL, m.hasDownSQL, err = parser.ParseMigration(string(content))
+				if err != nil {
+					loadErr = fmt.Errorf("Failed to parse SQL migration %s: %w", m.File, err)
+				}
+			})
+			return loadErr
+		}
+
+		m.Up = func(db dbx.Builder) error {
+			if err := m.load(); err != nil {
+				return err
+			}
+			return sqlStatementsFunc(m.upSQL)(db)
+		}
+
+		m.Down = func(db dbx.Builder) error {
+			if err := m.load(); err != nil {
+				return err
+			}
+			if !m.hasDownSQL {
+				return nil
+			}
+			return sqlStatementsFunc(m.downSQL)(db)
+		}
+
+		l.Add(m)
+	}
+
+	return nil
+}
+
+// sqlStatementsFunc returns a migration func that executes
+// the provided SQL statements one by one.
+func sqlStatementsFunc(statements []string) func(db dbx.Builder) error {
+	return func(db dbx.Builder) error {
+		return execSQLStatements(context.Background(), NewDBExecutor(db), statements)
+	}
+}
+
+// execSQLStatements executes the provided SQL statements one by one with executor.
+func execSQLStatements(ctx context.Context, executor Executor, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := executor.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("Failed to execute %q: %w", stmt, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeSQLAnnotation returns the lowercased trimmed line
+// (used for annotation matching).
+func normalizeSQLAnnotation(line string) string {
+	return strings.ToLower(strings.TrimSpace(line))
+}
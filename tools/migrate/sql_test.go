@@ -0,0 +1,289 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSQLParserParseStatements(t *testing.T) {
+	scenarios := []struct {
+		delimiter   string
+		sql         string
+		expectError bool
+		expected    []string
+	}{
+		{
+			"",
+			"",
+			false,
+			[]string{},
+		},
+		{
+			"",
+			"-- only comments\n/* ; */",
+			false,
+			[]string{},
+		},
+		{
+			"",
+			"SELECT 1; SELECT 2;\nSELECT 3",
+			false,
+			[]string{"SELECT 1", "SELECT 2", "SELECT 3"},
+		},
+		{
+			"",
+			"CREATE TABLE demo (name TEXT CHECK (name <> ';'), `a;b` TEXT, \"c;d\" TEXT);\nINSERT INTO demo (name) VALUES ('it''s; ok');",
+			false,
+			[]string{
+				"CREATE TABLE demo (name TEXT CHECK (name <> ';'), `a;b` TEXT, \"c;d\" TEXT)",
+				"INSERT INTO demo (name) VALUES ('it''s; ok')",
+			},
+		},
+		{
+			"",
+			"SELECT 1; -- comment; with delimiter\nSELECT /* ; */ 2;",
+			false,
+			[]string{"SELECT 1", "-- comment; with delimiter\nSELECT /* ; */ 2"},
+		},
+		{
+			"",
+			"CREATE TABLE demo (id TEXT);\n-- +statement-begin\nCREATE TRIGGER demo_trigger AFTER INSERT ON demo BEGIN\n  UPDATE demo SET id = id;\n  DELETE FROM demo WHERE id = ';';\nEND;\n-- +statement-end\nSELECT 1;",
+			false,
+			[]string{
+				"CREATE TABLE demo (id TEXT)",
+				"CREATE TRIGGER demo_trigger AFTER INSERT ON demo BEGIN\n  UPDATE demo SET id = id;\n  DELETE FROM demo WHERE id = ';';\nEND;",
+				"SELECT 1",
+			},
+		},
+		{
+			"$$",
+			"SELECT 1; SELECT 2$$SELECT '$$'$$",
+			false,
+			[]string{"SELECT 1; SELECT 2", "SELECT '$$'"},
+		},
+		{
+			"",
+			"SELECT 'unterminated;",
+			true,
+			nil,
+		},
+		{
+			"",
+			"SELECT 1 /* unterminated",
+			true,
+			nil,
+		},
+		{
+			"",
+			"-- +statement-begin\nSELECT 1;",
+			true,
+			nil,
+		},
+		{
+			"",
+			"SELECT 1;\n-- +statement-end",
+			true,
+			nil,
+		},
+		{
+			"",
+			"SELECT 1\n-- +statement-begin\nSELECT 2;\n-- +statement-end",
+			true,
+			nil,
+		},
+	}
+
+	for i, s := range scenarios {
+		p := &SQLParser{Delimiter: s.delimiter}
+
+		result, err := p.ParseStatements(s.sql)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if len(result) != len(s.expected) {
+			t.Errorf("(%d) Expected %d statements, got %d: \n%q", i, len(s.expected), len(result), result)
+			continue
+		}
+
+		for j, stmt := range s.expected {
+			if result[j] != stmt {
+				t.Errorf("(%d) Expected statement %d to be \n%q, \ngot \n%q", i, j, stmt, result[j])
+			}
+		}
+	}
+}
+
+func TestSQLParserParseMigration(t *testing.T) {
+	scenarios := []struct {
+		content       string
+		expectError   bool
+		expectUp      int
+		expectHasDown bool
+		expectDown    int
+	}{
+		{"", true, 0, false, 0},
+		{"SELECT 1;\n-- +up\nSELECT 2;", true, 0, false, 0},
+		{"-- +up\nSELECT 1;\n-- +up\nSELECT 2;", true, 0, false, 0},
+		{"-- header comment\n-- +up\nSELECT 1; SELECT 2;", false, 2, false, 0},
+		{"-- +UP\nSELECT 1;\n-- +down\n-- nothing to revert", false, 1, true, 0},
+		{"-- +down\nSELECT 3;\n-- +up\nSELECT 1;\nSELECT 2;", false, 2, true, 1},
+	}
+
+	for i, s := range scenarios {
+		p := &SQLParser{}
+
+		up, down, hasDown, err := p.ParseMigration(s.content)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if len(up) != s.expectUp {
+			t.Errorf("(%d) Expected %d up statements, got %q", i, s.expectUp, up)
+		}
+
+		if hasDown != s.expectHasDown {
+			t.Errorf("(%d) Expected hasDown %v, got %v", i, s.expectHasDown, hasDown)
+		}
+
+		if len(down) != s.expectDown {
+			t.Errorf("(%d) Expected %d down statements, got %q", i, s.expectDown, down)
+		}
+	}
+}
+
+func TestMigrationsListRegisterSQL(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+
+	if err := l.RegisterSQL("1_invalid.sql", "SELECT 1;"); err == nil {
+		t.Fatal("Expected parse error")
+	}
+
+	content := strings.Join([]string{
+		"-- +up",
+		"CREATE TABLE demo (id TEXT PRIMARY KEY, name TEXT CHECK (name <> ';'));",
+		"CREATE TABLE demo_log (id TEXT);",
+		"-- +statement-begin",
+		"CREATE TRIGGER demo_trigger AFTER INSERT ON demo BEGIN",
+		"  INSERT INTO demo_log (id) VALUES (NEW.id);",
+		"END;",
+		"-- +statement-end",
+		"INSERT INTO demo (id, name) VALUES ('1', 'a;b');",
+		"-- +down",
+		"DROP TABLE demo;",
+		"DROP TABLE demo_log;",
+	}, "\n")
+
+	if err := l.RegisterSQL("1_demo.sql", content); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := l.Item(0); !m.isSQL || len(m.upSQL) != 4 || len(m.downSQL) != 2 {
+		t.Fatalf("Expected the parsed statements to be stored, got %v", m)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	if err := testDB.Select("count(*)").From("demo_log").Row(&total); err != nil || total != 1 {
+		t.Fatalf("Expected the trigger to insert 1 log row, got %d (%v)", total, err)
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testDB.Select("count(*)").From("demo").Row(&total); err == nil {
+		t.Fatal("Expected the demo table to be dropped")
+	}
+}
+
+func TestMigrationsListRegisterFS(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	fsys := fstest.MapFS{
+		"1_demo.sql":    {Data: []byte("-- +up\nCREATE TABLE demo (id TEXT);\n-- +down\nDROP TABLE demo;")},
+		"2_no_down.sql": {Data: []byte("-- +up\nINSERT INTO demo (id) VALUES ('1');")},
+		"ignored.txt":   {Data: []byte("-- +up\nSELECT 1;")},
+	}
+
+	l := MigrationsList{}
+	if err := l.RegisterFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.Items()) != 2 {
+		t.Fatalf("Expected 2 registered migrations, got %d", len(l.Items()))
+	}
+
+	for _, m := range l.Items() {
+		if m.upSQL != nil {
+			t.Fatalf("Expected %s to not be loaded before its execution", m.File)
+		}
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := r.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations, got %v", applied)
+	}
+
+	if len(l.Item(0).upSQL) != 1 || len(l.Item(0).downSQL) != 1 {
+		t.Fatalf("Expected 1_demo.sql statements to be loaded, got %v", l.Item(0))
+	}
+
+	// missing down section (no-op as for RegisterSQL)
+	reverted, err := r.Down(1)
+	if err != nil {
+		t.Fatalf("Expected 2_no_down.sql to be reverted, got %v", err)
+	}
+	if len(reverted) != 1 || reverted[0] != "2_no_down.sql" {
+		t.Fatalf("Expected 2_no_down.sql to be reverted, got %v", reverted)
+	}
+
+	var total int
+	testDB.Select("count(*)").From("demo").Row(&total)
+	if total != 1 {
+		t.Fatalf("Expected the 2_no_down.sql changes to be kept, got %d rows", total)
+	}
+
+	// invalid content
+	fsys["3_invalid.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	l2 := MigrationsList{}
+	l2.RegisterFS(fsys)
+	r2, _ := NewRunner(testDB.DB, l2)
+	if _, err := r2.Up(); err == nil {
+		t.Fatal("Expected 3_invalid.sql parse error")
+	}
+}
@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// DefaultTimeFormat is the default Runner.TimeFormat layout.
+const DefaultTimeFormat = "2006-01-02 15:04:05 MST"
+
+// printTree writes all registered migrations in their apply order,
+// grouped by applied and pending, highlighting the current head
+// (aka. the last applied migration).
+func (r *Runner) printTree(w io.Writer) error {
+	appliedRecords, err := r.AppliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	appliedMap := make(map[string]AppliedMigration, len(appliedRecords))
+	for _, a := range appliedRecords {
+		appliedMap[a.Set+"/"+a.File] = a
+	}
+
+	applied := []*Migration{}
+	pending := []*Migration{}
+	var head *Migration
+	var headTime int64
+
+	for _, m := range r.items() {
+		a, ok := appliedMap[m.set+"/"+m.File]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+
+		applied = append(applied, m)
+
+		if a.Applied >= headTime {
+			head = m
+			headTime = a.Applied
+		}
+	}
+
+	groups := []struct {
+		title      string
+		migrations []*Migration
+	}{
+		{"Applied", applied},
+		{"Pending", pending},
+	}
+
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s (%d):\n", g.title, len(g.migrations))
+
+		for i, m := range g.migrations {
+			branch := "├─"
+			if i == len(g.migrations)-1 {
+				branch = "└─"
+			}
+
+			line := fmt.Sprintf("%s %s (%s)", branch, migrationName(m), r.migrationDate(m.File))
+			if a, ok := appliedMap[m.set+"/"+m.File]; ok {
+				line += " applied at " + r.formatTime(a.AppliedAt())
+				if a.AppliedBy != "" {
+					line += " by " + a.AppliedBy
+				}
+				if !a.Confirmed {
+					line += " [unconfirmed]"
+				}
+			}
+
+			if m.IrreversibleReason != "" {
+				line += " [irreversible: " + m.IrreversibleReason + "]"
+			}
+
+			if m == head {
+				color.New(color.FgGreen).Fprintf(w, "%s <- current head\n", line)
+			} else {
+				fmt.Fprintln(w, line)
+			}
+		}
+	}
+
+	return nil
+}
+
+// printAppliedSince writes the migrations applied in the last d duration.
+func (r *Runner) printAppliedSince(w io.Writer, d time.Duration) error {
+	records, err := r.AppliedSince(d)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Applied since %s (%d):\n", r.formatTime(r.now().Add(-d)), len(records))
+
+	for i, a := range records {
+		branch := "├─"
+		if i == len(records)-1 {
+			branch = "└─"
+		}
+
+		name := a.File
+		if a.Set != "" {
+			name = a.Set + "/" + a.File
+		}
+
+		line := fmt.Sprintf("%s %s applied at %s", branch, name, r.formatTime(a.AppliedAt()))
+		if a.AppliedBy != "" {
+			line += " by " + a.AppliedBy
+		}
+		if a.RunId != "" {
+			line += " (run " + a.RunId + ")"
+		}
+
+		fmt.Fprintln(w, line)
+
+		if a.Log != "" {
+			indent := "│  "
+			if i == len(records)-1 {
+				indent = "   "
+			}
+			for _, logLine := range strings.Split(strings.TrimRight(a.Log, "\n"), "\n") {
+				fmt.Fprintln(w, indent+"  "+logLine)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrationDate returns the human readable date
+// of the migration file timestamp prefix.
+func (r *Runner) migrationDate(file string) string {
+	ts, ok := migrationTimestamp(file)
+	if !ok {
+		return "unknown date"
+	}
+
+	return r.formatTime(time.Unix(ts, 0))
+}
+
+// formatTime formats t for the human readable commands output
+// based on the runner TimeFormat and TimeLocation settings.
+func (r *Runner) formatTime(t time.Time) string {
+	layout := r.TimeFormat
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+
+	loc := r.TimeLocation
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return t.In(loc).Format(layout)
+}
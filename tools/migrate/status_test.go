@@ -0,0 +1,176 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerPrintTree(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1656512345_init.go")
+	l.Register(nil, nil, "1656512400_posts.go")
+	l.Register(nil, nil, "1656513000_pending.go")
+	l.Register(nil, nil, "custom.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDB.Insert(r.TableName, dbx.Params{"file": "1656512345_init.go", "applied": 1656600000000000000}).Execute()
+	testDB.Insert(r.TableName, dbx.Params{"file": "1656512400_posts.go", "applied": 1656600060000000000, "applied_by": "test"}).Execute()
+
+	scenarios := []struct {
+		format   string
+		location *time.Location
+		expected []string
+	}{
+		{
+			"",
+			nil,
+			[]string{
+				"Applied (2):",
+				"├─ 1656512345_init.go (2022-06-29 14:19:05 UTC) applied at 2022-06-30 14:40:00 UTC",
+				"└─ 1656512400_posts.go (2022-06-29 14:20:00 UTC) applied at 2022-06-30 14:41:00 UTC by test <- current head",
+				"Pending (2):",
+				"├─ 1656513000_pending.go (2022-06-29 14:30:00 UTC)",
+				"└─ custom.go (unknown date)",
+				"",
+			},
+		},
+		{
+			time.RFC3339,
+			time.FixedZone("test", 2*60*60),
+			[]string{
+				"Applied (2):",
+				"├─ 1656512345_init.go (2022-06-29T16:19:05+02:00) applied at 2022-06-30T16:40:00+02:00",
+				"└─ 1656512400_posts.go (2022-06-29T16:20:00+02:00) applied at 2022-06-30T16:41:00+02:00 by test <- current head",
+				"Pending (2):",
+				"├─ 1656513000_pending.go (2022-06-29T16:30:00+02:00)",
+				"└─ custom.go (unknown date)",
+				"",
+			},
+		},
+	}
+
+	for i, s := range scenarios {
+		r.TimeFormat = s.format
+		r.TimeLocation = s.location
+
+		var b strings.Builder
+		if err := r.printTree(&b); err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		expected := strings.Join(s.expected, "\n")
+
+		if result := b.String(); result != expected {
+			t.Errorf("(%d) Expected \n%s, \ngot \n%s", i, expected, result)
+		}
+	}
+}
+
+func TestRunnerRunListSince(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test")
+	l.Register(nil, nil, "2_test")
+	l.Register(nil, nil, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.saveAppliedMigrationAt(testDB, "", "1_test", time.Now().Add(-72*time.Hour).UnixNano())
+	r.saveAppliedMigrationAt(testDB, "", "2_test", time.Now().Add(-2*time.Hour).UnixNano())
+
+	scenarios := []struct {
+		duration time.Duration
+		expected []string
+	}{
+		{time.Hour, []string{}},
+		{3 * time.Hour, []string{"2_test"}},
+		{4 * 24 * time.Hour, []string{"1_test", "2_test"}},
+	}
+
+	for i, s := range scenarios {
+		records, err := r.AppliedSince(s.duration)
+		if err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		files := []string{}
+		for _, a := range records {
+			files = append(files, a.File)
+		}
+
+		if strings.Join(files, ",") != strings.Join(s.expected, ",") {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, files)
+		}
+	}
+
+	originalOutput := color.Output
+	defer func() { color.Output = originalOutput }()
+
+	var b strings.Builder
+	color.Output = &b
+
+	if err := r.Run("list", "--since", "1d"); err != nil {
+		t.Fatal(err)
+	}
+
+	output := b.String()
+	if !strings.Contains(output, "(1):\n└─ 2_test applied at ") || strings.Contains(output, "1_test") || strings.Contains(output, "3_test") {
+		t.Fatalf("Expected only 2_test in the output, got \n%s", output)
+	}
+
+	if err := r.Run("list", "--since", "invalid"); err == nil {
+		t.Fatal("Expected invalid duration error, got nil")
+	}
+}
+
+func TestParseSinceDuration(t *testing.T) {
+	scenarios := []struct {
+		value       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{"", 0, true},
+		{"abc", 0, true},
+		{"-1h", 0, true},
+		{"-2d", 0, true},
+		{"30m", 30 * time.Minute, false},
+		{"12h", 12 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+	}
+
+	for i, s := range scenarios {
+		d, err := parseSinceDuration(s.value)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if d != s.expected {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, d)
+		}
+	}
+}
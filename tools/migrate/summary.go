@@ -0,0 +1,56 @@
+package migrate
+
+// Summary defines the compact migrations status
+// (eg. for an admin dashboard widget).
+type Summary struct {
+	// Total is the number of the registered migrations.
+	Total int `json:"total"`
+
+	// Applied is the number of the applied registered migrations.
+	Applied int `json:"applied"`
+
+	// Pending is the number of the unapplied registered migrations.
+	Pending int `json:"pending"`
+
+	// Head is the name of the last applied registered migration
+	// (prefixed with its set name, if any) or empty string if none.
+	Head string `json:"head"`
+}
+
+// Summary returns the registered migrations status, reconciling the
+// applied migrations records (loaded with a single query) against
+// the in-memory migrations list.
+func (r *Runner) Summary() (Summary, error) {
+	result := Summary{}
+
+	appliedRecords, err := r.AppliedMigrations()
+	if err != nil {
+		return result, err
+	}
+
+	appliedMap := make(map[string]int64, len(appliedRecords))
+	for _, a := range appliedRecords {
+		appliedMap[a.Set+"/"+a.File] = a.Applied
+	}
+
+	var headTime int64
+
+	for _, m := range r.items() {
+		result.Total++
+
+		applied, ok := appliedMap[m.set+"/"+m.File]
+		if !ok {
+			result.Pending++
+			continue
+		}
+
+		result.Applied++
+
+		if applied >= headTime {
+			result.Head = migrationName(m)
+			headTime = applied
+		}
+	}
+
+	return result, nil
+}
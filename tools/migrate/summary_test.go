@@ -0,0 +1,58 @@
+package migrate
+
+import "testing"
+
+func TestRunnerSummary(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(nil, nil, "1_test")
+	l.Register(nil, nil, "2_test")
+	l.Register(nil, nil, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staging := MigrationsList{}
+	staging.Register(nil, nil, "4_seed")
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := r.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := (Summary{Total: 4, Pending: 4}); summary != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, summary)
+	}
+
+	r.saveAppliedMigration(testDB, "", "1_test")
+	r.saveAppliedMigration(testDB, "staging", "4_seed")
+	r.saveAppliedMigration(testDB, "", "2_test")
+	r.saveAppliedMigration(testDB, "", "missing_test") // not registered
+
+	testDB.CalledQueries = nil
+
+	summary, err = r.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := len(testDB.CalledQueries)
+
+	if expected := (Summary{Total: 4, Applied: 3, Pending: 1, Head: "2_test"}); summary != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, summary)
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected a single query, got %d (%v)", calls, testDB.CalledQueries)
+	}
+}
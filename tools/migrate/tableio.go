@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+)
+
+// ExportTable writes the currently applied migrations records to w
+// as JSON lines (one AppliedMigration JSON object per line) in their
+// apply order, eg. to clone the migrations state of another
+// environment with ImportTable.
+func (r *Runner) ExportTable(w io.Writer) error {
+	records, err := r.AppliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportTable reads the JSON lines migrations records of ExportTable
+// from rd and inserts them as applied (in a single transaction),
+// preserving their apply time, applier, run id, confirmation and log.
+//
+// All imported records must refer to migrations registered with
+// the runner (in the default or in a named set), otherwise nothing
+// is imported. The records of the already applied migrations are skipped.
+//
+// Note that the migrations Up funcs are not executed, so the db
+// is expected to already have the state of the imported migrations
+// (eg. a fresh, but pre-seeded database).
+//
+// Returns the names of the imported migrations.
+func (r *Runner) ImportTable(rd io.Reader) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if r.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	records := []AppliedMigration{}
+
+	decoder := json.NewDecoder(rd)
+	for {
+		var record AppliedMigration
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("Failed to parse the migrations record %d: %w", len(records)+1, err)
+		}
+
+		records = append(records, record)
+	}
+
+	registered := map[string]struct{}{}
+	for _, m := range r.migrationsList.Items() {
+		registered[migrationName(m)] = struct{}{}
+	}
+	for _, s := range r.sets {
+		for _, m := range s.list.Items() {
+			registered[migrationName(m)] = struct{}{}
+		}
+	}
+
+	seen := make(map[string]struct{}, len(records))
+	missing := []string{}
+
+	for i, record := range records {
+		if record.File == "" {
+			return nil, fmt.Errorf("Missing file name of the migrations record %d", i+1)
+		}
+
+		if record.Applied <= 0 {
+			return nil, fmt.Errorf("Invalid applied time of the migrations record %d (%s)", i+1, record.name())
+		}
+
+		name := record.name()
+
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("Duplicated migrations record %s", name)
+		}
+		seen[name] = struct{}{}
+
+		if _, ok := registered[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf(
+			"The following imported migrations are not registered: %s",
+			strings.Join(missing, ", "),
+		)
+	}
+
+	imported := []string{}
+
+	err := r.db.Transactional(func(tx *dbx.Tx) error {
+		for _, record := range records {
+			applied, err := r.checkMigrationApplied(tx, record.Set, record.File)
+			if err != nil {
+				return err
+			}
+			if applied {
+				continue
+			}
+
+			if err := r.saveImportedMigration(tx, record); err != nil {
+				return fmt.Errorf("Failed to import migration %s: %w", record.name(), err)
+			}
+
+			imported = append(imported, record.name())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+// saveImportedMigration inserts the provided applied migration record.
+func (r *Runner) saveImportedMigration(tx dbx.Builder, record AppliedMigration) error {
+	return r.withTrackingRetry(func() error {
+		if err := r.cleanupInactiveRecords(tx, record.Set, record.File); err != nil {
+			return err
+		}
+
+		confirmed := 0
+		if record.Confirmed {
+			confirmed = 1
+		}
+
+		_, err := tx.Insert(r.TableName, dbx.Params{
+			"file":       record.File,
+			"applied":    record.Applied,
+			"applied_by": record.AppliedBy,
+			"set":        record.Set,
+			"run_id":     record.RunId,
+			"confirmed":  confirmed,
+			"log":        truncateLog(record.Log, r.maxLogLength()),
+		}).Execute()
+
+		return err
+	})
+}
+
+// name returns the record migration name prefixed
+// with its set name for the named sets migrations.
+func (a AppliedMigration) name() string {
+	if a.Set != "" {
+		return a.Set + "/" + a.File
+	}
+
+	return a.File
+}
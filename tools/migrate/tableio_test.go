@@ -0,0 +1,163 @@
+package migrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerExportImportTable(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test.go")
+	l.Register(noop, noop, "2_test.go")
+	l.Register(noop, noop, "3_test.go")
+
+	staging := MigrationsList{}
+	staging.Register(noop, noop, "4_seed.go")
+
+	sourceDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sourceDB.Close()
+
+	source, err := NewRunner(sourceDB.DB, l, func(r *Runner) { r.Applier = "prod" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := source.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.saveAppliedMigration(sourceDB, "", "1_test.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.saveAppliedMigration(sourceDB, "", "2_test.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.saveAppliedMigration(sourceDB, "staging", "4_seed.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := source.ExportTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("Expected 3 JSON lines, got %d:\n%s", lines, buf.String())
+	}
+
+	targetDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetDB.Close()
+
+	target, err := NewRunner(targetDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	// already applied in the target
+	if err := target.saveAppliedMigration(targetDB, "", "1_test.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := target.ImportTable(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := strings.Join(imported, ","); v != "2_test.go,staging/4_seed.go" {
+		t.Fatalf("Expected 2_test.go and staging/4_seed.go to be imported, got %v", v)
+	}
+
+	sourceRecords, err := source.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetRecords, err := target.AppliedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targetRecords) != 3 {
+		t.Fatalf("Expected 3 target records, got %v", targetRecords)
+	}
+
+	for _, s := range sourceRecords[1:] {
+		var found bool
+		for _, r := range targetRecords {
+			if r == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected the imported record %v to be preserved, got %v", s, targetRecords)
+		}
+	}
+
+	// idempotent
+	imported, err = target.ImportTable(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 0 {
+		t.Fatalf("Expected no imported migrations, got %v", imported)
+	}
+}
+
+func TestRunnerImportTableInvalid(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test.go")
+	l.Register(noop, noop, "2_test.go")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		data          string
+		expectedError string
+	}{
+		{`{"file":"1_test.go","applied":1}` + "\n" + `invalid`, "Failed to parse the migrations record 2"},
+		{`{"applied":1}`, "Missing file name of the migrations record 1"},
+		{`{"file":"1_test.go"}`, "Invalid applied time of the migrations record 1"},
+		{`{"file":"1_test.go","applied":1}` + "\n" + `{"file":"1_test.go","applied":2}`, "Duplicated migrations record 1_test.go"},
+		{
+			`{"file":"1_test.go","applied":1}` + "\n" + `{"file":"9_missing.go","applied":2}` + "\n" + `{"file":"2_test.go","set":"staging","applied":3}`,
+			"not registered: 9_missing.go, staging/2_test.go",
+		},
+	}
+
+	for i, s := range scenarios {
+		_, err := r.ImportTable(strings.NewReader(s.data))
+		if err == nil || !strings.Contains(err.Error(), s.expectedError) {
+			t.Errorf("(%d) Expected error containing %q, got %v", i, s.expectedError, err)
+		}
+
+		// nothing is imported
+		if total := r.appliedCount(); total != 0 {
+			t.Fatalf("(%d) Expected no applied migrations, got %d", i, total)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package migrate
+
+import "strings"
+
+// MigrationsForTable returns the names of the registered migrations that
+// declare the specified table in their Migration.AffectedTables
+// (eg. to find the migrations that must be reverted together).
+//
+// The table names are compared case-insensitively and the result is
+// in the migrations apply order (the named sets migrations are prefixed
+// with their set name, eg. "staging/1656512345_seed.go").
+func (r *Runner) MigrationsForTable(table string) []string {
+	result := []string{}
+
+	items, err := r.orderedItems()
+	if err != nil {
+		// fallback to the default order (eg. on dependencies cycle)
+		items = r.items()
+	}
+
+	for _, m := range items {
+		for _, affected := range m.AffectedTables {
+			if strings.EqualFold(affected, table) {
+				result = append(result, migrationName(m))
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunnerMigrationsForTable(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Add(&Migration{File: "3_orders_index.go", AffectedTables: []string{"orders"}})
+	l.Add(&Migration{File: "1_orders.go", AffectedTables: []string{"Orders", "users"}})
+	l.Add(&Migration{File: "2_users.go", AffectedTables: []string{"users"}})
+	l.Add(&Migration{File: "4_noop.go"})
+
+	staging := MigrationsList{}
+	staging.Add(&Migration{File: "5_seed.go", AffectedTables: []string{"orders"}})
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		table    string
+		expected []string
+	}{
+		{"orders", []string{"1_orders.go", "3_orders_index.go", "staging/5_seed.go"}},
+		{"USERS", []string{"1_orders.go", "2_users.go"}},
+		{"missing", []string{}},
+	}
+
+	for i, s := range scenarios {
+		result := r.MigrationsForTable(s.table)
+
+		if strings.Join(result, ",") != strings.Join(s.expected, ",") {
+			t.Errorf("(%d) Expected %v, got %v", i, s.expected, result)
+		}
+	}
+}
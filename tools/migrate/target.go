@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TargetVersionEnv is the name of the env variable with the target
+// migration version of Bootstrap (see Runner.TargetVersion).
+const TargetVersionEnv = "PB_MIGRATIONS_TARGET"
+
+// UpTo executes the unapplied migrations up to and including the
+// target migration, leaving the newer ones pending (eg. to gate the
+// application of already shipped migrations behind a config change).
+//
+// The target could be either a migration file name (prefixed with its
+// set name for the named sets migrations, eg. "staging/1656512345_seed.go")
+// or a timestamp, in which case the target is the last migration
+// with a timestamp prefix lower or equal to it.
+//
+// Note that the already applied migrations after the target are not reverted.
+//
+// On success returns list with the applied migrations file names.
+func (r *Runner) UpTo(target string) ([]string, error) {
+	if target == "" {
+		return nil, fmt.Errorf("Missing target migration version")
+	}
+
+	return r.up(nil, target, nil)
+}
+
+// targetItems returns the provided ordered migrations up to
+// and including the target version (if any, see Runner.UpTo).
+func (r *Runner) targetItems(migrations []*Migration, target string) ([]*Migration, error) {
+	if target == "" {
+		return migrations, nil
+	}
+
+	for i, m := range migrations {
+		if migrationName(m) == target {
+			return migrations[:i+1], nil
+		}
+	}
+
+	targetTs, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown target migration version %q", target)
+	}
+
+	last := -1
+	for i, m := range migrations {
+		if ts, ok := migrationTimestamp(m.File); ok && ts <= targetTs {
+			last = i
+		}
+	}
+
+	return migrations[:last+1], nil
+}
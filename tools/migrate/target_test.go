@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerUpTo(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	scenarios := []struct {
+		target      string
+		expectError bool
+		expected    []string
+	}{
+		{"", true, nil},
+		{"missing.go", true, nil},
+		{"2_test.go", false, []string{"1_test.go", "2_test.go"}},
+		{"staging/4_seed.go", false, []string{"1_test.go", "2_test.go", "3_test.go", "4_seed.go"}},
+		{"3", false, []string{"1_test.go", "2_test.go", "3_test.go"}},
+		{"0", false, []string{}},
+		{"100", false, []string{"1_test.go", "2_test.go", "3_test.go", "4_seed.go"}},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := MigrationsList{}
+		l.Register(noop, noop, "1_test.go")
+		l.Register(noop, noop, "2_test.go")
+		l.Register(noop, noop, "3_test.go")
+
+		staging := MigrationsList{}
+		staging.Register(noop, noop, "4_seed.go")
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := r.RegisterSet("staging", staging); err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err := r.UpTo(s.target)
+
+		testDB.Close()
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if strings.Join(applied, ",") != strings.Join(s.expected, ",") {
+			t.Errorf("(%d) Expected applied %v, got %v", i, s.expected, applied)
+		}
+
+		if r.TargetVersion != "" {
+			t.Errorf("(%d) Expected the TargetVersion to be unchanged, got %q", i, r.TargetVersion)
+		}
+	}
+}
+
+func TestBootstrapTargetVersion(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test.go")
+	l.Register(noop, noop, "2_test.go")
+	l.Register(noop, noop, "3_test.go")
+
+	quiet := func(r *Runner) { r.Quiet = true }
+
+	t.Setenv(TargetVersionEnv, "2_test.go")
+
+	applied, err := Bootstrap(testDB.DB, l, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(applied, ",") != "1_test.go,2_test.go" {
+		t.Fatalf("Expected 1_test.go and 2_test.go to be applied, got %v", applied)
+	}
+
+	// config flip
+	t.Setenv(TargetVersionEnv, "")
+
+	applied, err = Bootstrap(testDB.DB, l, quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(applied, ",") != "3_test.go" {
+		t.Fatalf("Expected 3_test.go to be applied, got %v", applied)
+	}
+}
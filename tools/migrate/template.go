@@ -1,5 +1,23 @@
 package migrate
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultFileExtension is the default "create" migration file extension.
+const DefaultFileExtension = ".go"
+
+// fileExtensionRegex defines the allowed Runner.FileExtension format.
+var fileExtensionRegex = regexp.MustCompile(`^\.[A-Za-z0-9]+$`)
+
+// List with the supported Runner.TemplateStyle values.
+const (
+	TemplateStyleAuto = ""
+	TemplateStyleDBX  = "dbx"
+)
+
 const createTemplateContent = `package migrations
 
 import (
@@ -19,3 +37,169 @@ func init() {
 	})
 }
 `
+
+const createSQLTemplateContent = `-- +up
+-- Add the statements that apply the migration (separated with ";").
+-- Wrap the statements containing ";" (eg. triggers) with the
+-- "-- +statement-begin" and "-- +statement-end" annotations.
+--
+-- eg. CREATE TABLE posts (id TEXT PRIMARY KEY NOT NULL, title TEXT);
+
+-- +down
+-- REMINDER: add the statements that revert the "+up" changes (in reverse
+-- order), otherwise the migration cannot be rolled back with "down".
+--
+-- eg. DROP TABLE posts;
+`
+
+const createCollectionTemplateContent = `package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+
+		collection, err := dao.FindCollectionByNameOrId(%[1]q)
+		if err != nil {
+			return err
+		}
+
+		// modify the collection (eg. collection.Schema.AddField(...))...
+
+		return dao.SaveCollection(collection)
+	}, func(db dbx.Builder) error {
+		dao := daos.New(db)
+
+		collection, err := dao.FindCollectionByNameOrId(%[1]q)
+		if err != nil {
+			return err
+		}
+
+		// revert the collection changes...
+
+		return dao.SaveCollection(collection)
+	})
+}
+`
+
+// templateContent returns the new migration template content
+// based on the runner FileExtension.
+//
+// The PocketBase import paths of the builtin Go templates are resolved
+// from the PocketBase module version required by the nearest go.mod.
+//
+// If collection is set, the template is pre-filled with the
+// boilerplate for loading, modifying and saving the collection.
+//
+// The Runner.Templates entry of the Runner.FileExtension (if any) takes
+// precedence over the builtin templates.
+func (r *Runner) templateContent(dir string, collection string) string {
+	if custom, ok := r.Templates[r.fileExtension()]; ok {
+		return custom
+	}
+
+	if r.fileExtension() == ".sql" {
+		return createSQLTemplateContent
+	}
+
+	if collection != "" {
+		return withPocketBaseImports(fmt.Sprintf(createCollectionTemplateContent, collection), dir)
+	}
+
+	return withPocketBaseImports(createTemplateContent, dir)
+}
+
+// withPocketBaseImports replaces the PocketBase import paths of the Go
+// template content with the PocketBase module path (eg. with a major
+// version suffix) required by the nearest go.mod of dir (if any).
+func withPocketBaseImports(content string, dir string) string {
+	_, goMod, ok := findGoMod(dir)
+	if !ok {
+		return content
+	}
+
+	path, ok := goModPocketBasePath(goMod)
+	if !ok || path == pocketbaseModule {
+		return content
+	}
+
+	return strings.ReplaceAll(content, `"`+pocketbaseModule+`/`, `"`+path+`/`)
+}
+
+// checkSQLTemplate validates whether the new SQL migration content
+// could be loaded back by MigrationsList.RegisterSQL.
+func checkSQLTemplate(content string) error {
+	if _, _, _, err := new(SQLParser).ParseMigration(content); err != nil {
+		return fmt.Errorf("Invalid SQL migration template: %w", err)
+	}
+
+	return nil
+}
+
+// fileExtension returns the "create" migration file extension
+// (fallbacks to DefaultFileExtension).
+func (r *Runner) fileExtension() string {
+	if r.FileExtension == "" {
+		return DefaultFileExtension
+	}
+
+	return r.FileExtension
+}
+
+// checkFileExtension validates whether the new migration files with
+// the runner FileExtension could be loaded by a MigrationsList.
+//
+// ".go" migrations are registered with MigrationsList.Register and
+// ".sql" ones with MigrationsList.RegisterFS. Any other extension
+// (eg. ".ts" that is transpiled by an external step) requires a
+// Runner.Templates entry and has to be registered by the caller.
+func (r *Runner) checkFileExtension(collection string) error {
+	ext := r.fileExtension()
+
+	if !fileExtensionRegex.MatchString(ext) {
+		return fmt.Errorf("Invalid migration file extension %q (expected a dot followed by letters or digits, eg. \".sql\")", ext)
+	}
+
+	_, hasCustom := r.Templates[ext]
+
+	if ext != ".go" && ext != ".sql" && !hasCustom {
+		return fmt.Errorf("Missing template for migration file extension %q (register one with Runner.Templates)", ext)
+	}
+
+	if collection != "" && ext != ".go" && !hasCustom {
+		return fmt.Errorf("The --collection template is available only for %q migrations", ".go")
+	}
+
+	return nil
+}
+
+// checkTemplateStyle validates whether the runner TemplateStyle
+// is one of the supported builtin template styles.
+func (r *Runner) checkTemplateStyle() error {
+	switch r.TemplateStyle {
+	case TemplateStyleAuto, TemplateStyleDBX:
+		return nil
+	default:
+		return fmt.Errorf("Unsupported migration template style %q (expected %q)", r.TemplateStyle, TemplateStyleDBX)
+	}
+}
+
+// templateHeader returns the "create --stdout" file path comment line.
+func (r *Runner) templateHeader(path string) string {
+	return r.commentPrefix() + path + "\n"
+}
+
+// commentPrefix returns the line comment prefix
+// of the "create" migration file extension.
+func (r *Runner) commentPrefix() string {
+	if r.fileExtension() == ".sql" {
+		return "-- "
+	}
+
+	return "// "
+}
@@ -0,0 +1,265 @@
+package migrate
+
+import (
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunnerTemplateContent(t *testing.T) {
+	scenarios := []struct {
+		style string
+		dir   string
+	}{
+		{TemplateStyleAuto, t.TempDir()},
+		{TemplateStyleAuto, filepath.Join(t.TempDir(), "missing")},
+		{TemplateStyleDBX, t.TempDir()},
+	}
+
+	for i, s := range scenarios {
+		r := &Runner{TemplateStyle: s.style}
+
+		if result := r.templateContent(s.dir, ""); result != createTemplateContent {
+			t.Errorf("(%d) Expected template \n%s, \ngot \n%s", i, createTemplateContent, result)
+		}
+	}
+
+	checkTemplateCompiles(t, createTemplateContent)
+}
+
+func TestRunnerCheckTemplateStyle(t *testing.T) {
+	scenarios := []struct {
+		style       string
+		expectError bool
+	}{
+		{TemplateStyleAuto, false},
+		{TemplateStyleDBX, false},
+		{"app", true},
+		{"unknown", true},
+	}
+
+	for i, s := range scenarios {
+		r := &Runner{TemplateStyle: s.style}
+
+		err := r.checkTemplateStyle()
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+	}
+}
+
+func TestRunnerCollectionTemplateContent(t *testing.T) {
+	scenarios := []struct {
+		style    string
+		expected string
+	}{
+		{TemplateStyleAuto, "dao.FindCollectionByNameOrId(\"posts\")"},
+		{TemplateStyleDBX, "dao.FindCollectionByNameOrId(\"posts\")"},
+	}
+
+	for i, s := range scenarios {
+		r := &Runner{TemplateStyle: s.style}
+
+		result := r.templateContent(t.TempDir(), "posts")
+
+		if !strings.Contains(result, s.expected) {
+			t.Errorf("(%d) Expected template to contain %q, got \n%s", i, s.expected, result)
+		}
+
+		if _, err := format.Source([]byte(result)); err != nil {
+			t.Errorf("(%d) Expected valid Go source, got %v", i, err)
+		}
+
+		checkTemplateCompiles(t, result)
+	}
+}
+
+// checkTemplateCompiles builds the provided Go migration template
+// content as a package of the current module.
+func checkTemplateCompiles(t *testing.T, content string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("Skipping the generated migration build in short mode")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Missing go binary")
+	}
+
+	// a dot prefixed dir is ignored by the "./..." package patterns
+	dir, err := os.MkdirTemp(".", ".templatecheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "1_migration.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(goBin, "build", "-o", os.DevNull, "./"+filepath.Base(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Expected the generated migration to compile, got %v:\n%s\n%s", err, out, content)
+	}
+}
+
+func TestRunnerRunCreateCollection(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+
+	r.Dir = t.TempDir()
+
+	scenarios := []struct {
+		args         []string
+		expectedFile string
+	}{
+		{[]string{"create", "add_title", "--collection", "posts"}, "*_add_title.go"},
+		{[]string{"create", "--collection=posts"}, "*_update_posts.go"},
+	}
+
+	for i, s := range scenarios {
+		if err := r.Run(s.args...); err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		files, _ := filepath.Glob(filepath.Join(r.Dir, s.expectedFile))
+		if len(files) != 1 {
+			t.Fatalf("(%d) Expected 1 created %s migration file, got %v", i, s.expectedFile, files)
+		}
+
+		content, _ := os.ReadFile(files[0])
+		if !strings.Contains(string(content), `FindCollectionByNameOrId("posts")`) {
+			t.Fatalf("(%d) Expected the collection template, got \n%s", i, content)
+		}
+	}
+
+	if err := r.Run("create", "test", "--collection"); err == nil {
+		t.Fatal("Expected missing collection name error, got nil")
+	}
+}
+
+func TestRunnerRunCreateFileExtension(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	r, err := NewRunner(testDB.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AutoConfirmCreate = true
+
+	scenarios := []struct {
+		extension       string
+		templates       map[string]string
+		args            []string
+		expectError     bool
+		expectedFile    string
+		expectedContent string
+	}{
+		{"", nil, []string{"create", "init"}, false, "*_init.go", createTemplateContent},
+		{".go", nil, []string{"create", "init"}, false, "*_init.go", createTemplateContent},
+		{".sql", nil, []string{"create", "init"}, false, "*_init.sql", createSQLTemplateContent},
+		{".ts", map[string]string{".ts": "// ts"}, []string{"create", "init"}, false, "*_init.ts", "// ts"},
+		{".sql", map[string]string{".sql": "-- +up\n-- custom\n"}, []string{"create", "init"}, false, "*_init.sql", "-- +up\n-- custom\n"},
+		{".sql", map[string]string{".sql": "-- custom"}, []string{"create", "init"}, true, "", ""},
+		{"", nil, []string{"create", "init", "--lang", "sql"}, false, "*_init.sql", createSQLTemplateContent},
+		{".sql", nil, []string{"create", "init", "--lang=go"}, false, "*_init.go", createTemplateContent},
+		{"", nil, []string{"create", "init", "--lang", "ts"}, true, "", ""},
+		{"", nil, []string{"create", "init", "--lang"}, true, "", ""},
+		{".ts", nil, []string{"create", "init"}, true, "", ""},
+		{"sql", nil, []string{"create", "init"}, true, "", ""},
+		{".s q", nil, []string{"create", "init"}, true, "", ""},
+		{".sql", nil, []string{"create", "--collection", "posts"}, true, "", ""},
+	}
+
+	for i, s := range scenarios {
+		r.Dir = t.TempDir()
+		r.FileExtension = s.extension
+		r.Templates = s.templates
+
+		err := r.Run(s.args...)
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+			continue
+		}
+
+		if hasErr {
+			continue
+		}
+
+		files, _ := filepath.Glob(filepath.Join(r.Dir, s.expectedFile))
+		if len(files) != 1 {
+			t.Errorf("(%d) Expected 1 created %s migration file, got %v", i, s.expectedFile, files)
+			continue
+		}
+
+		content, _ := os.ReadFile(files[0])
+		if string(content) != s.expectedContent {
+			t.Errorf("(%d) Expected content \n%s, \ngot \n%s", i, s.expectedContent, content)
+		}
+
+		if r.FileExtension != s.extension {
+			t.Errorf("(%d) Expected the FileExtension %q to be restored, got %q", i, s.extension, r.FileExtension)
+		}
+	}
+}
+
+func TestSQLTemplateContent(t *testing.T) {
+	up, down, hasDown, err := (&SQLParser{}).ParseMigration(createSQLTemplateContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(up) != 0 || len(down) != 0 || !hasDown {
+		t.Fatalf("Expected empty up and down sections, got %v, %v (%v)", up, down, hasDown)
+	}
+	if _, downSection, _ := strings.Cut(createSQLTemplateContent, SQLAnnotationDown); !strings.Contains(downSection, "REMINDER") {
+		t.Fatal("Expected the down section to contain a reversibility reminder")
+	}
+
+	// the generated stub is loadable and reversible as it is
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	if err := l.RegisterSQL("1_init.sql", createSQLTemplateContent); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, err := r.Up(); err != nil || len(applied) != 1 {
+		t.Fatalf("Expected the stub to be applied, got %v (%v)", applied, err)
+	}
+
+	if reverted, err := r.Down(1); err != nil || len(reverted) != 1 {
+		t.Fatalf("Expected the stub to be reverted, got %v (%v)", reverted, err)
+	}
+}
@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"github.com/pocketbase/dbx"
+)
+
+// UnconfirmedMigrations returns the applied migrations records
+// that are still waiting for confirmation (see Runner.Tentative).
+func (r *Runner) UnconfirmedMigrations() ([]AppliedMigration, error) {
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []AppliedMigration{}
+
+	for _, a := range applied {
+		if !a.Confirmed {
+			result = append(result, a)
+		}
+	}
+
+	return result, nil
+}
+
+// ConfirmApplied confirms the tentatively applied migrations with
+// the specified names (see Runner.Tentative).
+//
+// The migrations of a named set must be prefixed with the set name
+// (eg. "staging/1656512345_seed.go"), as returned by PendingMigrations.
+// If no names are specified, all unconfirmed migrations are confirmed.
+//
+// Returns the number of the confirmed migrations.
+func (r *Runner) ConfirmApplied(names ...string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	exp := dbx.And(dbx.HashExp{"confirmed": 0}, appliedRecordExp())
+
+	var where dbx.Expression = exp
+
+	if len(names) > 0 {
+		keys := make([]dbx.Expression, 0, len(names))
+
+		for _, name := range names {
+			set, file := splitMigrationName(name)
+
+			keys = append(keys, dbx.HashExp{"file": file, "set": set})
+		}
+
+		where = dbx.And(exp, dbx.Or(keys...))
+	}
+
+	var total int64
+
+	err := r.withTrackingRetry(func() error {
+		result, err := r.db.Update(r.TableName, dbx.Params{"confirmed": 1}, where).Execute()
+		if err != nil {
+			return err
+		}
+
+		total, err = result.RowsAffected()
+
+		return err
+	})
+
+	return total, err
+}
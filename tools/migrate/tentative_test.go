@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerTentative(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	calls := 0
+	up := func(db dbx.Builder) error {
+		calls++
+		return nil
+	}
+
+	l := MigrationsList{}
+	l.Register(up, nil, "1_test")
+
+	staging := MigrationsList{}
+	staging.Register(up, nil, "2_test")
+	staging.Register(up, nil, "3_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterSet("staging", staging); err != nil {
+		t.Fatal(err)
+	}
+
+	// confirmed apply
+	if err := r.saveAppliedMigration(testDB, "", "1_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Tentative = true
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	unconfirmed, err := r.UnconfirmedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unconfirmed) != 2 || unconfirmed[0].File != "2_test" || unconfirmed[1].File != "3_test" {
+		t.Fatalf("Expected 2_test and 3_test to be unconfirmed, got %v", unconfirmed)
+	}
+
+	// the unconfirmed migrations are still treated as applied
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected 2 migration calls, got %d", calls)
+	}
+
+	var b strings.Builder
+	if err := r.printTree(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "[unconfirmed]") != 2 {
+		t.Fatalf("Expected 2 unconfirmed flags, got \n%s", b.String())
+	}
+
+	scenarios := []struct {
+		names         []string
+		expectedTotal int64
+	}{
+		{[]string{"2_test"}, 0}, // not in the default set
+		{[]string{"staging/2_test", "1_test"}, 1},
+		{nil, 1},
+		{nil, 0},
+	}
+
+	for i, s := range scenarios {
+		total, err := r.ConfirmApplied(s.names...)
+		if err != nil {
+			t.Fatalf("(%d) %v", i, err)
+		}
+
+		if total != s.expectedTotal {
+			t.Errorf("(%d) Expected %d confirmed migrations, got %d", i, s.expectedTotal, total)
+		}
+	}
+
+	unconfirmed, err = r.UnconfirmedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unconfirmed) != 0 {
+		t.Fatalf("Expected no unconfirmed migrations, got %v", unconfirmed)
+	}
+}
@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// timingsTotal is the Runner.TimingsWriter statement
+// column value of the migration total duration rows.
+const timingsTotal = "(total)"
+
+type timingsRow struct {
+	file      string
+	statement string
+	duration  time.Duration
+}
+
+// timingsRecorder collects the migrations and their
+// statements durations of a single Up run.
+type timingsRecorder struct {
+	rows    []timingsRow
+	current string
+}
+
+// startTimings starts recording the durations of the current run
+// migrations (and their statements executed with the runner db).
+//
+// The returned func restores the runner db and writes the recorded
+// timings to the Runner.TimingsWriter.
+func (r *Runner) startTimings() func() error {
+	recorder := &timingsRecorder{}
+
+	original := r.db
+
+	db := original.Clone()
+	db.QueryLogFunc = func(ctx context.Context, t time.Duration, sql string, rows *sql.Rows, err error) {
+		recorder.recordStatement(sql, t)
+		if original.QueryLogFunc != nil {
+			original.QueryLogFunc(ctx, t, sql, rows, err)
+		}
+	}
+	db.ExecLogFunc = func(ctx context.Context, t time.Duration, sql string, result sql.Result, err error) {
+		recorder.recordStatement(sql, t)
+		if original.ExecLogFunc != nil {
+			original.ExecLogFunc(ctx, t, sql, result, err)
+		}
+	}
+
+	r.db = db
+	r.timings = recorder
+
+	return func() error {
+		r.db = original
+		r.timings = nil
+
+		return recorder.write(r.TimingsWriter)
+	}
+}
+
+// measure executes fn as the provided migration
+// and records its statements and total durations.
+func (t *timingsRecorder) measure(m *Migration, fn func() error) error {
+	if t == nil {
+		return fn()
+	}
+
+	t.current = migrationName(m)
+	defer func() { t.current = "" }()
+
+	start := time.Now()
+
+	err := fn()
+
+	t.rows = append(t.rows, timingsRow{file: t.current, statement: timingsTotal, duration: time.Since(start)})
+
+	return err
+}
+
+// recordStatement records the statement if it is executed
+// as part of a migration (eg. not a tracking table query).
+func (t *timingsRecorder) recordStatement(statement string, duration time.Duration) {
+	if t.current == "" {
+		return
+	}
+
+	t.rows = append(t.rows, timingsRow{file: t.current, statement: statement, duration: duration})
+}
+
+// write writes the recorded timings to w as tab separated values
+// with "file", "statement" and "duration_us" (microseconds) columns.
+//
+// Each migration statements rows are followed by a "(total)" row
+// with the migration total duration.
+func (t *timingsRecorder) write(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("file\tstatement\tduration_us\n")
+
+	for _, row := range t.rows {
+		fmt.Fprintf(
+			&b,
+			"%s\t%s\t%d\n",
+			row.file,
+			strings.Join(strings.Fields(row.statement), " "),
+			row.duration.Microseconds(),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+func TestRunnerTimings(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error {
+		_, err := db.NewQuery("CREATE TABLE demo (\n\tid TEXT\n)").Execute()
+		return err
+	}, nil, "1_test")
+	l.Register(func(db dbx.Builder) error {
+		_, err := db.Insert("demo", dbx.Params{"id": "test"}).Execute()
+		return err
+	}, nil, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "timings.tsv")
+
+	if err := r.Run("up", "--timings", output); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.TimingsWriter != nil {
+		t.Fatal("Expected TimingsWriter to be restored after the run")
+	}
+
+	if r.db != testDB.DB {
+		t.Fatal("Expected the runner db to be restored after the run")
+	}
+
+	raw, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+
+	expected := []string{
+		"file\tstatement\tduration_us",
+		"1_test\tCREATE TABLE demo ( id TEXT )\t",
+		"1_test\t(total)\t",
+		"2_test\tINSERT INTO `demo` (`id`) VALUES ('test')\t",
+		"2_test\t(total)\t",
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got \n%s", len(expected), raw)
+	}
+
+	for i, e := range expected {
+		if !strings.HasPrefix(lines[i], e) {
+			t.Errorf("(%d) Expected line to start with %q, got %q", i, e, lines[i])
+		}
+	}
+
+	// the original db log funcs should be still called
+	if !list.ExistInSlice("CREATE TABLE demo (\n\tid TEXT\n)", testDB.CalledQueries) {
+		t.Fatalf("Expected the migration query to be logged by the original db, got %v", testDB.CalledQueries)
+	}
+}
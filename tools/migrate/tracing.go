@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer defines a minimal tracing interface for instrumenting the
+// migrations runs (see Runner.Tracer).
+//
+// It is intentionally not tied to a specific tracing library and for
+// OpenTelemetry it could be implemented with a small trace.Tracer adapter, eg.:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, migrate.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value any) {
+//		s.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+type Tracer interface {
+	// Start creates a new span (as a child of the ctx span, if any)
+	// and returns it with its derived context.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span defines a single traced operation created by a Tracer.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// List with the migrations tracing span names.
+const (
+	SpanNameUp        = "migrate.up"
+	SpanNameDown      = "migrate.down"
+	SpanNameMigration = "migrate.migration"
+)
+
+// startRunSpan starts the span of the current Up or Down run
+// (if Runner.Tracer is set) and returns a func that ends it.
+func (r *Runner) startRunSpan(direction string) func(count int, err error) {
+	if r.Tracer == nil {
+		return func(count int, err error) {}
+	}
+
+	name := SpanNameUp
+	if direction == DirectionDown {
+		name = SpanNameDown
+	}
+
+	ctx, span := r.Tracer.Start(r.executorContext(), name)
+	span.SetAttribute("migration.direction", direction)
+	span.SetAttribute("migration.run_id", r.runId)
+
+	r.traceCtx = ctx
+
+	return func(count int, err error) {
+		r.traceCtx = nil
+
+		span.SetAttribute("migration.count", count)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
+
+// traceMigration executes fn within a single migration child span
+// of the current run span (if Runner.Tracer is set).
+func (r *Runner) traceMigration(m *Migration, direction string, fn func() error) error {
+	if r.Tracer == nil || r.traceCtx == nil {
+		return fn()
+	}
+
+	_, span := r.Tracer.Start(r.traceCtx, SpanNameMigration)
+	defer span.End()
+
+	span.SetAttribute("migration.file", migrationName(m))
+	span.SetAttribute("migration.direction", direction)
+
+	start := time.Now()
+
+	err := fn()
+
+	span.SetAttribute("migration.duration_ms", float64(time.Since(start).Microseconds())/1000)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
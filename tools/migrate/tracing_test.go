@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *testSpan) RecordError(err error)              { s.err = err }
+func (s *testSpan) End()                               { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: map[string]any{}}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	t.spans = append(t.spans, span)
+
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func (t *testTracer) summary() string {
+	lines := []string{}
+	for _, s := range t.spans {
+		line := fmt.Sprintf("%s<%s %v %v", s.name, s.parent, s.attrs["migration.file"], s.attrs["migration.direction"])
+		if count, ok := s.attrs["migration.count"]; ok {
+			line += fmt.Sprintf(" count=%v", count)
+		}
+		if s.err != nil {
+			line += " error"
+		}
+		if !s.ended {
+			line += " not-ended"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestRunnerTracer(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+
+	l := MigrationsList{}
+	l.Register(noop, noop, "1_test")
+	l.Register(noop, noop, "2_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := &testTracer{}
+	r.Tracer = tracer
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Down(1); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"migrate.up< <nil> up count=2",
+		"migrate.migration<migrate.up 1_test up",
+		"migrate.migration<migrate.up 2_test up",
+		"migrate.down< <nil> down count=1",
+		"migrate.migration<migrate.down 2_test down",
+	}, "\n")
+
+	if summary := tracer.summary(); summary != expected {
+		t.Fatalf("Expected spans \n%s, \ngot \n%s", expected, summary)
+	}
+
+	if _, ok := tracer.spans[1].attrs["migration.duration_ms"].(float64); !ok {
+		t.Fatalf("Expected the migration duration attribute, got %v", tracer.spans[1].attrs)
+	}
+
+	// failure
+	tracer.spans = nil
+	r.migrationsList.(*MigrationsList).Register(func(db dbx.Builder) error {
+		return errors.New("test")
+	}, nil, "3_test")
+
+	if _, err := r.Up(); err == nil {
+		t.Fatal("Expected the failed migration error, got nil")
+	}
+
+	expected = strings.Join([]string{
+		"migrate.up< <nil> up count=0 error",
+		"migrate.migration<migrate.up 2_test up",
+		"migrate.migration<migrate.up 3_test up error",
+	}, "\n")
+
+	if summary := tracer.summary(); summary != expected {
+		t.Fatalf("Expected spans \n%s, \ngot \n%s", expected, summary)
+	}
+
+	if r.traceCtx != nil {
+		t.Fatal("Expected the run trace context to be cleared")
+	}
+}
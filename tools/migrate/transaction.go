@@ -0,0 +1,72 @@
+package migrate
+
+import "fmt"
+
+// TransactionMode defines the transaction preference of a single
+// migration that overrides the runner default (see Migration.Transaction).
+type TransactionMode string
+
+// List with the supported Migration.Transaction modes.
+const (
+	// TransactionDefault executes the migration according to the runner
+	// PerMigrationTx and CommitEvery settings.
+	TransactionDefault TransactionMode = ""
+
+	// TransactionBatch executes the migration in a shared transaction
+	// with its adjacent batch migrations, even if PerMigrationTx is set.
+	TransactionBatch TransactionMode = "batch"
+
+	// TransactionOwn executes the migration in its own transaction,
+	// even if PerMigrationTx is not set.
+	TransactionOwn TransactionMode = "own"
+
+	// TransactionNone executes the migration outside of a transaction
+	// (same as Migration.NonTransactional).
+	TransactionNone TransactionMode = "none"
+)
+
+// transactionMode returns the effective transaction mode of m
+// based on its own preference and on the runner defaults.
+func (r *Runner) transactionMode(m *Migration) TransactionMode {
+	switch {
+	case m.NonTransactional || m.Transaction == TransactionNone:
+		return TransactionNone
+	case m.DisableForeignKeys || m.Transaction == TransactionOwn:
+		return TransactionOwn
+	case m.Transaction == TransactionBatch:
+		return TransactionBatch
+	case r.PerMigrationTx:
+		return TransactionOwn
+	default:
+		return TransactionBatch
+	}
+}
+
+// checkTransactionModes returns an error if any of the provided
+// migrations has an unknown or a conflicting transaction mode.
+//
+// The TransactionNone migrations are also rejected within a caller
+// provided transaction since they cannot be executed outside of it.
+func (r *Runner) checkTransactionModes(migrations []*Migration) error {
+	for _, m := range migrations {
+		switch m.Transaction {
+		case TransactionDefault, TransactionBatch, TransactionOwn, TransactionNone:
+		default:
+			return fmt.Errorf("Migration %s has unknown transaction mode %q", m.File, m.Transaction)
+		}
+
+		if m.NonTransactional && m.Transaction != TransactionDefault && m.Transaction != TransactionNone {
+			return fmt.Errorf("NonTransactional migration %s cannot have %q transaction mode", m.File, m.Transaction)
+		}
+
+		if m.DisableForeignKeys && (m.Transaction == TransactionNone || m.Transaction == TransactionBatch) {
+			return fmt.Errorf("Migration %s with DisableForeignKeys must be executed in its own transaction (got %q)", m.File, m.Transaction)
+		}
+
+		if r.tx != nil && m.Transaction == TransactionNone {
+			return fmt.Errorf("Migration %s with %q transaction mode cannot be executed in a caller provided transaction", m.File, m.Transaction)
+		}
+	}
+
+	return nil
+}
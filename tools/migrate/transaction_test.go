@@ -0,0 +1,206 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerTransactionModes(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+	fail := func(db dbx.Builder) error { return errors.New("test error") }
+
+	scenarios := []struct {
+		name           string
+		perMigrationTx bool
+		migrations     []*Migration
+		expected       []string // the applied migrations after the failure
+	}{
+		{
+			"per migration tx with batch migrations",
+			true,
+			[]*Migration{
+				{File: "1_test.go", Up: noop},
+				{File: "2_test.go", Up: noop, Transaction: TransactionBatch},
+				{File: "3_test.go", Up: fail, Transaction: TransactionBatch},
+			},
+			[]string{"1_test.go"},
+		},
+		{
+			"single tx with own migration",
+			false,
+			[]*Migration{
+				{File: "1_test.go", Up: noop},
+				{File: "2_test.go", Up: noop, Transaction: TransactionOwn},
+				{File: "3_test.go", Up: noop},
+				{File: "4_test.go", Up: fail},
+			},
+			[]string{"1_test.go", "2_test.go"},
+		},
+		{
+			"single tx with none migration",
+			false,
+			[]*Migration{
+				{File: "1_test.go", Up: noop},
+				{File: "2_test.go", Up: noop, Transaction: TransactionNone},
+				{File: "3_test.go", Up: fail},
+			},
+			[]string{"1_test.go", "2_test.go"},
+		},
+		{
+			"failed own migration",
+			false,
+			[]*Migration{
+				{File: "1_test.go", Up: noop},
+				{File: "2_test.go", Up: fail, Transaction: TransactionOwn},
+			},
+			[]string{"1_test.go"},
+		},
+	}
+
+	for _, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := MigrationsList{}
+		for _, m := range s.migrations {
+			l.Add(m)
+		}
+
+		r, err := NewRunner(testDB.DB, l, func(r *Runner) { r.PerMigrationTx = s.perMigrationTx })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := r.Up(); err == nil {
+			t.Errorf("[%s] Expected error, got nil", s.name)
+		}
+
+		applied := []string{}
+		for _, m := range s.migrations {
+			if r.isMigrationApplied(testDB, "", m.File) {
+				applied = append(applied, m.File)
+			}
+		}
+
+		if strings.Join(applied, ",") != strings.Join(s.expected, ",") {
+			t.Errorf("[%s] Expected applied %v, got %v", s.name, s.expected, applied)
+		}
+
+		testDB.Close()
+	}
+}
+
+func TestRunnerTransactionBatchContinueOnError(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	noop := func(db dbx.Builder) error { return nil }
+	fail := func(db dbx.Builder) error { return errors.New("test error") }
+
+	l := MigrationsList{}
+	l.Add(&Migration{File: "1_test.go", Up: noop})
+	l.Add(&Migration{File: "2_test.go", Up: noop, Transaction: TransactionBatch})
+	l.Add(&Migration{File: "3_test.go", Up: fail, Transaction: TransactionBatch})
+	l.Add(&Migration{File: "4_test.go", Up: noop})
+
+	r, err := NewRunner(testDB.DB, l, func(r *Runner) {
+		r.PerMigrationTx = true
+		r.ContinueOnError = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appliedEvents := []string{}
+	r.OnEvent = func(e MigrationEvent) {
+		if e.Type == MigrationEventApplied {
+			appliedEvents = append(appliedEvents, e.File)
+		}
+	}
+
+	applied, err := r.Up()
+
+	var migrationsErr *MigrationsError
+	if !errors.As(err, &migrationsErr) {
+		t.Fatalf("Expected *MigrationsError, got %v", err)
+	}
+
+	// 2_test.go is rolled back together with the failed 3_test.go
+	if strings.Join(applied, ",") != "1_test.go,4_test.go" {
+		t.Fatalf("Expected only the committed migrations to be returned, got %v", applied)
+	}
+
+	if strings.Join(appliedEvents, ",") != "1_test.go,4_test.go" {
+		t.Fatalf("Expected applied events only for the committed migrations, got %v", appliedEvents)
+	}
+
+	if r.isMigrationApplied(testDB, "", "2_test.go") {
+		t.Fatal("Expected 2_test.go to be rolled back")
+	}
+}
+
+func TestRunnerCheckTransactionModes(t *testing.T) {
+	noop := func(db dbx.Builder) error { return nil }
+
+	scenarios := []struct {
+		migration   *Migration
+		useTx       bool
+		expectError bool
+	}{
+		{&Migration{File: "1_test.go", Up: noop}, false, false},
+		{&Migration{File: "1_test.go", Up: noop, Transaction: "invalid"}, false, true},
+		{&Migration{File: "1_test.go", Up: noop, NonTransactional: true, Transaction: TransactionNone}, false, false},
+		{&Migration{File: "1_test.go", Up: noop, NonTransactional: true, Transaction: TransactionBatch}, false, true},
+		{&Migration{File: "1_test.go", Up: noop, NonTransactional: true, Transaction: TransactionOwn}, false, true},
+		{&Migration{File: "1_test.go", Up: noop, DisableForeignKeys: true, Transaction: TransactionOwn}, false, false},
+		{&Migration{File: "1_test.go", Up: noop, DisableForeignKeys: true, Transaction: TransactionBatch}, false, true},
+		{&Migration{File: "1_test.go", Up: noop, DisableForeignKeys: true, Transaction: TransactionNone}, false, true},
+		// nested in a caller provided transaction
+		{&Migration{File: "1_test.go", Up: noop, Transaction: TransactionBatch}, true, false},
+		{&Migration{File: "1_test.go", Up: noop, Transaction: TransactionOwn}, true, false},
+		{&Migration{File: "1_test.go", Up: noop, Transaction: TransactionNone}, true, true},
+	}
+
+	for i, s := range scenarios {
+		testDB, err := createTestDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := MigrationsList{}
+		l.Add(s.migration)
+
+		r, err := NewRunner(testDB.DB, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.useTx {
+			err = testDB.Transactional(func(tx *dbx.Tx) error {
+				_, err := r.UpTx(tx)
+				return err
+			})
+		} else {
+			_, err = r.Up()
+		}
+
+		hasErr := err != nil
+		if hasErr != s.expectError {
+			t.Errorf("(%d) Expected hasErr %v, got %v (%v)", i, s.expectError, hasErr, err)
+		}
+
+		if applied := r.isMigrationApplied(testDB, "", s.migration.File); applied == s.expectError {
+			t.Errorf("(%d) Expected applied %v, got %v", i, !s.expectError, applied)
+		}
+
+		testDB.Close()
+	}
+}
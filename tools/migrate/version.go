@@ -0,0 +1,32 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// schemaVersionLength is the number of hex characters of the SchemaVersion digest.
+const schemaVersionLength = 16
+
+// SchemaVersion returns a short stable digest of all applied migrations
+// (regardless of whether they are registered with the runner).
+//
+// The digest depends on the applied migrations file names (and their
+// sets) in their apply order, but not on the exact apply time, meaning
+// that two databases with the same migrations applied in the same order
+// have the same schema version, which could be used for quick drift detection.
+func (r *Runner) SchemaVersion() (string, error) {
+	applied, err := r.AppliedMigrations()
+	if err != nil {
+		return "", err
+	}
+
+	// the applied migrations are already in their apply order
+	h := sha256.New()
+	for _, a := range applied {
+		h.Write([]byte(a.Set + "/" + a.File))
+		h.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:schemaVersionLength], nil
+}
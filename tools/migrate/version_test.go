@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"testing"
+)
+
+func TestRunnerSchemaVersion(t *testing.T) {
+	db1, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db1.Close()
+
+	db2, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Close()
+
+	r1, err := NewRunner(db1.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := NewRunner(db2.DB, MigrationsList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty, err := r1.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty) != schemaVersionLength {
+		t.Fatalf("Expected %d characters version, got %q", schemaVersionLength, empty)
+	}
+
+	// same migrations applied in the same order (at different time)
+	r1.saveAppliedMigrationAt(db1, "", "1_test", 100)
+	r1.saveAppliedMigrationAt(db1, "", "2_test", 200)
+	r2.saveAppliedMigrationAt(db2, "", "1_test", 300)
+	r2.saveAppliedMigrationAt(db2, "", "2_test", 400)
+
+	v1, _ := r1.SchemaVersion()
+	v2, _ := r2.SchemaVersion()
+	if v1 != v2 {
+		t.Fatalf("Expected the same versions, got %q and %q", v1, v2)
+	}
+	if v1 == empty {
+		t.Fatalf("Expected the version to change after applying migrations, got %q", v1)
+	}
+
+	// same migrations applied in different order
+	r2.saveRevertedMigration(db2, "", "1_test")
+	r2.saveAppliedMigrationAt(db2, "", "1_test", 500)
+	if v2, _ = r2.SchemaVersion(); v1 == v2 {
+		t.Fatalf("Expected different versions for different apply order, got %q", v1)
+	}
+
+	// same file in different set
+	r1.saveRevertedMigration(db1, "", "2_test")
+	r1.saveAppliedMigrationAt(db1, "demo", "2_test", 200)
+	if v, _ := r1.SchemaVersion(); v == v1 {
+		t.Fatalf("Expected different versions for different sets, got %q", v)
+	}
+}
@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookTimeout is the default timeout of a single webhook event delivery.
+const DefaultWebhookTimeout = 5 * time.Second
+
+// webhookQueueSize is the max number of the not yet delivered
+// webhook events (the newer events are dropped when it is full).
+const webhookQueueSize = 100
+
+// webhookPayload defines the JSON body of a single webhook event request.
+type webhookPayload struct {
+	Type  string    `json:"type"`
+	File  string    `json:"file,omitempty"`
+	Error string    `json:"error,omitempty"`
+	RunId string    `json:"runId"`
+	Time  time.Time `json:"time"`
+}
+
+// Webhook is a best-effort poster of the migration events to an
+// external HTTP endpoint (eg. an ops platform events timeline).
+//
+// Each event is sent as a JSON POST request in a separate goroutine,
+// so a slow or failing endpoint never stalls the migrations run:
+//
+//	webhook := migrate.NewWebhook("https://example.com/events")
+//	defer webhook.Close()
+//
+//	runner.OnEvent = webhook.OnEvent
+type Webhook struct {
+	// Client is the HTTP client used to deliver the events
+	// (default to a client with DefaultWebhookTimeout).
+	//
+	// It must be changed before the first OnEvent call.
+	Client *http.Client
+
+	url    string
+	queue  chan webhookPayload
+	done   chan struct{}
+	start  sync.Once
+	mu     sync.Mutex
+	closed bool
+
+	// failed and dropped are the number of the undelivered events
+	failed  int
+	dropped int
+}
+
+// NewWebhook creates a new Webhook that posts the events to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		Client: &http.Client{Timeout: DefaultWebhookTimeout},
+		url:    url,
+		queue:  make(chan webhookPayload, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// OnEvent queues the event for delivery without blocking
+// (it could be used directly as Runner.OnEvent).
+//
+// The event is dropped if the webhook is closed or its queue is full.
+func (w *Webhook) OnEvent(e MigrationEvent) {
+	w.start.Do(func() { go w.deliver() })
+
+	payload := webhookPayload{Type: e.Type, File: e.File, RunId: e.RunId, Time: time.Now().UTC()}
+	if e.Error != nil {
+		payload.Error = e.Error.Error()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		w.dropped++
+		return
+	}
+
+	select {
+	case w.queue <- payload:
+	default:
+		w.dropped++
+	}
+}
+
+// Close stops accepting new events and waits for the
+// delivery of the already queued ones.
+//
+// Returns an error if some of the events were not delivered.
+func (w *Webhook) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	w.start.Do(func() { go w.deliver() })
+
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failed > 0 || w.dropped > 0 {
+		return fmt.Errorf("Failed to deliver %d webhook event(s) (%d dropped)", w.failed+w.dropped, w.dropped)
+	}
+
+	return nil
+}
+
+// deliver posts the queued events until the queue is closed.
+func (w *Webhook) deliver() {
+	defer close(w.done)
+
+	for payload := range w.queue {
+		if err := w.post(payload); err != nil {
+			w.mu.Lock()
+			w.failed++
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *Webhook) post(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Unexpected webhook response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+)
+
+func TestRunnerOnEvent(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []string{}
+	r.OnEvent = func(e MigrationEvent) {
+		if e.RunId == "" {
+			t.Errorf("Expected the %s event run id to be set", e.Type)
+		}
+		events = append(events, e.Type+":"+e.File)
+	}
+
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "run_started:,started:1_test,applied:1_test,committed:"
+	if strings.Join(events, ",") != expected {
+		t.Fatalf("Expected events %s, got %v", expected, events)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	testDB, err := createTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	received := []webhookPayload{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// simulate a slow endpoint
+		<-release
+
+		payload := webhookPayload{}
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode the webhook payload: %v", err)
+		}
+
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected application/json content type, got %q", ct)
+		}
+
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+
+		if payload.Type == MigrationEventFailed {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	l := MigrationsList{}
+	l.Register(func(db dbx.Builder) error { return nil }, nil, "1_test")
+
+	r, err := NewRunner(testDB.DB, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhook := NewWebhook(server.URL)
+	r.OnEvent = webhook.OnEvent
+
+	// the run is not blocked by the slow endpoint
+	if _, err := r.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+
+	if err := webhook.Close(); err != nil {
+		t.Fatalf("Expected all events to be delivered, got %v", err)
+	}
+
+	types := []string{}
+	for _, p := range received {
+		types = append(types, p.Type)
+		if p.RunId != r.LastRunId() || p.Time.IsZero() {
+			t.Errorf("Expected the run id and time to be set, got %v", p)
+		}
+	}
+
+	if expected := "run_started,started,applied,committed"; strings.Join(types, ",") != expected {
+		t.Fatalf("Expected events %s, got %v", expected, types)
+	}
+
+	// the events after close are dropped
+	webhook.OnEvent(MigrationEvent{Type: MigrationEventStarted})
+	if err := webhook.Close(); err == nil || !strings.Contains(err.Error(), "1 dropped") {
+		t.Fatalf("Expected dropped event error, got %v", err)
+	}
+
+	// failed deliveries are reported
+	failing := NewWebhook(server.URL)
+	failing.OnEvent(MigrationEvent{Type: MigrationEventFailed})
+	if err := failing.Close(); err == nil {
+		t.Fatal("Expected failed delivery error, got nil")
+	}
+}